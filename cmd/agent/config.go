@@ -1,99 +1,131 @@
 package main
 
 import (
-	"flag"
-
-	"github.com/ilyakaznacheev/cleanenv"
+	"fmt"
+	"os"
+	"time"
 
 	configpkg "github.com/idudko/go-musthave-metrics/internal/config"
 )
 
-// JSONConfig represents configuration from JSON file
-type JSONConfig struct {
-	Address        string `json:"address"`
-	ReportInterval string `json:"report_interval"`
-	PollInterval   string `json:"poll_interval"`
-	CryptoKey      string `json:"crypto_key"`
-	GrpcAddress    string `json:"grpc_address"`
-}
-
-// Config represents the full configuration with all sources
+// Config represents the agent's full configuration, merged from defaults,
+// an optional JSON/YAML/TOML config file, environment variables, and
+// command-line flags via configpkg.Loader (file/env/flag tags document
+// each field's source name; see NewConfig).
 type Config struct {
-	Address        string `env:"ADDRESS"`
-	PollInterval   int    `env:"POLL_INTERVAL"`
-	ReportInterval int    `env:"REPORT_INTERVAL"`
-	UseBatch       bool   `env:"BATCH"`
-	Key            string `env:"KEY"`
-	RateLimit      int    `env:"RATE_LIMIT"`
-	CryptoKey      string `env:"CRYPTO_KEY"`
-	GrpcAddress    string `env:"GRPC_ADDRESS"`
+	Address        string        `json:"address" env:"ADDRESS" flag:"a" default:"localhost:8080" usage:"HTTP server address"`
+	PollInterval   time.Duration `json:"poll_interval" env:"POLL_INTERVAL" flag:"p" default:"2s" usage:"Poll interval (e.g. 2s, 500ms)"`
+	ReportInterval time.Duration `json:"report_interval" env:"REPORT_INTERVAL" flag:"r" default:"10s" usage:"Report interval (e.g. 10s, 1m)"`
+	UseBatch       bool          `env:"BATCH" flag:"b" default:"true" usage:"Use batch reporting"`
+	Key            string        `env:"KEY" flag:"k" default:"" secret:"true" usage:"Key for signing requests"`
+	RateLimit      int           `env:"RATE_LIMIT" flag:"l" default:"1" usage:"Rate limit for concurrent requests"`
+	CryptoKey      string        `json:"crypto_key" env:"CRYPTO_KEY" flag:"crypto-key" default:"" usage:"Path to public key file for encryption"`
+	GrpcAddress    string        `json:"grpc_address" env:"GRPC_ADDRESS" flag:"grpc-addr" default:"" usage:"gRPC server address"`
+
+	// Transport selects how metrics reach the server: "http" (the default,
+	// to Address) or "grpc" (to GrpcAddress). Output and StatsdAddr only
+	// apply to the http transport; Inputs applies to both.
+	Transport  string `json:"transport" env:"TRANSPORT" flag:"transport" default:"http" usage:"Report transport: http or grpc"`
+	Output     string `json:"output" env:"OUTPUT" flag:"o" default:"http" usage:"Report output backend: http or statsd, used when -transport=http"`
+	StatsdAddr string `json:"statsd_addr" env:"STATSD_ADDR" flag:"statsd-addr" default:"localhost:8125" usage:"StatsD/DogStatsD server address (host:port), used when -o=statsd"`
+	Inputs     string `json:"inputs" env:"INPUTS" flag:"inputs" default:"runtime,cpu,mem,disk,process" usage:"Comma-separated list of enabled collector inputs"`
+	HashAlgo   string `json:"hash_algo" env:"HASH_ALGO" flag:"hash-algo" default:"HashSHA256" usage:"Signing algorithm header to use, used when -o=http and no encryption is configured"`
+	AuthToken  string `json:"auth_token" env:"AUTH_TOKEN" flag:"auth-token" default:"" secret:"true" usage:"Bearer token sent in the Authorization header, used when -transport=http"`
+
+	// Scheme selects the URL scheme used when talking to the HTTP server
+	// ("http" or "https"). CertFingerprint, if set, pins the server's
+	// certificate to that SHA-256 fingerprint instead of validating it
+	// against the system trust store.
+	Scheme          string `json:"scheme" env:"SCHEME" flag:"scheme" default:"http" usage:"URL scheme to use when talking to the HTTP server (http or https)"`
+	CertFingerprint string `json:"cert_fingerprint" env:"CERT_FINGERPRINT" flag:"cert-fingerprint" default:"" usage:"Expected SHA-256 fingerprint of the server's TLS certificate (pins trust instead of using the system store)"`
+
+	// ConfigFile is the path to the config file, resolved from -c/-config or
+	// the CONFIG env var before any other field is loaded. ConfigFormat
+	// selects its decoder ("json", "yaml", or "toml"), resolved the same way
+	// from -config-format or CONFIG_FORMAT, falling back to ConfigFile's
+	// extension.
+	ConfigFile   string `flag:"c,config" usage:"Path to config file"`
+	ConfigFormat string `flag:"config-format" usage:"Config file format: json, yaml, or toml (default: inferred from file extension)"`
 
-	// ConfigFile is the path to the configuration file if specified
-	ConfigFile string
+	// PrintConfig and DryRun let an operator inspect the fully-merged
+	// configuration (with Key masked) or validate it, without running the
+	// agent; see EffectiveJSON.
+	PrintConfig bool `flag:"print-config" default:"false" usage:"Print the effective configuration as JSON and exit"`
+	DryRun      bool `flag:"dry-run" default:"false" usage:"Validate the configuration and exit without starting the agent"`
 }
 
-// NewConfig initializes and returns configuration from all sources.
-// Priority order (lowest to highest):
-// 1. Default values
-// 2. JSON config file (if provided via -c or -config or CONFIG env var)
-// 3. Environment variables
-// 4. Command line flags (highest priority)
-//
-// Returns a pointer to the initialized Config structure.
-func NewConfig() (*Config, error) {
-	cfg := &Config{
-		Address:        "localhost:8080",
-		PollInterval:   2,
-		ReportInterval: 10,
-		UseBatch:       true,
-		Key:            "",
-		RateLimit:      1,
-		CryptoKey:      "",
-		GrpcAddress:    "",
-	}
+// NewConfig builds a Config from args (typically os.Args[1:]): flags are
+// parsed first to find -c/-config (falling back to the CONFIG env var) and
+// -config-format (falling back to CONFIG_FORMAT, then the file's
+// extension), then that file, environment variables, and the already-parsed
+// flags are merged in ascending priority - defaults, file, env, flags - via
+// configpkg.Loader. The result is validated before being returned. If
+// -print-config or -dry-run is set, NewConfig prints the effective
+// configuration (for -print-config) and exits the process with status 0
+// once validation succeeds, instead of returning, so a caller never starts
+// the agent on either flag.
+func NewConfig(args []string) (*Config, error) {
+	cfg := &Config{}
+	loader := &configpkg.Loader{}
 
-	// Register flags with default values
-	flag.StringVar(&cfg.Address, "a", "localhost:8080", "HTTP address to listen on")
-	flag.IntVar(&cfg.PollInterval, "p", 2, "Poll interval in seconds")
-	flag.IntVar(&cfg.ReportInterval, "r", 10, "Report interval in seconds")
-	flag.BoolVar(&cfg.UseBatch, "b", true, "Use batch reporting")
-	flag.StringVar(&cfg.Key, "k", "", "Key for signing requests")
-	flag.IntVar(&cfg.RateLimit, "l", 1, "Rate limit for concurrent requests")
-	flag.StringVar(&cfg.CryptoKey, "crypto-key", "", "Path to public key file for encryption")
-	flag.StringVar(&cfg.GrpcAddress, "g", "", "gRPC server address")
+	visited, err := loader.ParseFlags(cfg, args)
+	if err != nil {
+		return nil, err
+	}
 
-	var configFileFlag string
-	flag.StringVar(&configFileFlag, "c", "", "Path to config file")
-	flag.StringVar(&configFileFlag, "config", "", "Path to config file")
-	flag.Parse()
+	configPath := configpkg.GetConfigFilePath(cfg.ConfigFile)
+	configFormat := configpkg.GetConfigFormat(cfg.ConfigFormat, configPath)
+	if err := loader.Load(cfg, configPath, configFormat, visited); err != nil {
+		return nil, err
+	}
 
-	// Get config file path from flag or environment variable
-	cfg.ConfigFile = configpkg.GetConfigFilePath(configFileFlag)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 
-	// Load JSON config if file is specified (lower priority than env/flags)
-	if cfg.ConfigFile != "" {
-		var jsonCfg JSONConfig
-		if err := configpkg.LoadConfigFile(cfg.ConfigFile, &jsonCfg); err != nil {
+	if cfg.PrintConfig {
+		data, err := cfg.EffectiveJSON()
+		if err != nil {
 			return nil, err
 		}
-		cfg.applyJSONConfig(&jsonCfg)
+		fmt.Println(string(data))
+		os.Exit(0)
 	}
-
-	// Apply environment variables (higher priority than config file, lower than flags)
-	if err := cleanenv.ReadEnv(cfg); err != nil {
-		return nil, err
+	if cfg.DryRun {
+		os.Exit(0)
 	}
 
 	return cfg, nil
 }
 
-// applyJSONConfig applies config from JSON file with lower priority than env/flags
-// Only applies values if the current value is still the default
-func (c *Config) applyJSONConfig(cfg *JSONConfig) {
-	// Apply JSON config values only if current values are still default
-	configpkg.ApplyStringIfDefault(&c.Address, "localhost:8080", cfg.Address)
-	configpkg.ApplyDurationIfDefault(&c.PollInterval, 2, cfg.PollInterval)
-	configpkg.ApplyDurationIfDefault(&c.ReportInterval, 10, cfg.ReportInterval)
-	configpkg.ApplyStringIfDefault(&c.CryptoKey, "", cfg.CryptoKey)
-	configpkg.ApplyStringIfDefault(&c.GrpcAddress, "", cfg.GrpcAddress)
+// Validate reports an error for any field combination NewConfig can't
+// safely run with.
+func (c *Config) Validate() error {
+	if c.Address == "" {
+		return fmt.Errorf("config: address must not be empty")
+	}
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("config: poll interval must be positive, got %s", c.PollInterval)
+	}
+	if c.ReportInterval <= 0 {
+		return fmt.Errorf("config: report interval must be positive, got %s", c.ReportInterval)
+	}
+	if c.RateLimit < 1 {
+		return fmt.Errorf("config: rate limit must be at least 1, got %d", c.RateLimit)
+	}
+	if c.Scheme != "http" && c.Scheme != "https" {
+		return fmt.Errorf("config: scheme must be http or https, got %q", c.Scheme)
+	}
+	if c.CryptoKey != "" {
+		if err := configpkg.ValidatePEMFile(c.CryptoKey); err != nil {
+			return fmt.Errorf("config: crypto key: %w", err)
+		}
+	}
+	return nil
+}
+
+// EffectiveJSON renders c as JSON with Key masked, for a -print-config
+// flag to dump the fully-merged configuration.
+func (c *Config) EffectiveJSON() ([]byte, error) {
+	return configpkg.MarshalMasked(c)
 }
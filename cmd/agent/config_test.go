@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"address":"file:9000","poll_interval":"3s"}`), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	t.Setenv("REPORT_INTERVAL", "11s")
+
+	cfg, err := NewConfig([]string{"-c", path, "-a", "flag:9001"})
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+
+	if cfg.Address != "flag:9001" {
+		t.Errorf("Address = %q, want flag value", cfg.Address)
+	}
+	if cfg.PollInterval != 3*time.Second {
+		t.Errorf("PollInterval = %s, want file value 3s", cfg.PollInterval)
+	}
+	if cfg.ReportInterval != 11*time.Second {
+		t.Errorf("ReportInterval = %s, want env value 11s", cfg.ReportInterval)
+	}
+}
+
+func TestNewConfigValidateRejectsBadScheme(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"scheme":"ftp"}`), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if _, err := NewConfig([]string{"-c", path}); err == nil {
+		t.Error("NewConfig with scheme=ftp: expected error, got nil")
+	}
+}
+
+func TestNewConfigValidateRejectsMissingCryptoKey(t *testing.T) {
+	if _, err := NewConfig([]string{"-crypto-key", filepath.Join(t.TempDir(), "missing.pem")}); err == nil {
+		t.Error("NewConfig with missing crypto key file: expected error, got nil")
+	}
+}
+
+func TestEffectiveJSONMasksKey(t *testing.T) {
+	cfg, err := NewConfig([]string{"-k", "super-secret"})
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+
+	data, err := cfg.EffectiveJSON()
+	if err != nil {
+		t.Fatalf("EffectiveJSON: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded["KEY"] != "***" {
+		t.Errorf("effective config KEY = %v, want masked ***", decoded["KEY"])
+	}
+}
@@ -1,28 +1,208 @@
 package main
 
 import (
-	"flag"
+	"context"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/idudko/go-musthave-metrics/internal/agent"
+	configpkg "github.com/idudko/go-musthave-metrics/internal/config"
+	"github.com/idudko/go-musthave-metrics/pkg/hash"
 )
 
 func main() {
-	serverAddr := flag.String("a", "localhost:8080", "HTTP server address")
-	pollInterval := flag.Int("p", 2, "Poll interval in seconds")
-	reportInterval := flag.Int("r", 10, "Report interval in seconds")
-	flag.Parse()
-	collector := agent.NewCollector()
+	cfg, err := NewConfig(os.Args[1:])
+	if err != nil {
+		log.Fatalf("config: %v", err)
+	}
+
+	algo, ok := hash.Lookup(cfg.HashAlgo)
+	if !ok {
+		log.Fatalf("unknown -hash-algo %q: want one of %s", cfg.HashAlgo, strings.Join(hash.Headers(), ", "))
+	}
+	signer := hash.NewSigner(cfg.Key, algo)
+
+	sink, sender, err := newSink(cfg, signer)
+	if err != nil {
+		log.Fatalf("error creating report sink: %v", err)
+	}
+	defer sink.Close()
+
+	runtimeInputs, systemInputs := selectInputs(cfg.Inputs)
+	collector := agent.NewCollectorWithInputs("", runtimeInputs, systemInputs)
+
+	intervals := &atomic.Pointer[agentIntervals]{}
+	intervals.Store(&agentIntervals{poll: cfg.PollInterval, report: cfg.ReportInterval})
+
+	ctx := context.Background()
+	reportPool := agent.NewWorkerPool("agent_report_pool", cfg.RateLimit, 100)
+	reportPool.Start(ctx)
+	defer reportPool.Stop()
+
+	if cfg.ConfigFile != "" {
+		watchAgentConfig(ctx, cfg.ConfigFile, intervals, reportPool, sender)
+	}
 
 	for {
+		current := intervals.Load()
+
 		collector.Collect()
-		time.Sleep(time.Duration(*pollInterval) * time.Second)
+		collector.CollectSystemMetrics()
+		time.Sleep(current.poll)
 
-		err := collector.Report(*serverAddr)
+		err := reportPool.Submit(ctx, func(context.Context) error {
+			if err := collector.Report(sink); err != nil {
+				log.Printf("error reporting metrics: %v", err)
+			}
+			return nil
+		})
 		if err != nil {
-			log.Printf("error reporting metrics: %v", err)
+			log.Printf("error enqueuing metrics report: %v", err)
+		}
+		time.Sleep(current.report - current.poll)
+	}
+}
+
+// agentIntervals holds the poll/report durations the main loop reads every
+// iteration via an atomic.Pointer, so watchAgentConfig can hot-swap both
+// together without the loop ever observing a torn read.
+type agentIntervals struct {
+	poll   time.Duration
+	report time.Duration
+}
+
+// watchAgentConfig starts a config.Watcher on configFile and applies
+// "poll_interval"/"report_interval" changes to intervals, "rate_limit"
+// changes to reportPool's worker count, and "crypto_key" changes to sender
+// (when the agent was started with a sender-backed sink; see newSink) as
+// they're published, until ctx is canceled. "address" is marked immutable,
+// since switching report sinks without reconnecting isn't supported; a
+// watcher that fails to start (e.g. the file doesn't exist yet) only logs a
+// warning, since hot-reload is a best-effort convenience on top of the
+// flags/env values already applied at startup.
+func watchAgentConfig(ctx context.Context, configFile string, intervals *atomic.Pointer[agentIntervals], reportPool *agent.WorkerPool, sender *agent.Sender) {
+	watcher, err := configpkg.NewWatcher(configFile, configpkg.WithImmutableFields("address"))
+	if err != nil {
+		log.Printf("Config hot-reload disabled: %v", err)
+		return
+	}
+
+	go watcher.Run(ctx)
+
+	go func() {
+		for change := range watcher.Changes() {
+			switch change.Field {
+			case "poll_interval", "report_interval":
+				applyIntervalChange(intervals, change)
+			case "rate_limit":
+				applyRateLimitChange(reportPool, change)
+			case "crypto_key":
+				applyCryptoKeyChange(sender, change)
+			}
+		}
+	}()
+}
+
+// applyIntervalChange parses change.New as a duration string (e.g. "2s",
+// "500ms"), the same format the config file uses, and stores it into
+// intervals' poll or report field, leaving the other untouched.
+func applyIntervalChange(intervals *atomic.Pointer[agentIntervals], change configpkg.ConfigChange) {
+	duration, err := time.ParseDuration(change.New)
+	if err != nil {
+		log.Printf("Ignoring invalid reloaded %s %q: %v", change.Field, change.New, err)
+		return
+	}
+
+	current := intervals.Load()
+	next := *current
+	switch change.Field {
+	case "poll_interval":
+		next.poll = duration
+	case "report_interval":
+		next.report = duration
+	}
+	intervals.Store(&next)
+	log.Printf("Reloaded %s: %q -> %q", change.Field, change.Old, change.New)
+}
+
+// applyRateLimitChange resizes reportPool to the new worker count, bounding
+// how many Report calls the agent has in flight at once.
+func applyRateLimitChange(reportPool *agent.WorkerPool, change configpkg.ConfigChange) {
+	n, err := strconv.Atoi(change.New)
+	if err != nil {
+		log.Printf("Ignoring invalid reloaded rate_limit %q: %v", change.New, err)
+		return
+	}
+	reportPool.Resize(n)
+	log.Printf("Reloaded rate_limit: %q -> %q", change.Old, change.New)
+}
+
+// applyCryptoKeyChange rotates sender's encryption key. sender is nil when
+// the agent started without encryption/TLS pinning configured (see newSink),
+// in which case enabling encryption requires a restart, so the reload is
+// logged and ignored rather than silently applied.
+func applyCryptoKeyChange(sender *agent.Sender, change configpkg.ConfigChange) {
+	if sender == nil {
+		log.Printf("Reload ignored: crypto_key requires the agent to have been started with -crypto-key, -scheme=https, or -cert-fingerprint set")
+		return
+	}
+	if err := sender.SetCryptoKey(change.New); err != nil {
+		log.Printf("Ignoring invalid reloaded crypto_key %q: %v", change.New, err)
+		return
+	}
+	log.Printf("Reloaded crypto_key: %q -> %q", change.Old, change.New)
+}
+
+// selectInputs splits the -inputs flag into the runtime and system Input
+// sets, enabling only the named inputs.
+func selectInputs(inputsFlag string) (runtimeInputs, systemInputs []agent.Input) {
+	enabled := make(map[string]bool)
+	for _, name := range strings.Split(inputsFlag, ",") {
+		enabled[strings.TrimSpace(name)] = true
+	}
+
+	return agent.SelectInputs(enabled)
+}
+
+// newSink builds the report sink selected by cfg.Transport, falling back to
+// cfg.Output to pick between the http and statsd backends when
+// cfg.Transport=http. It also returns the *agent.Sender backing the sink, or
+// nil if the http+statsd/grpc path doesn't use one, so callers can hot-swap
+// its crypto key later; see newHTTPTransportSink.
+func newSink(cfg *Config, signer hash.Signer) (agent.Sink, *agent.Sender, error) {
+	switch cfg.Transport {
+	case "grpc":
+		sink, err := agent.NewGRPCSink(cfg.GrpcAddress)
+		return sink, nil, err
+	case "http", "":
+		return newHTTPTransportSink(cfg, signer)
+	default:
+		return nil, nil, fmt.Errorf("unknown transport %q: want http or grpc", cfg.Transport)
+	}
+}
+
+// newHTTPTransportSink builds the report sink selected by cfg.Output. When
+// cfg.Output is http and encryption (CryptoKey) or TLS pinning
+// (Scheme=https, CertFingerprint) is configured, it reports through an
+// agent.Sender-backed sink instead of the plain httpSink, since a
+// body-less, URL-encoded POST has no room to carry an encrypted payload.
+func newHTTPTransportSink(cfg *Config, signer hash.Signer) (agent.Sink, *agent.Sender, error) {
+	switch cfg.Output {
+	case "statsd":
+		sink, err := agent.NewStatsDSink(cfg.StatsdAddr)
+		return sink, nil, err
+	case "http", "":
+		if cfg.CryptoKey != "" || cfg.Scheme == "https" || cfg.CertFingerprint != "" {
+			sender := agent.NewSender(cfg.Key, cfg.CryptoKey, cfg.Scheme, cfg.CertFingerprint)
+			return agent.NewSenderSink(cfg.Address, sender, cfg.UseBatch), sender, nil
 		}
-		time.Sleep(time.Duration(*reportInterval-*pollInterval) * time.Second)
+		return agent.NewHTTPSink(cfg.Address, signer, cfg.AuthToken, nil), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown output backend %q: want http or statsd", cfg.Output)
 	}
 }
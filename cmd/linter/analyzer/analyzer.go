@@ -1,6 +1,7 @@
-// Package analyzer provides a static analyzer for Go code that detects:
+// Package analyzer provides static analyzers for Go code that detect:
 // - Usage of panic function
 // - Calls to log.Fatal or os.Exit outside of main function in main package
+// - Direct calls to log.Print/Printf/Println outside of main packages (see LoggerAnalyzer)
 package analyzer
 
 import (
@@ -11,4 +11,30 @@ import (
 func TestAnalyzer(t *testing.T) {
 	testdata := analysistest.TestData()
 	analysistest.Run(t, testdata, analyzer.Analyzer, "a", "b", "c", "d", "e")
+	// RunWithSuggestedFixes applies every SuggestedFix and diffs the result
+	// against each package's .golden files: f mixes Printf with Println and
+	// Print, so its golden is unchanged - the import rewrite must not fire
+	// when zerolog's log package can't cover every stdlib call left in the
+	// file. g has two Printf calls and nothing else, so its golden expects
+	// both rewritten with the shared import changed exactly once, not once
+	// per call site.
+	analysistest.RunWithSuggestedFixes(t, testdata, analyzer.LoggerAnalyzer, "f", "g")
+}
+
+// TestBundle runs every analyzer in analyzer.Bundle against the same
+// exitcheck fixtures, guarding against a future analyzer in the bundle
+// changing cmd/linter's exit code for code this repo already accepts.
+func TestBundle(t *testing.T) {
+	testdata := analysistest.TestData()
+	for _, a := range analyzer.Bundle() {
+		if a == analyzer.Analyzer {
+			// Already covered by TestAnalyzer with its expected //want
+			// annotations; the other bundled analyzers have none in
+			// these fixtures, so they must simply run without error.
+			continue
+		}
+		t.Run(a.Name, func(t *testing.T) {
+			analysistest.Run(t, testdata, a, "c")
+		})
+	}
 }
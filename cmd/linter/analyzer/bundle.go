@@ -0,0 +1,79 @@
+package analyzer
+
+import (
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/atomicalign"
+	"golang.org/x/tools/go/analysis/passes/bools"
+	"golang.org/x/tools/go/analysis/passes/copylock"
+	"golang.org/x/tools/go/analysis/passes/nilness"
+	"golang.org/x/tools/go/analysis/passes/printf"
+	"golang.org/x/tools/go/analysis/passes/shadow"
+	"golang.org/x/tools/go/analysis/passes/structtag"
+	"golang.org/x/tools/go/analysis/passes/unusedresult"
+	"honnef.co/go/tools/analysis/lint"
+	"honnef.co/go/tools/quickfix"
+	"honnef.co/go/tools/simple"
+	"honnef.co/go/tools/staticcheck"
+	"honnef.co/go/tools/stylecheck"
+
+	"github.com/timakin/bodyclose/passes/bodyclose"
+)
+
+// staticcheckCategories are the four staticcheck-class analyzer sets
+// LoadStaticcheck draws from, keyed by check ID (e.g. "SA1000", "S1000",
+// "ST1005", "QF1001").
+var staticcheckCategories = []map[string]*lint.Analyzer{
+	staticcheck.Analyzers,
+	simple.Analyzers,
+	stylecheck.Analyzers,
+	quickfix.Analyzers,
+}
+
+// CoreAnalyzers returns the analyzers cmd/linter always runs, regardless of
+// -config: this repo's exitcheck, the standard go/analysis passes this
+// bundle curates, and bodyclose.
+func CoreAnalyzers() []*analysis.Analyzer {
+	return []*analysis.Analyzer{
+		Analyzer,
+		LoggerAnalyzer,
+		atomicalign.Analyzer,
+		bools.Analyzer,
+		copylock.Analyzer,
+		nilness.Analyzer,
+		printf.Analyzer,
+		shadow.Analyzer,
+		structtag.Analyzer,
+		unusedresult.Analyzer,
+		bodyclose.Analyzer,
+	}
+}
+
+// LoadStaticcheck returns the analysis.Analyzers for every staticcheck
+// check ID in enabled, across all four categories (SA, S, ST, QF). A nil
+// enabled returns every check in every category; this is what cmd/linter
+// falls back to when run without -config.
+func LoadStaticcheck(enabled map[string]bool) []*analysis.Analyzer {
+	var out []*analysis.Analyzer
+	for _, category := range staticcheckCategories {
+		for id, a := range category {
+			if enabled != nil && !enabled[id] {
+				continue
+			}
+			out = append(out, a.Analyzer)
+		}
+	}
+	// category/id iteration order is randomized by Go's map, so sort to
+	// keep the registered set (and any output depending on its order)
+	// reproducible across runs.
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Bundle returns the full default analyzer set - CoreAnalyzers plus every
+// staticcheck check in every category - so analyzer_test can exercise it
+// with analysistest.Run without duplicating cmd/linter's -config wiring.
+func Bundle() []*analysis.Analyzer {
+	return append(CoreAnalyzers(), LoadStaticcheck(nil)...)
+}
@@ -0,0 +1,189 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// LoggerAnalyzer flags calls to the standard library's log.Print, log.Printf,
+// and log.Println outside of main packages, which should use this project's
+// structured zerolog logger instead. Packages that legitimately need stdlib
+// log (e.g. because they run before the structured logger is configured)
+// can opt out via -nodirectlogger.allow, a comma-separated list of package
+// import paths.
+var LoggerAnalyzer = &analysis.Analyzer{
+	Name: "nodirectlogger",
+	Doc:  "check for direct calls to log.Print/Printf/Println outside of main packages",
+	Run:  runLogger,
+}
+
+// defaultAllowedPackages lists packages that predate this analyzer and still
+// call the stdlib logger directly; -nodirectlogger.allow defaults to this
+// set so a bare `cmd/linter` run doesn't fail on pre-existing code. New
+// packages should use zerolog instead of growing this list.
+const defaultAllowedPackages = "github.com/idudko/go-musthave-metrics/internal/audit," +
+	"github.com/idudko/go-musthave-metrics/internal/agent," +
+	"github.com/idudko/go-musthave-metrics/internal/repository," +
+	"github.com/idudko/go-musthave-metrics/internal/middleware"
+
+var allowedPackages string
+
+func init() {
+	LoggerAnalyzer.Flags.StringVar(&allowedPackages, "allow", defaultAllowedPackages, "comma-separated package import paths allowed to call log.Print/Printf/Println directly")
+}
+
+// directLogFuncs are the stdlib log package functions this analyzer flags.
+var directLogFuncs = map[string]bool{
+	"Print":   true,
+	"Printf":  true,
+	"Println": true,
+}
+
+func runLogger(pass *analysis.Pass) (interface{}, error) {
+	if pass.Pkg.Name() == "main" || isAllowedPackage(pass.Pkg.Path()) {
+		return nil, nil
+	}
+
+	for _, file := range pass.Files {
+		stdlibLogImport := findStdlibLogImport(file)
+		// canRewriteImport is true only when Printf is the sole stdlib log
+		// usage in the file: github.com/rs/zerolog/log has no Println,
+		// Fatal, or any of the other stdlib functions/types, so rewriting
+		// the shared import while a sibling log.Println (or any other
+		// stdlib-only usage) survives in the same file would leave code
+		// that doesn't compile.
+		canRewriteImport := stdlibLogImport != nil && logUsedOnlyForPrintf(file, pass.TypesInfo)
+		// importRewritten tracks whether this file's "log" import has
+		// already been rewritten to zerolog's by an earlier Printf fix in
+		// this file, so a second flagged call doesn't emit another edit
+		// over the same import span - analysistest and -fix both reject
+		// overlapping TextEdits.
+		importRewritten := false
+
+		ast.Inspect(file, func(n ast.Node) bool {
+			callExpr, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+
+			sel, ok := callExpr.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+
+			obj := pass.TypesInfo.ObjectOf(sel.Sel)
+			name, ok := isDirectLogCall(obj)
+			if !ok {
+				return true
+			}
+
+			diag := analysis.Diagnostic{
+				Pos:     callExpr.Pos(),
+				Message: fmt.Sprintf("log.%s should not be called directly; use the project's structured logger instead", name),
+			}
+			if name == "Printf" && canRewriteImport {
+				edits := []analysis.TextEdit{
+					{
+						Pos:     sel.Pos(),
+						End:     sel.End(),
+						NewText: []byte("log.Info().Msgf"),
+					},
+				}
+				if !importRewritten {
+					edits = append(edits, analysis.TextEdit{
+						Pos:     stdlibLogImport.Path.Pos(),
+						End:     stdlibLogImport.Path.End(),
+						NewText: []byte(`"github.com/rs/zerolog/log"`),
+					})
+					importRewritten = true
+				}
+				diag.SuggestedFixes = []analysis.SuggestedFix{{
+					Message:   "rewrite as a structured log.Info().Msgf call, importing zerolog's log package",
+					TextEdits: edits,
+				}}
+			}
+			pass.Report(diag)
+
+			return true
+		})
+	}
+
+	return nil, nil
+}
+
+// isDirectLogCall reports whether obj is one of the stdlib log package's
+// Print/Printf/Println functions, returning its unqualified name.
+func isDirectLogCall(obj types.Object) (name string, ok bool) {
+	if obj == nil {
+		return "", false
+	}
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return "", false
+	}
+	pkg := fn.Pkg()
+	if pkg == nil || pkg.Path() != "log" {
+		return "", false
+	}
+	if !directLogFuncs[fn.Name()] {
+		return "", false
+	}
+	return fn.Name(), true
+}
+
+// findStdlibLogImport returns file's import spec for the stdlib "log"
+// package, or nil if it doesn't import it. The Printf SuggestedFix rewrites
+// this spec's path alongside the call site when logUsedOnlyForPrintf allows
+// it, since zerolog's log package is only a drop-in replacement for stdlib
+// log's Printf, not its other functions, and the rewritten call site still
+// refers to the unqualified "log" identifier.
+func findStdlibLogImport(file *ast.File) *ast.ImportSpec {
+	for _, imp := range file.Imports {
+		if imp.Path.Value == `"log"` {
+			return imp
+		}
+	}
+	return nil
+}
+
+// logUsedOnlyForPrintf reports whether every reference to the stdlib log
+// package in file resolves to log.Printf. It's the precondition for the
+// Printf SuggestedFix to rewrite the shared import: zerolog's log package
+// only mirrors Print/Printf, so a single surviving log.Println, log.Fatal,
+// or any other stdlib-only reference would make the rewritten import not
+// compile.
+func logUsedOnlyForPrintf(file *ast.File, info *types.Info) bool {
+	onlyPrintf := true
+	ast.Inspect(file, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		obj := info.ObjectOf(sel.Sel)
+		if obj == nil || obj.Pkg() == nil || obj.Pkg().Path() != "log" {
+			return true
+		}
+		if fn, ok := obj.(*types.Func); !ok || fn.Name() != "Printf" {
+			onlyPrintf = false
+		}
+		return true
+	})
+	return onlyPrintf
+}
+
+// isAllowedPackage reports whether path is listed in -nodirectlogger.allow.
+func isAllowedPackage(path string) bool {
+	if allowedPackages == "" {
+		return false
+	}
+	for _, allowed := range strings.Split(allowedPackages, ",") {
+		if strings.TrimSpace(allowed) == path {
+			return true
+		}
+	}
+	return false
+}
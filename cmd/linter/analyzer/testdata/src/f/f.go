@@ -0,0 +1,13 @@
+package f
+
+import "log"
+
+// doThing mixes Printf with Println and Print in the same file: zerolog's
+// log package has no Println, so the Printf SuggestedFix must not offer to
+// rewrite the shared import here - doing so would leave the Println call
+// site referring to a function that package doesn't have.
+func doThing(x int) {
+	log.Println("starting")   // want "log.Println should not be called directly; use the project's structured logger instead"
+	log.Printf("value %d", x) // want "log.Printf should not be called directly; use the project's structured logger instead"
+	log.Print("done")         // want "log.Print should not be called directly; use the project's structured logger instead"
+}
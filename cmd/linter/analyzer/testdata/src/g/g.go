@@ -0,0 +1,14 @@
+package g
+
+import "log"
+
+// doThing and doOtherThing exercise two Printf calls in the same file where
+// Printf is the file's only stdlib log usage, so the import rewrite is safe
+// and must be emitted exactly once, not once per call site.
+func doThing(x int) {
+	log.Printf("value %d", x) // want "log.Printf should not be called directly; use the project's structured logger instead"
+}
+
+func doOtherThing(x int) {
+	log.Printf("other %d", x) // want "log.Printf should not be called directly; use the project's structured logger instead"
+}
@@ -1,13 +1,64 @@
-// Package linter provides a static analyzer for Go code that detects:
-// - Usage of panic function
-// - Calls to log.Fatal or os.Exit outside of main function in main package
+// Package linter builds a multichecker binary bundling this repo's
+// exitcheck (see cmd/linter/analyzer) alongside a curated set of
+// standard go/analysis passes and staticcheck's SA/S/ST/QF checks, the
+// latter filtered through an optional -config file.
 package main
 
 import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"golang.org/x/tools/go/analysis/multichecker"
+
 	"github.com/idudko/go-musthave-metrics/cmd/linter/analyzer"
-	"golang.org/x/tools/go/analysis/singlechecker"
 )
 
+// linterConfig is the shape of the JSON file -config points at: a list of
+// staticcheck check IDs (e.g. "SA1000", "ST1005") to enable. Checks not
+// listed are left out of the multichecker; this repo's own exitcheck and
+// the standard passes analyzers in analyzer.CoreAnalyzers always run
+// regardless of -config.
+type linterConfig struct {
+	Checks []string `json:"checks"`
+}
+
 func main() {
-	singlechecker.Main(analyzer.Analyzer)
+	configPath := flag.String("config", "", "path to a JSON file listing enabled staticcheck check IDs (default: enable all)")
+	flag.Parse()
+
+	enabled, err := loadEnabledChecks(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "linter: %v\n", err)
+		os.Exit(1)
+	}
+
+	analyzers := append(analyzer.CoreAnalyzers(), analyzer.LoadStaticcheck(enabled)...)
+	multichecker.Main(analyzers...)
+}
+
+// loadEnabledChecks reads configPath's JSON file into a set of enabled
+// staticcheck check IDs for analyzer.LoadStaticcheck. An empty configPath
+// returns a nil set, which LoadStaticcheck treats as "enable everything".
+func loadEnabledChecks(configPath string) (map[string]bool, error) {
+	if configPath == "" {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", configPath, err)
+	}
+
+	var cfg linterConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", configPath, err)
+	}
+
+	enabled := make(map[string]bool, len(cfg.Checks))
+	for _, id := range cfg.Checks {
+		enabled[id] = true
+	}
+	return enabled, nil
 }
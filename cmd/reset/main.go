@@ -1,3 +1,8 @@
+// Программа reset генерирует методы Reset для структур, помеченных
+// комментарием // generate:reset: для каждого пакета она пишет
+// reset.gen.go с методом Reset() для каждой такой структуры и
+// reset.gen_test.go с тестом, заполняющим структуру и проверяющим, что
+// Reset действительно обнуляет её поля.
 package main
 
 import (
@@ -5,191 +10,116 @@ import (
 	"fmt"
 	"go/ast"
 	"go/format"
-	"go/parser"
 	"go/token"
 	"go/types"
 	"os"
 	"path/filepath"
-	"slices"
 	"strings"
+
+	"golang.org/x/tools/go/packages"
 )
 
-// StructInfo хранит информацию о структуре для генерации
-type StructInfo struct {
-	Name     string
-	Package  string
-	FilePath string
-	Fields   []*FieldInfo
-	HasReset bool
+// resetComment - комментарий над объявлением структуры, включающий для неё генерацию.
+const resetComment = "generate:reset"
+
+// skipFieldComment на отдельном поле исключает его из генерируемого Reset.
+const skipFieldComment = "generate:reset:skip"
+
+// remakeFieldComment на поле-карте заставляет Reset пересоздавать карту через
+// make вместо builtin clear, освобождая память прежней карты вместо
+// переиспользования её backing-хранилища.
+const remakeFieldComment = "generate:reset:remake"
+
+// structInfo хранит информацию о структуре для генерации.
+type structInfo struct {
+	name   string
+	fields []fieldInfo
 }
 
-// FieldInfo хранит информацию о поле структуры
-type FieldInfo struct {
-	Name      string
-	TypeExpr  ast.Expr
-	TypeStr   string
-	IsPointer bool
-	IsSlice   bool
-	IsMap     bool
-	IsArray   bool
-	IsStruct  bool
-	HasReset  bool
+// fieldInfo хранит информацию о поле структуры, полученную из go/types, а не
+// из строкового представления типа.
+type fieldInfo struct {
+	name      string
+	typ       types.Type
+	anonymous bool
+	skip      bool
+	remake    bool
+	hasReset  bool
 }
 
 func main() {
-	// Получаем путь к проекту
-	projectPath, err := os.Getwd()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error getting working directory: %v\n", err)
-		os.Exit(1)
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+			packages.NeedImports | packages.NeedDeps | packages.NeedTypes |
+			packages.NeedTypesInfo | packages.NeedSyntax,
 	}
 
-	// Проходимся по всем пакетам и генерируем Reset методы
-	err = filepath.Walk(projectPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Пропускаем не директории
-		if !info.IsDir() {
-			return nil
-		}
-
-		// Пропускаем скрытые директории
-		if strings.HasPrefix(filepath.Base(path), ".") {
-			return filepath.SkipDir
-		}
-
-		// Пропускаем директории vendor, node_modules и cmd/reset
-		base := filepath.Base(path)
-		if base == "vendor" || base == "node_modules" || (base == "reset" && strings.Contains(path, "cmd/reset")) {
-			return filepath.SkipDir
-		}
-
-		// Пропускаем директории с тестами
-		if base == "_test" {
-			return filepath.SkipDir
-		}
-
-		// Проверяем, есть ли в директории Go файлы
-		files, err := filepath.Glob(filepath.Join(path, "*.go"))
-		if err != nil || len(files) == 0 {
-			return nil
-		}
-
-		// Пропускаем, если есть только сгенерированные файлы
-		hasNonGen := false
-		for _, f := range files {
-			if !strings.HasSuffix(f, "_gen.go") && !strings.HasSuffix(f, "_test.go") {
-				hasNonGen = true
-				break
-			}
-		}
-		if !hasNonGen {
-			return nil
-		}
-
-		// Генерируем для текущего пакета
-		if err := generateForPackage(path); err != nil {
-			fmt.Fprintf(os.Stderr, "Error generating for package %s: %v\n", path, err)
-		}
-
-		return nil
-	})
-
+	pkgs, err := packages.Load(cfg, "./...")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error walking project: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error loading packages: %v\n", err)
 		os.Exit(1)
 	}
-}
-
-// generateForPackage генерирует Reset методы для всех структур в пакете
-func generateForPackage(pkgPath string) error {
-	fset := token.NewFileSet()
-
-	// Получаем список Go файлов (кроме _gen.go и _test.go)
-	files, err := filepath.Glob(filepath.Join(pkgPath, "*.go"))
-	if err != nil {
-		return fmt.Errorf("error listing files: %w", err)
+	if packages.PrintErrors(pkgs) > 0 {
+		fmt.Fprintln(os.Stderr, "Warning: type checking reported errors above, continuing anyway")
 	}
 
-	// Парсим все подходящие .go файлы
-	var astFiles []*ast.File
-	var pkgName string
-	for _, file := range files {
-		// Пропускаем тестовые и сгенерированные файлы
-		if strings.HasSuffix(file, "_test.go") || strings.HasSuffix(file, "_gen.go") {
+	for _, pkg := range pkgs {
+		if len(pkg.GoFiles) == 0 {
 			continue
 		}
 
-		// Парсим файл
-		src, err := os.ReadFile(file)
-		if err != nil {
-			return fmt.Errorf("error reading file %s: %w", file, err)
+		// Пропускаем сам генератор, чтобы не сгенерировать Reset для его
+		// собственных вспомогательных типов.
+		dir := filepath.Dir(pkg.GoFiles[0])
+		if strings.Contains(dir, string(filepath.Separator)+filepath.Join("cmd", "reset")) {
+			continue
 		}
 
-		astFile, err := parser.ParseFile(fset, file, src, parser.ParseComments)
-		if err != nil {
-			return fmt.Errorf("error parsing file %s: %w", file, err)
+		if err := generateForPackage(pkg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error generating for package %s: %v\n", pkg.PkgPath, err)
 		}
+	}
+}
 
-		// Получаем имя пакета из первого файла
-		if pkgName == "" {
-			pkgName = astFile.Name.Name
+// generateForPackage находит в pkg структуры с комментарием // generate:reset
+// и, если такие есть, пишет рядом с ними reset.gen.go и reset.gen_test.go.
+func generateForPackage(pkg *packages.Package) error {
+	var structs []structInfo
+	for _, file := range pkg.Syntax {
+		name := pkg.Fset.File(file.Pos()).Name()
+		if strings.HasSuffix(name, "_test.go") || strings.HasSuffix(name, "_gen.go") {
+			continue
 		}
-
-		astFiles = append(astFiles, astFile)
+		structs = append(structs, findStructsWithReset(file, pkg)...)
 	}
-
-	if len(astFiles) == 0 {
+	if len(structs) == 0 {
 		return nil
 	}
 
-	// Создаем информацию о типах
-	info := &types.Info{
-		Types: make(map[ast.Expr]types.TypeAndValue),
-		Defs:  make(map[*ast.Ident]types.Object),
-		Uses:  make(map[*ast.Ident]types.Object),
-	}
+	dir := filepath.Dir(pkg.GoFiles[0])
 
-	// Проверяем типы
-	conf := types.Config{}
-	_, err = conf.Check(pkgName, fset, astFiles, info)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: type checking failed for %s: %v (continuing anyway)\n", pkgPath, err)
+	codePath := filepath.Join(dir, "reset.gen.go")
+	if err := os.WriteFile(codePath, []byte(generateResetCode(pkg.Name, structs, pkg.Types)), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", codePath, err)
 	}
 
-	// Находим структуры с комментарием // generate:reset
-	var structs []*StructInfo
-	for _, file := range astFiles {
-		filePath := fset.File(file.Pos()).Name()
-		fileStructs := findStructsWithReset(fset, file, info, pkgName, filePath)
-		structs = append(structs, fileStructs...)
+	testPath := filepath.Join(dir, "reset.gen_test.go")
+	if err := os.WriteFile(testPath, []byte(generateResetTestCode(pkg.Name, structs, pkg.Types)), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", testPath, err)
 	}
-
-	if len(structs) == 0 {
-		return nil
-	}
-
-	// Генерируем код для reset.gen.go
-	code := generateResetCode(pkgName, structs)
-
-	// Записываем в файл
-	outputPath := filepath.Join(pkgPath, "reset.gen.go")
-	return os.WriteFile(outputPath, []byte(code), 0644)
+	return nil
 }
 
-// findStructsWithReset находит структуры с комментарием // generate:reset
-func findStructsWithReset(fset *token.FileSet, file *ast.File, info *types.Info, pkgName, filePath string) []*StructInfo {
-	var structs []*StructInfo
+// findStructsWithReset находит структуры с комментарием // generate:reset в
+// file, используя типовую информацию pkg вместо строкового разбора типов.
+func findStructsWithReset(file *ast.File, pkg *packages.Package) []structInfo {
+	var structs []structInfo
 
 	for _, decl := range file.Decls {
 		genDecl, ok := decl.(*ast.GenDecl)
 		if !ok || genDecl.Tok != token.TYPE {
 			continue
 		}
-
-		// Проверяем комментарий перед объявлением
 		if !hasResetComment(genDecl.Doc) {
 			continue
 		}
@@ -199,24 +129,27 @@ func findStructsWithReset(fset *token.FileSet, file *ast.File, info *types.Info,
 			if !ok {
 				continue
 			}
-
-			structType, ok := typeSpec.Type.(*ast.StructType)
+			astStruct, ok := typeSpec.Type.(*ast.StructType)
 			if !ok {
 				continue
 			}
 
-			// Собираем информацию о полях
-			fields := collectFieldInfo(structType.Fields.List, info)
-
-			// Проверяем, есть ли у самой структуры метод Reset
-			hasReset := hasResetMethodForType(typeSpec.Name.Name)
+			obj, ok := pkg.TypesInfo.Defs[typeSpec.Name]
+			if !ok || obj == nil {
+				continue
+			}
+			named, ok := obj.Type().(*types.Named)
+			if !ok {
+				continue
+			}
+			structType, ok := named.Underlying().(*types.Struct)
+			if !ok {
+				continue
+			}
 
-			structs = append(structs, &StructInfo{
-				Name:     typeSpec.Name.Name,
-				Package:  pkgName,
-				FilePath: filePath,
-				Fields:   fields,
-				HasReset: hasReset,
+			structs = append(structs, structInfo{
+				name:   typeSpec.Name.Name,
+				fields: collectFields(astStruct.Fields.List, structType),
 			})
 		}
 	}
@@ -224,290 +157,383 @@ func findStructsWithReset(fset *token.FileSet, file *ast.File, info *types.Info,
 	return structs
 }
 
-// collectFieldInfo собирает информацию о полях структуры
-func collectFieldInfo(fieldList []*ast.Field, info *types.Info) []*FieldInfo {
-	var fields []*FieldInfo
-
-	for _, field := range fieldList {
-		typeAndValue, ok := info.Types[field.Type]
-		var fieldTypes []types.Type
-
-		if ok {
-			fieldTypes = []types.Type{typeAndValue.Type}
+// collectFields сопоставляет AST-поля структуры (откуда берутся комментарии
+// generate:reset:skip/remake) с их типами из go/types, честно разворачивая
+// поля вида "a, b T" в отдельные fieldInfo в том же порядке, в каком их
+// видит types.Struct.
+func collectFields(astFields []*ast.Field, structType *types.Struct) []fieldInfo {
+	var fields []fieldInfo
+
+	idx := 0
+	for _, astField := range astFields {
+		skip := hasFieldComment(astField, skipFieldComment)
+		remake := hasFieldComment(astField, remakeFieldComment)
+
+		names := len(astField.Names)
+		if names == 0 {
+			names = 1 // анонимное (embedded) поле
 		}
 
-		for _, name := range field.Names {
-			fieldType := exprToString(field.Type)
-			var t types.Type
-
-			if len(fieldTypes) > 0 {
-				t = fieldTypes[0]
-				fieldType = typeToString(t)
-			}
+		for i := 0; i < names; i++ {
+			v := structType.Field(idx)
+			idx++
+
+			fields = append(fields, fieldInfo{
+				name:      v.Name(),
+				typ:       v.Type(),
+				anonymous: v.Anonymous(),
+				skip:      skip,
+				remake:    remake,
+				hasReset:  hasResetMethod(v.Type()),
+			})
+		}
+	}
 
-			fieldInfo := &FieldInfo{
-				Name:     name.Name,
-				TypeExpr: field.Type,
-				TypeStr:  fieldType,
-			}
+	return fields
+}
 
-			// Определяем характеристики типа
-			if t != nil {
-				fieldInfo.IsPointer = isPointerType(t)
-				fieldInfo.IsSlice = isSliceType(t)
-				fieldInfo.IsMap = isMapType(t)
-				fieldInfo.IsArray = isArrayType(t)
-				fieldInfo.IsStruct = isStructType(t)
-				fieldInfo.HasReset = hasResetMethodForType(fieldType)
-			}
+// hasResetMethod проверяет методом types.NewMethodSet, есть ли у t метод
+// Reset() - неважно, с получателем по значению или по указателю: метод,
+// объявленный по значению, тоже входит в method set указателя на t, а все
+// поля генерируемой структуры доступны по адресу через приёмник x *T.
+func hasResetMethod(t types.Type) bool {
+	return types.NewMethodSet(types.NewPointer(t)).Lookup(nil, "Reset") != nil
+}
 
-			fields = append(fields, fieldInfo)
+// hasResetComment проверяет наличие комментария // generate:reset над
+// объявлением типа.
+func hasResetComment(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) == resetComment {
+			return true
 		}
+	}
+	return false
+}
 
-		// Анонимные поля (вложенные структуры)
-		if len(field.Names) == 0 {
-			// Пропускаем анонимные поля для простоты
+// hasFieldComment проверяет наличие marker в строке документации или в
+// комментарии в конце строки у поля field.
+func hasFieldComment(field *ast.Field, marker string) bool {
+	for _, group := range []*ast.CommentGroup{field.Doc, field.Comment} {
+		if group == nil {
 			continue
 		}
+		for _, c := range group.List {
+			if strings.Contains(c.Text, marker) {
+				return true
+			}
+		}
 	}
-
-	return fields
+	return false
 }
 
-// generateResetCode генерирует код для reset.gen.go
-func generateResetCode(pkgName string, structs []*StructInfo) string {
+// generateResetCode генерирует содержимое reset.gen.go для пакета pkgName.
+func generateResetCode(pkgName string, structs []structInfo, pkg *types.Package) string {
 	var buf bytes.Buffer
-
-	// Генерируем заголовок файла
 	buf.WriteString("// Code generated by reset generator; DO NOT EDIT.\n\n")
-	buf.WriteString(fmt.Sprintf("package %s\n\n", pkgName))
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
 
-	// Генерируем методы для каждой структуры
 	for _, st := range structs {
-		generateResetMethod(&buf, st)
+		generateResetMethod(&buf, st, pkg)
 	}
 
-	// Форматируем код
 	formatted, err := format.Source(buf.Bytes())
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error formatting code: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error formatting generated code: %v\n", err)
 		return buf.String()
 	}
 	return string(formatted)
 }
 
-// generateResetMethod генерирует метод Reset для структуры
-func generateResetMethod(buf *bytes.Buffer, st *StructInfo) {
-	buf.WriteString(fmt.Sprintf("// Reset resets the %s fields to their zero values\n", st.Name))
-	buf.WriteString(fmt.Sprintf("func (x *%s) Reset() {\n", st.Name))
-	buf.WriteString("\tif x == nil {\n")
-	buf.WriteString("\t\treturn\n")
-	buf.WriteString("\t}\n")
+// generateResetMethod генерирует метод Reset для структуры st.
+func generateResetMethod(buf *bytes.Buffer, st structInfo, pkg *types.Package) {
+	fmt.Fprintf(buf, "// Reset resets the %s fields to their zero values\n", st.name)
+	fmt.Fprintf(buf, "func (x *%s) Reset() {\n", st.name)
+	buf.WriteString("\tif x == nil {\n\t\treturn\n\t}\n\n")
 
-	for _, field := range st.Fields {
-		generateFieldReset(buf, field, "x")
+	for _, f := range st.fields {
+		generateFieldReset(buf, f, "x", pkg)
 	}
 
 	buf.WriteString("}\n\n")
 }
 
-// generateFieldReset генерирует код сброса для поля
-func generateFieldReset(buf *bytes.Buffer, field *FieldInfo, receiver string) {
-	if field.Name == "" {
+// generateFieldReset генерирует код сброса для одного поля структуры,
+// обращаясь к нему как receiver.f.name.
+func generateFieldReset(buf *bytes.Buffer, f fieldInfo, receiver string, pkg *types.Package) {
+	if f.skip {
 		return
 	}
+	access := receiver + "." + f.name
 
-	fieldAccess := fmt.Sprintf("%s.%s", receiver, field.Name)
+	if f.anonymous {
+		generateAnonymousFieldReset(buf, f, access, pkg)
+		return
+	}
 
-	// Проверяем тип поля и генерируем соответствующий код
-	switch {
-	case field.IsPointer:
-		fmt.Fprintf(buf, "\tif %s != nil {\n", fieldAccess)
-
-		// Получаем базовый тип указателя
-		baseType := getBaseType(field.TypeStr)
-
-		switch {
-		case isStringType(baseType):
-			fmt.Fprintf(buf, "\t\t*%s = \"\"\n", fieldAccess)
-		case isBoolType(baseType):
-			fmt.Fprintf(buf, "\t\t*%s = false\n", fieldAccess)
-		case isNumericType(baseType):
-			fmt.Fprintf(buf, "\t\t*%s = 0\n", fieldAccess)
-		case field.HasReset:
-			fmt.Fprintf(buf, "\t\t%s.Reset()\n", fieldAccess)
-		default:
-			// Для структур без Reset обнуляем
-			fmt.Fprintf(buf, "\t\t*%s = %s{}\n", fieldAccess, baseType)
-		}
+	generateKindReset(buf, f.typ, access, f.hasReset, f.remake, pkg)
+}
 
+// generateAnonymousFieldReset генерирует сброс для анонимного (embedded)
+// поля: если у него (или, для указателя, у его базового типа) есть метод
+// Reset, он вызывается напрямую; иначе сброс "продвигается" внутрь -
+// генерируется код для каждого поля вложенной структуры, как если бы оно
+// было объявлено прямо в x.
+func generateAnonymousFieldReset(buf *bytes.Buffer, f fieldInfo, access string, pkg *types.Package) {
+	typ := f.typ
+	if ptr, ok := typ.Underlying().(*types.Pointer); ok {
+		fmt.Fprintf(buf, "\tif %s != nil {\n", access)
+		generateEmbeddedBody(buf, ptr.Elem(), access, hasResetMethod(ptr.Elem()), pkg)
 		buf.WriteString("\t}\n")
+		return
+	}
 
-	case field.IsSlice:
-		// Обрезаем слайс (с проверкой на nil)
-		fmt.Fprintf(buf, "\tif %s != nil {\n", fieldAccess)
-		fmt.Fprintf(buf, "\t\t%s = %s[:0]\n", fieldAccess, fieldAccess)
-		buf.WriteString("\t}\n")
+	generateEmbeddedBody(buf, typ, access, f.hasReset, pkg)
+}
 
-	case field.IsMap:
-		// Очищаем карту (с проверкой на nil)
-		fmt.Fprintf(buf, "\tif %s != nil {\n", fieldAccess)
-		fmt.Fprintf(buf, "\t\tclear(%s)\n", fieldAccess)
-		buf.WriteString("\t}\n")
+// generateEmbeddedBody генерирует тело сброса embedded-поля, уже
+// развёрнутого до не-указательного типа typ.
+func generateEmbeddedBody(buf *bytes.Buffer, typ types.Type, access string, hasReset bool, pkg *types.Package) {
+	if hasReset {
+		fmt.Fprintf(buf, "\t%s.Reset()\n", access)
+		return
+	}
 
-	case field.IsArray:
-		// Обнуляем массив
-		fmt.Fprintf(buf, "\t%s = [len(%s)]%s{}\n", fieldAccess, fieldAccess, getSliceElementType(field.TypeStr))
+	if st, ok := typ.Underlying().(*types.Struct); ok {
+		for i := 0; i < st.NumFields(); i++ {
+			v := st.Field(i)
+			sub := fieldInfo{
+				name:      v.Name(),
+				typ:       v.Type(),
+				anonymous: v.Anonymous(),
+				hasReset:  hasResetMethod(v.Type()),
+			}
+			generateFieldReset(buf, sub, access, pkg)
+		}
+		return
+	}
 
-	case isStringType(field.TypeStr):
-		fmt.Fprintf(buf, "\t%s = \"\"\n", fieldAccess)
+	generateKindReset(buf, typ, access, hasReset, false, pkg)
+}
 
-	case isBoolType(field.TypeStr):
-		fmt.Fprintf(buf, "\t%s = false\n", fieldAccess)
+// generateKindReset генерирует сброс access (уже готового выражения вида
+// x.Field или x.Field[i]) в зависимости от категории typ.
+func generateKindReset(buf *bytes.Buffer, typ types.Type, access string, hasReset, remake bool, pkg *types.Package) {
+	switch t := typ.Underlying().(type) {
+	case *types.Pointer:
+		fmt.Fprintf(buf, "\tif %s != nil {\n", access)
+		generatePointerElemReset(buf, t.Elem(), access, pkg)
+		buf.WriteString("\t}\n")
 
-	case isNumericType(field.TypeStr):
-		fmt.Fprintf(buf, "\t%s = 0\n", fieldAccess)
+	case *types.Slice:
+		fmt.Fprintf(buf, "\tif %s != nil {\n\t\t%s = %s[:0]\n\t}\n", access, access, access)
 
-	case field.IsStruct:
-		// Проверяем, есть ли метод Reset
-		if field.HasReset {
-			// Пытаемся вызвать Reset через интерфейс
-			buf.WriteString("\tresetter, ok := " + fieldAccess + ".(interface{ Reset() })\n")
-			buf.WriteString("\tif ok {\n")
-			buf.WriteString("\t\tresetter.Reset()\n")
-			buf.WriteString("\t}\n")
+	case *types.Map:
+		fmt.Fprintf(buf, "\tif %s != nil {\n", access)
+		if remake {
+			fmt.Fprintf(buf, "\t\t%s = make(%s)\n", access, typeString(typ, pkg))
 		} else {
-			// Обнуляем структуру
-			fmt.Fprintf(buf, "\t%s = %s{}\n", fieldAccess, field.TypeStr)
+			fmt.Fprintf(buf, "\t\tclear(%s)\n", access)
 		}
+		buf.WriteString("\t}\n")
+
+	case *types.Array:
+		fmt.Fprintf(buf, "\tfor i := range %s {\n", access)
+		generateKindReset(buf, t.Elem(), access+"[i]", hasResetMethod(t.Elem()), false, pkg)
+		buf.WriteString("\t}\n")
+
+	case *types.Basic:
+		fmt.Fprintf(buf, "\t%s = %s\n", access, zeroLiteral(t))
 
 	default:
-		// Для остальных типов обнуляем
-		fmt.Fprintf(buf, "\t%s = %s{}\n", fieldAccess, field.TypeStr)
+		if hasReset {
+			fmt.Fprintf(buf, "\t%s.Reset()\n", access)
+			return
+		}
+		varName := safeVarName(access) + "Zero"
+		fmt.Fprintf(buf, "\tvar %s %s\n\t%s = %s\n", varName, typeString(typ, pkg), access, varName)
 	}
 }
 
-// hasResetComment проверяет наличие комментария // generate:reset
-func hasResetComment(doc *ast.CommentGroup) bool {
-	if doc == nil {
-		return false
+// generatePointerElemReset генерирует сброс разыменованного указателя access
+// внутри уже сгенерированной проверки "if access != nil".
+func generatePointerElemReset(buf *bytes.Buffer, elem types.Type, access string, pkg *types.Package) {
+	if b, ok := elem.Underlying().(*types.Basic); ok {
+		fmt.Fprintf(buf, "\t\t*%s = %s\n", access, zeroLiteral(b))
+		return
 	}
-	for _, comment := range doc.List {
-		if strings.Contains(comment.Text, "generate:reset") {
-			return true
-		}
+	if hasResetMethod(elem) {
+		fmt.Fprintf(buf, "\t\t%s.Reset()\n", access)
+		return
 	}
-	return false
+	fmt.Fprintf(buf, "\t\t*%s = %s{}\n", access, typeString(elem, pkg))
 }
 
-// exprToString преобразует ast.Expr в строку
-func exprToString(expr ast.Expr) string {
-	var buf bytes.Buffer
-	format.Node(&buf, token.NewFileSet(), expr)
-	return buf.String()
+// zeroLiteral возвращает литерал нулевого значения для базового типа b.
+func zeroLiteral(b *types.Basic) string {
+	switch {
+	case b.Info()&types.IsString != 0:
+		return `""`
+	case b.Info()&types.IsBoolean != 0:
+		return "false"
+	default:
+		return "0"
+	}
 }
 
-// typeToString преобразует types.Type в строку
-func typeToString(t types.Type) string {
-	switch v := t.(type) {
-	case *types.Basic:
-		return v.Name()
-	case *types.Pointer:
-		return "*" + typeToString(v.Elem())
-	case *types.Slice:
-		return "[]" + typeToString(v.Elem())
-	case *types.Map:
-		return fmt.Sprintf("map[%s]%s", typeToString(v.Key()), typeToString(v.Elem()))
-	case *types.Array:
-		return fmt.Sprintf("[%d]%s", v.Len(), typeToString(v.Elem()))
-	case *types.Named:
-		return v.Obj().Name()
-	case *types.Struct:
-		return "struct{}"
+// nonZeroLiteral возвращает литерал ненулевого значения для базового типа b,
+// которым генерируемый тест заполняет поле перед вызовом Reset.
+func nonZeroLiteral(b *types.Basic) string {
+	switch {
+	case b.Info()&types.IsString != 0:
+		return `"x"`
+	case b.Info()&types.IsBoolean != 0:
+		return "true"
 	default:
-		return ""
+		return "1"
 	}
 }
 
-// isPointerType проверяет, является ли тип указателем
-func isPointerType(t types.Type) bool {
-	_, ok := t.(*types.Pointer)
-	return ok
+// typeString возвращает имя типа t, как оно должно быть записано в коде
+// пакета pkg (для импортированных типов - с префиксом пакета).
+func typeString(t types.Type, pkg *types.Package) string {
+	return types.TypeString(t, types.RelativeTo(pkg))
 }
 
-// isSliceType проверяет, является ли тип слайсом
-func isSliceType(t types.Type) bool {
-	_, ok := t.(*types.Slice)
-	return ok
+// safeVarName превращает выражение доступа вида "x.Embedded.Field" в
+// допустимый и уникальный для функции идентификатор локальной переменной.
+func safeVarName(access string) string {
+	r := strings.NewReplacer(".", "_", "[", "_", "]", "_")
+	return r.Replace(access)
 }
 
-// isMapType проверяет, является ли тип мапой
-func isMapType(t types.Type) bool {
-	_, ok := t.(*types.Map)
-	return ok
-}
+// generateResetTestCode генерирует содержимое reset.gen_test.go для пакета
+// pkgName: по одному тесту на структуру, заполняющему её представительными
+// значениями и проверяющему, что Reset действительно их обнуляет.
+func generateResetTestCode(pkgName string, structs []structInfo, pkg *types.Package) string {
+	var body bytes.Buffer
+	for _, st := range structs {
+		generateResetTest(&body, st, pkg)
+	}
+	usesReflect := strings.Contains(body.String(), "reflect.")
 
-// isArrayType проверяет, является ли тип массивом
-func isArrayType(t types.Type) bool {
-	_, ok := t.(*types.Array)
-	return ok
-}
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by reset generator; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+	buf.WriteString("import (\n")
+	if usesReflect {
+		buf.WriteString("\t\"reflect\"\n")
+	}
+	buf.WriteString("\t\"testing\"\n)\n\n")
+	buf.Write(body.Bytes())
 
-// isStructType проверяет, является ли тип структурой
-func isStructType(t types.Type) bool {
-	switch v := t.(type) {
-	case *types.Struct:
-		return true
-	case *types.Named:
-		_, ok := v.Underlying().(*types.Struct)
-		return ok
-	default:
-		return false
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error formatting generated test code: %v\n", err)
+		return buf.String()
 	}
+	return string(formatted)
 }
 
-// hasResetMethodForType проверяет наличие метода Reset у типа
-func hasResetMethodForType(_ string) bool {
-	// Для простоты считаем, что метод есть если имя типа указано явно
-	// В реальной реализации нужно смотреть в info.Types и проверять методы
-	return false
-}
+// generateResetTest генерирует TestXxxReset для структуры st: анонимные и
+// помеченные generate:reset:skip поля не заполняются и не проверяются,
+// остальные - по категории типа (числа/строки/bool, указатели, срезы,
+// карты, массивы, структуры с собственным Reset).
+func generateResetTest(buf *bytes.Buffer, st structInfo, pkg *types.Package) {
+	fmt.Fprintf(buf, "func Test%sReset(t *testing.T) {\n", st.name)
+	fmt.Fprintf(buf, "\tobj := &%s{}\n\n", st.name)
 
-// getBaseType получает базовый тип из указателя (например, "*int" -> "int")
-func getBaseType(typeStr string) string {
-	if strings.HasPrefix(typeStr, "*") {
-		return typeStr[1:]
+	for _, f := range st.fields {
+		if f.skip || f.anonymous {
+			continue
+		}
+		populateField(buf, f.typ, "obj."+f.name, pkg)
 	}
-	return typeStr
-}
 
-// isStringType проверяет, является ли тип строкой
-func isStringType(typeStr string) bool {
-	return typeStr == "string"
+	buf.WriteString("\n\tobj.Reset()\n\n")
+
+	for _, f := range st.fields {
+		if f.skip || f.anonymous {
+			continue
+		}
+		assertFieldReset(buf, f.typ, "obj."+f.name, pkg)
+	}
+
+	buf.WriteString("}\n\n")
 }
 
-// isBoolType проверяет, является ли тип bool
-func isBoolType(typeStr string) bool {
-	return typeStr == "bool"
+// populateField записывает в access представительное ненулевое значение
+// подходящее для категории typ, оставляя поля, которые сводятся только к
+// вызову чужого Reset(), нетронутыми (их покрывает тест того типа).
+func populateField(buf *bytes.Buffer, typ types.Type, access string, pkg *types.Package) {
+	switch t := typ.Underlying().(type) {
+	case *types.Pointer:
+		if b, ok := t.Elem().Underlying().(*types.Basic); ok {
+			v := safeVarName(access) + "Val"
+			fmt.Fprintf(buf, "\t%s := %s\n\t%s = &%s\n", v, nonZeroLiteral(b), access, v)
+		} else {
+			fmt.Fprintf(buf, "\t%s = &%s{}\n", access, typeString(t.Elem(), pkg))
+		}
+	case *types.Slice:
+		fmt.Fprintf(buf, "\t%s = append(%s, %s)\n", access, access, zeroCompositeElem(t.Elem(), pkg))
+	case *types.Map:
+		fmt.Fprintf(buf, "\t%s = %s{%s: %s}\n", access, typeString(typ, pkg), mapKeyLiteral(t.Key(), pkg), zeroCompositeElem(t.Elem(), pkg))
+	case *types.Array:
+		fmt.Fprintf(buf, "\t%s[0] = %s\n", access, zeroCompositeElem(t.Elem(), pkg))
+	case *types.Basic:
+		fmt.Fprintf(buf, "\t%s = %s\n", access, nonZeroLiteral(t))
+	}
 }
 
-// isNumericType проверяет, является ли тип числовым
-func isNumericType(typeStr string) bool {
-	numericTypes := []string{"int", "int8", "int16", "int32", "int64",
-		"uint", "uint8", "uint16", "uint32", "uint64",
-		"float32", "float64", "complex64", "complex128"}
-	return slices.Contains(numericTypes, typeStr)
+// assertFieldReset записывает проверку того, что access принял ожидаемое
+// Reset'ом состояние для своей категории типа.
+func assertFieldReset(buf *bytes.Buffer, typ types.Type, access string, pkg *types.Package) {
+	switch t := typ.Underlying().(type) {
+	case *types.Pointer:
+		if b, ok := t.Elem().Underlying().(*types.Basic); ok {
+			fmt.Fprintf(buf, "\tif %s != nil && *%s != %s {\n\t\tt.Errorf(\"expected *%s to be %s after Reset, got %%v\", *%s)\n\t}\n",
+				access, access, zeroLiteral(b), access, zeroLiteral(b), access)
+		} else if hasResetMethod(t.Elem()) {
+			fmt.Fprintf(buf, "\tif %s == nil {\n\t\tt.Errorf(\"expected %s to remain non-nil after Reset\")\n\t}\n", access, access)
+		} else {
+			fmt.Fprintf(buf, "\tif %s != nil && !reflect.DeepEqual(*%s, %s{}) {\n\t\tt.Errorf(\"expected *%s to be zero after Reset\")\n\t}\n",
+				access, access, typeString(t.Elem(), pkg), access)
+		}
+	case *types.Slice:
+		fmt.Fprintf(buf, "\tif len(%s) != 0 {\n\t\tt.Errorf(\"expected %s to be empty after Reset, got %%d items\", len(%s))\n\t}\n", access, access, access)
+	case *types.Map:
+		fmt.Fprintf(buf, "\tif len(%s) != 0 {\n\t\tt.Errorf(\"expected %s to be empty after Reset, got %%d items\", len(%s))\n\t}\n", access, access, access)
+	case *types.Array:
+		fmt.Fprintf(buf, "\tif %s != (%s{}) {\n\t\tt.Errorf(\"expected %s to be zero after Reset\")\n\t}\n", access, typeString(typ, pkg), access)
+	case *types.Basic:
+		fmt.Fprintf(buf, "\tif %s != %s {\n\t\tt.Errorf(\"expected %s to be %s after Reset, got %%v\", %s)\n\t}\n",
+			access, zeroLiteral(t), access, zeroLiteral(t), access)
+	default:
+		// Структуры с собственным Reset проверяются их собственным тестом.
+	}
 }
 
-// getSliceElementType получает тип элемента слайса или массива
-func getSliceElementType(typeStr string) string {
-	if strings.HasPrefix(typeStr, "[]") {
-		return typeStr[2:]
+// zeroCompositeElem возвращает литерал для элемента среза/карты/массива типа
+// t, заполняемый generateResetTest перед вызовом Reset.
+func zeroCompositeElem(t types.Type, pkg *types.Package) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return nonZeroLiteral(u)
+	case *types.Pointer:
+		return "&" + typeString(u.Elem(), pkg) + "{}"
+	default:
+		return typeString(t, pkg) + "{}"
 	}
-	if strings.HasPrefix(typeStr, "[") {
-		if idx := strings.Index(typeStr, "]"); idx > 0 {
-			return typeStr[idx+1:]
-		}
+}
+
+// mapKeyLiteral возвращает литерал ключа карты, заполняемой
+// generateResetTest.
+func mapKeyLiteral(t types.Type, pkg *types.Package) string {
+	if b, ok := t.Underlying().(*types.Basic); ok {
+		return nonZeroLiteral(b)
 	}
-	return ""
+	return typeString(t, pkg) + "{}"
 }
@@ -1,118 +1,127 @@
 package main
 
 import (
-	"flag"
-
-	"github.com/ilyakaznacheev/cleanenv"
+	"fmt"
+	"os"
+	"time"
 
 	configpkg "github.com/idudko/go-musthave-metrics/internal/config"
 )
 
-// JSONConfig represents configuration from JSON file
-type JSONConfig struct {
-	Address       string `json:"address"`
-	Restore       bool   `json:"restore"`
-	StoreInterval string `json:"store_interval"`
-	StoreFile     string `json:"store_file"`
-	DatabaseDSN   string `json:"database_dsn"`
-	CryptoKey     string `json:"crypto_key"`
-	AuditFile     string `json:"audit_file"`
-	AuditURL      string `json:"audit_url"`
-	TrustedSubnet string `json:"trusted_subnet"`
-	GrpcAddress   string `json:"grpc_address"`
-}
-
-// Config represents the full configuration
+// Config represents the server's full configuration, merged from
+// defaults, an optional JSON/YAML/TOML config file, environment variables,
+// and command-line flags via configpkg.Loader (file/env/flag tags document
+// each field's source name; see NewConfig).
 type Config struct {
-	Address         string `env:"ADDRESS"`
-	StoreInterval   int    `env:"STORE_INTERVAL"`
-	FileStoragePath string `env:"STORE_FILE"`
-	Restore         bool   `env:"RESTORE"`
-	DSN             string `env:"DATABASE_DSN"`
-	Key             string `env:"KEY"`
-	AuditFile       string `env:"AUDIT_FILE"`
-	AuditURL        string `env:"AUDIT_URL"`
-	CryptoKey       string `env:"CRYPTO_KEY"`
-	TrustedSubnet   string `env:"TRUSTED_SUBNET"`
-	GrpcAddress     string `env:"GRPC_ADDRESS"`
+	Address         string        `json:"address" env:"ADDRESS" flag:"a" default:"localhost:8080" usage:"HTTP address to listen on"`
+	StoreInterval   time.Duration `json:"store_interval" env:"STORE_INTERVAL" flag:"i" default:"300s" usage:"Store interval (0 = synchronous)"`
+	FileStoragePath string        `json:"store_file" env:"STORE_FILE" flag:"f" default:"" usage:"Path to file storage"`
+	Restore         bool          `json:"restore" env:"RESTORE" flag:"r" default:"false" usage:"Restore metrics from file"`
+	DSN             string        `json:"database_dsn" env:"DATABASE_DSN" flag:"d" default:"" secret:"true" usage:"PostgreSQL DSN"`
+	Key             string        `env:"KEY" flag:"k" default:"" secret:"true" usage:"Key for signing requests"`
+	AuditFile       string        `json:"audit_file" env:"AUDIT_FILE" flag:"audit-file" default:"" usage:"Path to audit log file"`
+	AuditURL        string        `json:"audit_url" env:"AUDIT_URL" flag:"audit-url" default:"" usage:"URL for audit server"`
+	CryptoKey       string        `json:"crypto_key" env:"CRYPTO_KEY" flag:"crypto-key" default:"" usage:"Path to private key file for decryption"`
+	TrustedSubnet   string        `json:"trusted_subnet" env:"TRUSTED_SUBNET" flag:"t" default:"" usage:"Trusted subnet in CIDR notation (e.g., 192.168.1.0/24)"`
+	GrpcAddress     string        `json:"grpc_address" env:"GRPC_ADDRESS" flag:"g" default:"" usage:"gRPC address to listen on"`
+
+	// TLSDomain enables Let's Encrypt autocert TLS when set. TLSCacheDir and
+	// TLSEmail configure certificate caching and the ACME account contact.
+	TLSDomain   string `json:"tls_domain" env:"TLS_DOMAIN" flag:"tls-domain" default:"" usage:"Domain name to request a Let's Encrypt certificate for (enables HTTPS)"`
+	TLSCacheDir string `json:"tls_cache_dir" env:"TLS_CACHE_DIR" flag:"tls-cache-dir" default:"./certs" usage:"Directory to cache Let's Encrypt certificates in"`
+	TLSEmail    string `json:"tls_email" env:"TLS_EMAIL" flag:"tls-email" default:"" usage:"Contact email for Let's Encrypt account registration"`
 
-	// ConfigFile is the path to the configuration file if specified
-	ConfigFile string
+	// ConfigFile is the path to the config file, resolved from -c/-config or
+	// the CONFIG env var before any other field is loaded. ConfigFormat
+	// selects its decoder ("json", "yaml", or "toml"), resolved the same way
+	// from -config-format or CONFIG_FORMAT, falling back to ConfigFile's
+	// extension.
+	ConfigFile   string `flag:"c,config" usage:"Path to config file"`
+	ConfigFormat string `flag:"config-format" usage:"Config file format: json, yaml, or toml (default: inferred from file extension)"`
+
+	// PrintConfig and DryRun let an operator inspect the fully-merged
+	// configuration (with Key masked) or validate it, without starting the
+	// server; see EffectiveJSON.
+	PrintConfig bool `flag:"print-config" default:"false" usage:"Print the effective configuration as JSON and exit"`
+	DryRun      bool `flag:"dry-run" default:"false" usage:"Validate the configuration and exit without starting listeners"`
 }
 
-// NewConfig initializes and returns configuration from all sources.
-// Priority order (lowest to highest):
-// 1. Default values
-// 2. Config file (if specified via -c/-config or CONFIG env var)
-// 3. Environment variables
-// 4. Command line flags (highest priority)
-//
-// Returns a pointer to the initialized Config structure.
-func NewConfig() (*Config, error) {
-	cfg := &Config{
-		Address:         "localhost:8080",
-		StoreInterval:   300,
-		FileStoragePath: "",
-		Restore:         false,
-		DSN:             "",
-		Key:             "",
-		AuditFile:       "",
-		AuditURL:        "",
-		CryptoKey:       "",
-		TrustedSubnet:   "",
-		GrpcAddress:     "",
-	}
+// NewConfig builds a Config from args (typically os.Args[1:]): flags are
+// parsed first to find -c/-config (falling back to the CONFIG env var) and
+// -config-format (falling back to CONFIG_FORMAT, then the file's
+// extension), then that file, environment variables, and the already-parsed
+// flags are merged in ascending priority - defaults, file, env, flags - via
+// configpkg.Loader. The result is validated before being returned. If
+// -print-config or -dry-run is set, NewConfig prints the effective
+// configuration (for -print-config) and exits the process with status 0
+// once validation succeeds, instead of returning, so a caller never starts
+// listeners on either flag.
+func NewConfig(args []string) (*Config, error) {
+	cfg := &Config{}
+	loader := &configpkg.Loader{}
 
-	// Register flags with default values
-	flag.StringVar(&cfg.Address, "a", "localhost:8080", "HTTP address to listen on")
-	flag.IntVar(&cfg.StoreInterval, "i", 300, "Store interval in seconds (0 = synchronous)")
-	flag.StringVar(&cfg.FileStoragePath, "f", "", "Path to file storage")
-	flag.BoolVar(&cfg.Restore, "r", false, "Restore metrics from file")
-	flag.StringVar(&cfg.DSN, "d", "", "PostgreSQL DSN")
-	flag.StringVar(&cfg.Key, "k", "", "Key for signing requests")
-	flag.StringVar(&cfg.AuditFile, "audit-file", "", "Path to audit log file")
-	flag.StringVar(&cfg.AuditURL, "audit-url", "", "URL for audit server")
-	flag.StringVar(&cfg.CryptoKey, "crypto-key", "", "Path to private key file for decryption")
-	flag.StringVar(&cfg.TrustedSubnet, "t", "", "Trusted subnet in CIDR notation (e.g., 192.168.1.0/24)")
-	flag.StringVar(&cfg.GrpcAddress, "g", "", "gRPC address to listen on")
+	visited, err := loader.ParseFlags(cfg, args)
+	if err != nil {
+		return nil, err
+	}
 
-	var configFileFlag string
-	flag.StringVar(&configFileFlag, "c", "", "Path to config file")
-	flag.StringVar(&configFileFlag, "config", "", "Path to config file")
-	flag.Parse()
+	configPath := configpkg.GetConfigFilePath(cfg.ConfigFile)
+	configFormat := configpkg.GetConfigFormat(cfg.ConfigFormat, configPath)
+	if err := loader.Load(cfg, configPath, configFormat, visited); err != nil {
+		return nil, err
+	}
 
-	// Get config file path from flag or environment variable
-	cfg.ConfigFile = configpkg.GetConfigFilePath(configFileFlag)
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
 
-	// Load JSON config if file is specified (lower priority than env/flags)
-	if cfg.ConfigFile != "" {
-		var jsonCfg JSONConfig
-		if err := configpkg.LoadConfigFile(cfg.ConfigFile, &jsonCfg); err != nil {
+	if cfg.PrintConfig {
+		data, err := cfg.EffectiveJSON()
+		if err != nil {
 			return nil, err
 		}
-		cfg.applyJSONConfig(&jsonCfg)
+		fmt.Println(string(data))
+		os.Exit(0)
 	}
-
-	// Apply environment variables (higher priority than config file, lower than flags)
-	if err := cleanenv.ReadEnv(cfg); err != nil {
-		return nil, err
+	if cfg.DryRun {
+		os.Exit(0)
 	}
 
 	return cfg, nil
 }
 
-// applyJSONConfig applies config from JSON file with lower priority than env/flags
-// Only applies values if the current value is still the default
-func (c *Config) applyJSONConfig(cfg *JSONConfig) {
-	// Apply JSON config values only if current values are still default
-	configpkg.ApplyStringIfDefault(&c.Address, "localhost:8080", cfg.Address)
-	configpkg.ApplyDurationIfDefault(&c.StoreInterval, 300, cfg.StoreInterval)
-	configpkg.ApplyStringIfDefault(&c.FileStoragePath, "", cfg.StoreFile)
-	configpkg.ApplyStringIfDefault(&c.DSN, "", cfg.DatabaseDSN)
-	configpkg.ApplyStringIfDefault(&c.AuditFile, "", cfg.AuditFile)
-	configpkg.ApplyStringIfDefault(&c.AuditURL, "", cfg.AuditURL)
-	configpkg.ApplyStringIfDefault(&c.CryptoKey, "", cfg.CryptoKey)
-	configpkg.ApplyStringIfDefault(&c.TrustedSubnet, "", cfg.TrustedSubnet)
-	configpkg.ApplyStringIfDefault(&c.GrpcAddress, "", cfg.GrpcAddress)
-	configpkg.ApplyBoolIfDefault(&c.Restore, cfg.Restore)
+// Validate reports an error for any field combination NewConfig can't
+// safely run with.
+func (c *Config) Validate() error {
+	if c.Address == "" {
+		return fmt.Errorf("config: address must not be empty")
+	}
+	if c.StoreInterval < 0 {
+		return fmt.Errorf("config: store interval must not be negative, got %s", c.StoreInterval)
+	}
+	if c.TLSDomain != "" && c.TLSCacheDir == "" {
+		return fmt.Errorf("config: tls cache dir must not be empty when tls domain is set")
+	}
+	if c.TrustedSubnet != "" {
+		if err := configpkg.ValidateCIDR(c.TrustedSubnet); err != nil {
+			return fmt.Errorf("config: trusted subnet: %w", err)
+		}
+	}
+	if c.CryptoKey != "" {
+		if err := configpkg.ValidatePEMFile(c.CryptoKey); err != nil {
+			return fmt.Errorf("config: crypto key: %w", err)
+		}
+	}
+	if c.DSN != "" {
+		if err := configpkg.ValidateDSN(c.DSN); err != nil {
+			return fmt.Errorf("config: database dsn: %w", err)
+		}
+	}
+	return nil
+}
+
+// EffectiveJSON renders c as JSON with Key masked, for a -print-config
+// flag to dump the fully-merged configuration.
+func (c *Config) EffectiveJSON() ([]byte, error) {
+	return configpkg.MarshalMasked(c)
 }
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"address":"file:9000","store_interval":"3s"}`), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	t.Setenv("RESTORE", "true")
+
+	cfg, err := NewConfig([]string{"-c", path, "-a", "flag:9001"})
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+
+	if cfg.Address != "flag:9001" {
+		t.Errorf("Address = %q, want flag value", cfg.Address)
+	}
+	if cfg.StoreInterval != 3*time.Second {
+		t.Errorf("StoreInterval = %s, want file value 3s", cfg.StoreInterval)
+	}
+	if !cfg.Restore {
+		t.Error("Restore = false, want true from env")
+	}
+}
+
+func TestNewConfigValidateRejectsEmptyAddress(t *testing.T) {
+	if _, err := NewConfig([]string{"-a", ""}); err == nil {
+		t.Error("NewConfig with empty address: expected error, got nil")
+	}
+}
+
+func TestNewConfigValidateRejectsBadTrustedSubnet(t *testing.T) {
+	if _, err := NewConfig([]string{"-t", "not-a-cidr"}); err == nil {
+		t.Error("NewConfig with bad trusted subnet: expected error, got nil")
+	}
+}
+
+func TestNewConfigValidateRejectsBadDSN(t *testing.T) {
+	if _, err := NewConfig([]string{"-d", "not a dsn at all ://"}); err == nil {
+		t.Error("NewConfig with bad DSN: expected error, got nil")
+	}
+}
+
+func TestEffectiveJSONMasksKey(t *testing.T) {
+	cfg, err := NewConfig([]string{"-k", "super-secret"})
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+
+	data, err := cfg.EffectiveJSON()
+	if err != nil {
+		t.Fatalf("EffectiveJSON: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded["KEY"] != "***" {
+		t.Errorf("effective config KEY = %v, want masked ***", decoded["KEY"])
+	}
+}
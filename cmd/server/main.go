@@ -1,32 +1,498 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+
+	"github.com/idudko/go-musthave-metrics/internal/audit"
+	configpkg "github.com/idudko/go-musthave-metrics/internal/config"
 	"github.com/idudko/go-musthave-metrics/internal/handler"
+	"github.com/idudko/go-musthave-metrics/internal/handler/ingest"
+	"github.com/idudko/go-musthave-metrics/internal/health"
+	appmiddleware "github.com/idudko/go-musthave-metrics/internal/middleware"
 	"github.com/idudko/go-musthave-metrics/internal/repository"
+	"github.com/idudko/go-musthave-metrics/internal/security"
+	"github.com/idudko/go-musthave-metrics/internal/server/grpc"
+	"github.com/idudko/go-musthave-metrics/internal/server/tlsserver"
 	"github.com/idudko/go-musthave-metrics/internal/service"
+	"github.com/idudko/go-musthave-metrics/pkg/hash"
 )
 
+// ingestConfig groups the flags that enable the optional third-party
+// ingest protocols (OpenTSDB, Datadog, Graphite), so operators can drop
+// this server in as a Telegraf/StatsD output target without touching
+// their agents.
+type ingestConfig struct {
+	openTSDB     bool
+	datadog      bool
+	graphiteAddr string
+}
+
+// tlsConfig groups the flags that enable and configure the optional Let's
+// Encrypt autocert listener.
+type tlsConfig struct {
+	domain       string
+	cacheDir     string
+	email        string
+	redirectAddr string
+}
+
+// auditConfig groups the flags that configure the optional audit pipeline:
+// which observers are attached, and how audit.Subject's async delivery
+// pipeline is tuned.
+type auditConfig struct {
+	logFile          string
+	webhookURL       string
+	kafkaBrokers     string
+	kafkaTopic       string
+	mqttBrokers      string
+	mqttTopic        string
+	mqttQoS          int
+	clientID         string
+	tls              audit.ObserverTLSConfig
+	spillDir         string
+	workers          int
+	queueSize        int
+	maxQueueSize     int64
+	dropOldest       bool
+	signingKey       string
+	signingAlgo      hash.Algorithm
+	diskMinFree      uint64
+	webhookBatchURL  string
+	webhookSecret    string
+	webhookMarshaler string
+	webhookMaxBatch  int
+	webhookFlush     time.Duration
+	webhookQueueSize int
+	syslogAddr       string
+	syslogNetwork    string
+	syslogAppName    string
+}
+
+// enabled reports whether any audit observer is configured.
+func (c auditConfig) enabled() bool {
+	return c.logFile != "" || c.webhookURL != "" || c.kafkaBrokers != "" || c.mqttBrokers != "" ||
+		c.webhookBatchURL != "" || c.syslogAddr != ""
+}
+
 func main() {
 	address := flag.String("a", "localhost:8080", "HTTP address to listen on")
+	transport := flag.String("transport", "http", "Ingest transport to serve: http or grpc")
+	grpcAddress := flag.String("g", "localhost:3200", "gRPC address to listen on, used when -transport=grpc")
+	key := flag.String("k", "", "Secret key for HMAC-SHA256 request/response signing")
+	authToken := flag.String("auth-token", "", "Bearer token required in the Authorization header, used when set")
+	tlsDomain := flag.String("tls-domain", "", "Domain name to request a Let's Encrypt certificate for (enables HTTPS on -a)")
+	tlsCacheDir := flag.String("tls-cache-dir", "./certs", "Directory to cache Let's Encrypt certificates in")
+	tlsEmail := flag.String("tls-email", "", "Contact email for Let's Encrypt account registration")
+	tlsHTTPRedirectAddr := flag.String("tls-http-redirect-addr", ":80", "Address for the HTTP listener that answers ACME challenges and redirects to HTTPS, used when -tls-domain is set")
+	openTSDB := flag.Bool("opentsdb", false, "Enable an OpenTSDB-compatible POST /api/put endpoint")
+	datadog := flag.Bool("datadog", false, "Enable a Datadog v1-compatible POST /api/v1/series endpoint")
+	graphiteAddr := flag.String("graphite-addr", "", "Address for a Graphite plaintext-protocol TCP listener, disabled when empty")
+	databaseDSN := flag.String("database-dsn", "", "PostgreSQL connection string, enables DBStorage when set")
+	redisAddr := flag.String("redis-addr", "", "Redis address (e.g. localhost:6379), enables RedisStorage when set and takes precedence over -database-dsn")
+	memStorageImpl := flag.String("mem-storage-impl", "simple", "In-memory storage implementation to use when -database-dsn and -redis-addr are both empty: simple or sharded; switch to sharded if simple's single mutex shows up as write contention under high-cardinality counters")
+	memShardCount := flag.Int("mem-shard-count", 0, "Shard count for -mem-storage-impl=sharded, 0 uses the package default")
+	auditLogFile := flag.String("audit-log", "", "File to append audit events to, enables auditing when set")
+	auditWebhookURL := flag.String("audit-webhook-url", "", "URL to POST audit events to, enables auditing when set")
+	auditKafkaBrokers := flag.String("audit-kafka-brokers", "", "Comma-separated Kafka broker addresses, enables a KafkaObserver when set")
+	auditKafkaTopic := flag.String("audit-kafka-topic", "audit-events", "Kafka topic to publish audit events to, used when -audit-kafka-brokers is set")
+	auditMQTTBrokers := flag.String("audit-mqtt-brokers", "", "Comma-separated MQTT broker URLs (e.g. tcp://localhost:1883), enables an MQTTObserver when set")
+	auditMQTTTopic := flag.String("audit-mqtt-topic", "audit/events", "MQTT topic to publish audit events to, used when -audit-mqtt-brokers is set")
+	auditMQTTQoS := flag.Int("audit-mqtt-qos", 1, "MQTT QoS (0, 1, or 2) for published audit events")
+	auditClientID := flag.String("audit-client-id", "go-musthave-metrics-server", "Client ID presented to the Kafka/MQTT brokers")
+	auditTLSCert := flag.String("audit-tls-cert", "", "Client certificate file for Kafka/MQTT broker TLS")
+	auditTLSKey := flag.String("audit-tls-key", "", "Client key file for Kafka/MQTT broker TLS, used with -audit-tls-cert")
+	auditTLSCA := flag.String("audit-tls-ca", "", "CA certificate file to validate the Kafka/MQTT broker against")
+	auditTLSInsecureSkipVerify := flag.Bool("audit-tls-insecure-skip-verify", false, "Skip Kafka/MQTT broker certificate verification, for local/dev brokers only")
+	auditSpillDir := flag.String("audit-spill-dir", "", "Directory for the durable audit spill queue, disabled (events drop instead of spilling) when empty")
+	auditWorkers := flag.Int("audit-workers", 4, "Number of workers fanning audit events out to observers")
+	auditQueueSize := flag.Int("audit-queue-size", 256, "In-memory audit event channel capacity before falling back to the spill queue or drop policy")
+	auditMaxQueueSize := flag.Int64("audit-max-queue-size", 100_000, "Maximum number of events the durable audit spill queue may hold, 0 means unbounded")
+	auditDropOldest := flag.Bool("audit-drop-oldest", false, "Drop the oldest queued audit event under backpressure instead of blocking the request, used when -audit-spill-dir is empty")
+	auditKey := flag.String("audit-key", "", "Shared HMAC key for signing the audit event chain (Nonce/PrevHash, X-Audit-Chain/X-Audit-Signature headers); falls back to the AUDIT_KEY env var, disables signing when both are empty")
+	auditKeyAlgo := flag.String("audit-key-algo", "HashSHA256", "HMAC algorithm for the audit event chain, one of: "+strings.Join(hash.Headers(), ", "))
+	healthCacheTTL := flag.Duration("health-cache-ttl", 5*time.Second, "How long /readyz caches its check results for, so probes can't hammer a slow dependency")
+	auditDiskMinFreeMB := flag.Int64("audit-disk-min-free-mb", 100, "Minimum free disk space, in MB, required on the -audit-log filesystem for /readyz, used when -audit-log is set")
+	auditWebhookBatchURL := flag.String("audit-webhook-batch-url", "", "URL to POST batched, HMAC-signed audit events to via a WebhookObserver, enables it when set; unlike -audit-webhook-url this batches deliveries and drops oldest under backpressure instead of blocking")
+	auditWebhookSecret := flag.String("audit-webhook-secret", "", "Shared secret the WebhookObserver uses to HMAC-SHA256 sign each batch into X-Signature-256/X-Timestamp headers, used with -audit-webhook-batch-url")
+	auditWebhookMarshaler := flag.String("audit-webhook-marshaler", "json", "Wire format for -audit-webhook-batch-url batches: json or cloudevents")
+	auditWebhookMaxBatch := flag.Int("audit-webhook-max-batch", 50, "Maximum events per POST for -audit-webhook-batch-url")
+	auditWebhookFlush := flag.Duration("audit-webhook-flush-interval", 5*time.Second, "Longest an event waits before a partial batch is flushed for -audit-webhook-batch-url")
+	auditWebhookQueueSize := flag.Int("audit-webhook-queue-size", 256, "In-memory queue capacity for -audit-webhook-batch-url before the oldest queued event is dropped")
+	auditSyslogAddr := flag.String("audit-syslog-addr", "", "Address of a syslog collector to send RFC 5424 audit events to, enables a SyslogObserver when set")
+	auditSyslogNetwork := flag.String("audit-syslog-network", "udp", "Network for -audit-syslog-addr: udp or tcp")
+	auditSyslogAppName := flag.String("audit-syslog-app-name", "audit", "RFC 5424 APP-NAME field for -audit-syslog-addr")
+	grpcTLSCert := flag.String("grpc-tls-cert", "", "Server certificate file, enables TLS on -transport=grpc")
+	grpcTLSKey := flag.String("grpc-tls-key", "", "Server private key file, used with -grpc-tls-cert")
+	grpcTLSClientCA := flag.String("grpc-tls-client-ca", "", "CA certificate file to verify gRPC client certificates against, enables mutual TLS")
+	grpcTLSAllowedClients := flag.String("grpc-tls-allowed-clients", "", "Comma-separated list of allowed client certificate CN/SAN values, used with -grpc-tls-client-ca; empty allows any certificate that verifies")
+	trustedSubnet := flag.String("t", "", "Trusted subnet in CIDR notation (e.g., 192.168.1.0/24), enforced on -transport=grpc")
+	logLevel := flag.String("log-level", "info", "zerolog global log level: debug, info, warn, error, or disabled")
+	configFileFlag := flag.String("c", "", "Path to JSON config file, hot-reloaded for -t and -log-level when -transport=grpc")
+	flag.StringVar(configFileFlag, "config", "", "Path to JSON config file, hot-reloaded for -t and -log-level when -transport=grpc")
 	flag.Parse()
 
-	storage := repository.NewMemStorage()
+	if err := applyLogLevel(*logLevel); err != nil {
+		log.Fatalf("invalid -log-level %q: %v", *logLevel, err)
+	}
+
+	storage, err := newStorage(*redisAddr, *databaseDSN, *memStorageImpl, *memShardCount)
+	if err != nil {
+		log.Fatalf("failed to initialize storage: %v", err)
+	}
+	tlsCfg := tlsConfig{domain: *tlsDomain, cacheDir: *tlsCacheDir, email: *tlsEmail, redirectAddr: *tlsHTTPRedirectAddr}
+	ingestCfg := ingestConfig{openTSDB: *openTSDB, datadog: *datadog, graphiteAddr: *graphiteAddr}
+
+	signingKey := *auditKey
+	if signingKey == "" {
+		signingKey = os.Getenv("AUDIT_KEY")
+	}
+	signingAlgo, ok := hash.Lookup(*auditKeyAlgo)
+	if !ok {
+		log.Fatalf("unknown -audit-key-algo %q: want one of %s", *auditKeyAlgo, strings.Join(hash.Headers(), ", "))
+	}
+
+	auditCfg := auditConfig{
+		logFile:      *auditLogFile,
+		webhookURL:   *auditWebhookURL,
+		kafkaBrokers: *auditKafkaBrokers,
+		kafkaTopic:   *auditKafkaTopic,
+		mqttBrokers:  *auditMQTTBrokers,
+		mqttTopic:    *auditMQTTTopic,
+		mqttQoS:      *auditMQTTQoS,
+		clientID:     *auditClientID,
+		tls: audit.ObserverTLSConfig{
+			CertFile:           *auditTLSCert,
+			KeyFile:            *auditTLSKey,
+			CAFile:             *auditTLSCA,
+			InsecureSkipVerify: *auditTLSInsecureSkipVerify,
+		},
+		spillDir:     *auditSpillDir,
+		workers:      *auditWorkers,
+		queueSize:    *auditQueueSize,
+		maxQueueSize: *auditMaxQueueSize,
+		dropOldest:   *auditDropOldest,
+		signingKey:   signingKey,
+		signingAlgo:  signingAlgo,
+		diskMinFree:  uint64(*auditDiskMinFreeMB) * 1024 * 1024,
+
+		webhookBatchURL:  *auditWebhookBatchURL,
+		webhookSecret:    *auditWebhookSecret,
+		webhookMarshaler: *auditWebhookMarshaler,
+		webhookMaxBatch:  *auditWebhookMaxBatch,
+		webhookFlush:     *auditWebhookFlush,
+		webhookQueueSize: *auditWebhookQueueSize,
+		syslogAddr:       *auditSyslogAddr,
+		syslogNetwork:    *auditSyslogNetwork,
+		syslogAppName:    *auditSyslogAppName,
+	}
+
+	grpcTLSCfg := grpc.TLSConfig{
+		CertFile:     *grpcTLSCert,
+		KeyFile:      *grpcTLSKey,
+		ClientCAFile: *grpcTLSClientCA,
+	}
+	if *grpcTLSAllowedClients != "" {
+		grpcTLSCfg.AllowedClientNames = strings.Split(*grpcTLSAllowedClients, ",")
+	}
+
+	switch *transport {
+	case "grpc":
+		runGRPC(*grpcAddress, *trustedSubnet, *key, grpcTLSCfg, storage, configpkg.GetConfigFilePath(*configFileFlag))
+	case "http", "":
+		runHTTP(*address, *key, *authToken, tlsCfg, ingestCfg, auditCfg, storage, *healthCacheTTL)
+	default:
+		log.Fatalf("unknown transport %q: want http or grpc", *transport)
+	}
+}
+
+// newHealthRegistry builds the health.Registry for runHTTP: a critical
+// "database" check if storage has a live connection to verify, an
+// informational "audit-sink" reachability check if auditCfg's webhook is
+// set, a critical "audit-disk" free-space check if auditCfg's log file is
+// set, and an informational "storage-cardinality" check against MemStorage's
+// dropped-metric counter when storage is a *repository.MemStorage (not
+// registered for ShardedMemStorage).
+func newHealthRegistry(storage repository.Storage, auditCfg auditConfig, cacheTTL time.Duration) *health.Registry {
+	registry := health.NewRegistry(cacheTTL)
+
+	if pinger, ok := storage.(health.Pinger); ok {
+		registry.Register(health.NewPingCheck("database", time.Second, pinger))
+	}
+	if memStorage, ok := storage.(*repository.MemStorage); ok {
+		registry.Register(health.NewCardinalityCheck("storage-cardinality", time.Second, memStorage.MetricsDropped))
+	}
+	if auditCfg.webhookURL != "" {
+		registry.Register(health.NewHTTPReachabilityCheck("audit-sink", auditCfg.webhookURL, 2*time.Second))
+	}
+	if auditCfg.webhookBatchURL != "" {
+		registry.Register(health.NewHTTPReachabilityCheck("audit-webhook-batch-sink", auditCfg.webhookBatchURL, 2*time.Second))
+	}
+	if auditCfg.logFile != "" {
+		registry.Register(health.NewDiskFreeCheck("audit-disk", filepath.Dir(auditCfg.logFile), auditCfg.diskMinFree, time.Second))
+	}
+
+	return registry
+}
+
+// newAuditSubject builds the audit.Subject for cfg, attaching a FileObserver,
+// HTTPObserver, KafkaObserver, MQTTObserver, WebhookObserver, and/or
+// SyslogObserver per whichever of cfg's log file, webhook, broker, batched
+// webhook, and syslog settings are set. It returns a nil Subject when none
+// are set, so AuditMiddleware is a no-op.
+func newAuditSubject(cfg auditConfig) (*audit.Subject, error) {
+	if !cfg.enabled() {
+		return nil, nil
+	}
+
+	subject, err := audit.NewSubjectWithConfig(audit.SubjectConfig{
+		Workers:       cfg.workers,
+		QueueCapacity: cfg.queueSize,
+		SpillDir:      cfg.spillDir,
+		MaxQueueSize:  cfg.maxQueueSize,
+		DropOldest:    cfg.dropOldest,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.logFile != "" {
+		if cfg.signingKey != "" {
+			subject.Attach(audit.NewSignedFileObserver(cfg.logFile, cfg.signingKey, cfg.signingAlgo))
+		} else {
+			subject.Attach(audit.NewFileObserver(cfg.logFile))
+		}
+	}
+	if cfg.webhookURL != "" {
+		if cfg.signingKey != "" {
+			subject.Attach(audit.NewSignedHTTPObserver(cfg.webhookURL, cfg.signingKey, cfg.signingAlgo))
+		} else {
+			subject.Attach(audit.NewHTTPObserver(cfg.webhookURL))
+		}
+	}
+	if cfg.kafkaBrokers != "" {
+		kafkaObserver, err := audit.NewKafkaObserver(audit.KafkaObserverConfig{
+			Brokers:  strings.Split(cfg.kafkaBrokers, ","),
+			Topic:    cfg.kafkaTopic,
+			ClientID: cfg.clientID,
+			TLS:      cfg.tls,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Kafka audit observer: %w", err)
+		}
+		subject.Attach(kafkaObserver)
+	}
+	if cfg.mqttBrokers != "" {
+		mqttObserver, err := audit.NewMQTTObserver(audit.MQTTObserverConfig{
+			Brokers:  strings.Split(cfg.mqttBrokers, ","),
+			Topic:    cfg.mqttTopic,
+			ClientID: cfg.clientID,
+			QoS:      byte(cfg.mqttQoS),
+			TLS:      cfg.tls,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create MQTT audit observer: %w", err)
+		}
+		subject.Attach(mqttObserver)
+	}
+	if cfg.webhookBatchURL != "" {
+		marshaler, err := newAuditMarshaler(cfg.webhookMarshaler)
+		if err != nil {
+			return nil, err
+		}
+		subject.Attach(audit.NewWebhookObserver(audit.WebhookObserverConfig{
+			URL:           cfg.webhookBatchURL,
+			Secret:        cfg.webhookSecret,
+			Marshaler:     marshaler,
+			QueueCapacity: cfg.webhookQueueSize,
+			MaxBatch:      cfg.webhookMaxBatch,
+			FlushInterval: cfg.webhookFlush,
+		}))
+	}
+	if cfg.syslogAddr != "" {
+		subject.Attach(audit.NewSyslogObserver(audit.SyslogObserverConfig{
+			Network: cfg.syslogNetwork,
+			Addr:    cfg.syslogAddr,
+			AppName: cfg.syslogAppName,
+		}))
+	}
+
+	return subject, nil
+}
+
+// newAuditMarshaler picks the audit.Marshaler named by -audit-webhook-marshaler.
+func newAuditMarshaler(name string) (audit.Marshaler, error) {
+	switch name {
+	case "", "json":
+		return audit.JSONMarshaler{}, nil
+	case "cloudevents":
+		return audit.CloudEventsMarshaler{Source: "go-musthave-metrics/audit"}, nil
+	default:
+		return nil, fmt.Errorf("unknown -audit-webhook-marshaler %q: want json or cloudevents", name)
+	}
+}
+
+// newStorage picks the storage backend: redisAddr, if set, wins over
+// databaseDSN (RedisStorage over DBStorage); an empty redisAddr and
+// databaseDSN fall back to an in-memory storage, chosen by memStorageImpl
+// ("simple" for MemStorage, "sharded" for ShardedMemStorage with
+// memShardCount shards, 0 meaning the package default).
+func newStorage(redisAddr, databaseDSN, memStorageImpl string, memShardCount int) (repository.Storage, error) {
+	switch {
+	case redisAddr != "":
+		return repository.NewRedisStorage(redisAddr)
+	case databaseDSN != "":
+		return repository.NewDBStorage(databaseDSN)
+	default:
+		switch memStorageImpl {
+		case "sharded":
+			return repository.NewShardedMemStorageWithShards(memShardCount), nil
+		case "simple", "":
+			return repository.NewMemStorage(), nil
+		default:
+			return nil, fmt.Errorf("unknown -mem-storage-impl %q: want simple or sharded", memStorageImpl)
+		}
+	}
+}
+
+// runHTTP starts the chi-based HTTP server, preserving the historical
+// single-metric URL routes. When tlsCfg.domain is set, it serves HTTPS via
+// Let's Encrypt autocert instead of plain HTTP.
+func runHTTP(address, key, authToken string, tlsCfg tlsConfig, ingestCfg ingestConfig, auditCfg auditConfig, storage repository.Storage, healthCacheTTL time.Duration) {
 	metricsService := service.NewMetricsService(storage)
-	h := handler.NewHandler(metricsService)
+	h := handler.NewHandler(metricsService, key)
+
+	auditSubject, err := newAuditSubject(auditCfg)
+	if err != nil {
+		log.Fatalf("failed to initialize audit pipeline: %v", err)
+	}
+	if auditSubject != nil {
+		defer auditSubject.Close()
+	}
+
+	healthRegistry := newHealthRegistry(storage, auditCfg, healthCacheTTL)
 
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
+	r.Use(appmiddleware.HashValidationMiddleware(key))
+	r.Use(appmiddleware.AuditMiddleware(auditSubject))
+	if authToken != "" {
+		r.Use(security.BearerAuthMiddleware(security.StaticTokenValidator{Token: authToken}))
+	}
+	r.Get("/livez", healthRegistry.LivezHandler)
+	r.Get("/readyz", healthRegistry.ReadyzHandler)
 	r.Post("/update/{type}/{name}/{value}", h.UpdateMetricHandler)
 	r.Get("/value/{type}/{name}", h.GetMetricValueHandler)
 	r.Get("/", h.ListMetricsHandler)
+	r.Post("/api/v1/write", h.RemoteWriteHandler)
+	r.Post("/api/v1/agents/heartbeat", h.AgentHeartbeatHandler)
+	r.Get("/api/v1/agents", h.ListAgentsHandler)
+	if ingestCfg.openTSDB {
+		r.Post("/api/put", ingest.NewHandler(ingest.OpenTSDBDecoder{}, metricsService))
+	}
+	if ingestCfg.datadog {
+		r.Post("/api/v1/series", ingest.NewHandler(ingest.DatadogDecoder{}, metricsService))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if ingestCfg.graphiteAddr != "" {
+		graphiteListener := ingest.NewGraphiteListener(metricsService)
+		go func() {
+			fmt.Printf("Graphite listener is running on %s\n", ingestCfg.graphiteAddr)
+			if err := graphiteListener.Serve(ctx, ingestCfg.graphiteAddr); err != nil {
+				log.Printf("Graphite listener failed: %v", err)
+			}
+		}()
+	}
+
+	if tlsCfg.domain != "" {
+		fmt.Printf("Server is running on %s (HTTPS via Let's Encrypt for %s)\n", address, tlsCfg.domain)
+		if err := tlsserver.Serve(ctx, address, tlsCfg.domain, tlsCfg.cacheDir, tlsCfg.email, tlsCfg.redirectAddr, r); err != nil {
+			log.Fatalf("HTTPS server failed: %v", err)
+		}
+		return
+	}
+
+	fmt.Printf("Server is running on %s\n", address)
+	log.Fatal(http.ListenAndServe(address, r))
+}
+
+// runGRPC starts the gRPC Metrics service and blocks until it is signalled
+// to shut down. configFile, if non-empty, is watched (reloaded on write or
+// SIGHUP) for changes to "trusted_subnet" and "log_level", applied live via
+// trustedSubnetState and applyLogLevel without restarting the server.
+func runGRPC(address, trustedSubnet, key string, tlsCfg grpc.TLSConfig, storage repository.Storage, configFile string) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	_, trustedSubnetState, err := grpc.StartServer(ctx, address, trustedSubnet, key, tlsCfg, storage)
+	if err != nil {
+		log.Fatalf("failed to start gRPC server: %v", err)
+	}
+
+	if configFile != "" {
+		watchConfigFile(ctx, configFile, trustedSubnetState)
+	}
+
+	fmt.Printf("gRPC server is running on %s\n", address)
+	<-ctx.Done()
+}
+
+// watchConfigFile starts a config.Watcher on configFile and applies
+// "trusted_subnet" and "log_level" changes as they're published, until ctx
+// is canceled. Fields that require a listener restart to take effect
+// ("address", "grpc_address", "database_dsn") are marked immutable, so the
+// watcher logs and ignores them instead of pretending to apply them. A
+// watcher that fails to start (e.g. the file doesn't exist yet) only logs a
+// warning, since hot-reload is a best-effort convenience on top of the
+// flags/env values already applied at startup.
+func watchConfigFile(ctx context.Context, configFile string, trustedSubnetState *grpc.TrustedSubnetState) {
+	watcher, err := configpkg.NewWatcher(configFile, configpkg.WithImmutableFields("address", "grpc_address", "database_dsn"))
+	if err != nil {
+		log.Printf("Config hot-reload disabled: %v", err)
+		return
+	}
+
+	go watcher.Run(ctx)
+
+	go func() {
+		for change := range watcher.Changes() {
+			switch change.Field {
+			case "trusted_subnet":
+				trustedSubnetState.Set(change.New)
+				log.Printf("Reloaded trusted_subnet: %q -> %q", change.Old, change.New)
+			case "log_level":
+				if err := applyLogLevel(change.New); err != nil {
+					log.Printf("Ignoring invalid reloaded log_level %q: %v", change.New, err)
+				}
+			}
+		}
+	}()
+}
 
-	fmt.Printf("Server is running on %s\n", *address)
-	log.Fatal(http.ListenAndServe(*address, r))
+// applyLogLevel parses level and sets it as zerolog's global level, which
+// every zerolog.Logger created without an explicit level (the middleware
+// and grpc packages' package-level loggers) respects.
+func applyLogLevel(level string) error {
+	parsed, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	zerolog.SetGlobalLevel(parsed)
+	return nil
 }
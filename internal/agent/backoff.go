@@ -0,0 +1,130 @@
+package agent
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryBackoff computes the delay to wait before retry attempt n (n starts at 1),
+// given the request that was just attempted and the response it produced (nil on
+// network error). A non-positive return value aborts the retry loop and the last
+// error is returned to the caller.
+type RetryBackoff func(n int, req *http.Request, resp *http.Response) time.Duration
+
+const (
+	maxBackoff    = 10 * time.Second
+	maxBackoffJit = 1 * time.Second
+
+	// maxAttempts bounds the retry loop: once attempt n exceeds it,
+	// DefaultRetryBackoff returns 0 and gives up, even on a plain network
+	// error (resp == nil) that isRetryableStatus never gets a chance to
+	// veto. Without this a persistently down server would retry forever.
+	maxAttempts = 8
+
+	// maxShift caps the exponent passed to 1<<n; n is already bounded by
+	// maxAttempts above, but this keeps the shift itself safe regardless.
+	maxShift = 32
+)
+
+// DefaultRetryBackoff mirrors the strategy used by golang.org/x/crypto/acme: for
+// attempt n it waits min(2^n seconds, 10s) plus up to 1s of jitter, unless the
+// response carries a Retry-After header (delta-seconds or HTTP-date), in which
+// case that value plus jitter takes precedence. Non-retryable 4xx responses and
+// exceeding maxAttempts both abort the loop by returning 0; the maxAttempts
+// check runs before the Retry-After lookup so a server that keeps answering
+// 429/503 with Retry-After can't honor its way past the cap and retry forever,
+// the same as a plain network error (resp == nil) would without the cap.
+func DefaultRetryBackoff(n int, req *http.Request, resp *http.Response) time.Duration {
+	if resp != nil && !isRetryableStatus(resp) {
+		return 0
+	}
+
+	if n > maxAttempts {
+		return 0
+	}
+
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return d + jitter()
+		}
+	}
+
+	shift := n
+	if shift > maxShift {
+		shift = maxShift
+	}
+	d := time.Duration(1<<uint(shift)) * time.Second
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d + jitter()
+}
+
+func jitter() time.Duration {
+	return time.Duration(rand.Int63n(int64(maxBackoffJit)))
+}
+
+// retryAfter parses a Retry-After header value, which may be either a number of
+// delta-seconds or an HTTP-date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// isRetryableStatus reports whether a failed response should be retried. 5xx
+// responses and 429 Too Many Requests are retried; other 4xx responses are not,
+// except for a 400 carrying a "bad nonce"-style transient error.
+func isRetryableStatus(resp *http.Response) bool {
+	switch {
+	case resp.StatusCode < 400:
+		return false
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode == http.StatusBadRequest:
+		return isBadNonce(resp)
+	case resp.StatusCode < 500:
+		return false
+	default:
+		return true
+	}
+}
+
+// isBadNonce detects a "bad nonce"-style transient condition communicated via
+// either the X-Error header or the response body, restoring the body afterwards
+// so callers can still read it.
+func isBadNonce(resp *http.Response) bool {
+	if containsBadNonce(resp.Header.Get("X-Error")) {
+		return true
+	}
+	if resp.Body == nil {
+		return false
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return containsBadNonce(string(body))
+}
+
+func containsBadNonce(s string) bool {
+	return strings.Contains(strings.ToLower(s), "bad nonce")
+}
@@ -0,0 +1,26 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+)
+
+// pinnedCertVerifier returns a tls.Config.VerifyPeerCertificate callback that
+// accepts the server's leaf certificate only if its SHA-256 fingerprint
+// matches expectedFingerprint (hex-encoded). This lets zero-trust deployments
+// pin the exact server certificate instead of relying on the system trust
+// store, which matters when talking to a server behind a self-signed or
+// privately issued certificate.
+func pinnedCertVerifier(expectedFingerprint string) func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		for _, raw := range rawCerts {
+			sum := sha256.Sum256(raw)
+			if hex.EncodeToString(sum[:]) == expectedFingerprint {
+				return nil
+			}
+		}
+		return fmt.Errorf("server certificate fingerprint does not match pinned value %q", expectedFingerprint)
+	}
+}
@@ -1,104 +1,169 @@
 package agent
 
 import (
-	"fmt"
+	"context"
 	"math/rand"
-	"net/http"
 	"runtime"
 	"sync"
-	"time"
 )
 
+// Metric is a single named sample gathered by an Input.
+type Metric struct {
+	Name      string
+	Value     float64
+	IsCounter bool
+}
+
+// Input is a pluggable metrics source, mirroring a telegraf-style input
+// plugin: each Input gathers one category of metrics independently of the
+// others, so adding a new system metric doesn't require editing Collect().
+type Input interface {
+	// Name identifies the input, e.g. for the -inputs enable/disable flag.
+	Name() string
+	Gather(ctx context.Context) ([]Metric, error)
+}
+
 type Collector struct {
 	mu        sync.Mutex
 	gauges    map[string]float64
 	counters  map[string]int64
 	pollCount int64
+
+	key string
+
+	runtimeInputs []Input
+	systemInputs  []Input
+}
+
+// NewCollector creates a Collector with the default set of inputs enabled:
+// "runtime" (Go runtime.MemStats) feeding Collect, and "cpu", "mem", "disk",
+// "process" (gopsutil-backed) feeding CollectSystemMetrics. key is retained
+// for signing outgoing metric batches elsewhere in the agent pipeline.
+func NewCollector(key string) *Collector {
+	return NewCollectorWithInputs(key, []Input{&runtimeInput{}}, defaultSystemInputs())
 }
 
-func NewCollector() *Collector {
+// NewCollectorWithInputs creates a Collector with an explicit set of runtime
+// and system inputs, letting callers enable/disable inputs (e.g. via the
+// agent's -inputs flag) without touching Collect/CollectSystemMetrics.
+func NewCollectorWithInputs(key string, runtimeInputs, systemInputs []Input) *Collector {
 	return &Collector{
-		gauges:   make(map[string]float64),
-		counters: make(map[string]int64),
+		gauges:        make(map[string]float64),
+		counters:      make(map[string]int64),
+		key:           key,
+		runtimeInputs: runtimeInputs,
+		systemInputs:  systemInputs,
 	}
 }
 
+// Collect gathers the runtime inputs (Go runtime.MemStats by default) and
+// bumps the PollCount counter, matching the spec's expectation that every
+// Collect call represents one poll.
 func (c *Collector) Collect() {
+	c.gather(c.runtimeInputs)
+
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.pollCount++
+	c.counters["PollCount"] = c.pollCount
+	c.mu.Unlock()
+}
 
-	var memStats runtime.MemStats
-	runtime.ReadMemStats(&memStats)
+// CollectSystemMetrics gathers the system inputs (CPU, memory, disk, process
+// count by default). It's called on its own poll ticker by the service layer,
+// independent of Collect.
+func (c *Collector) CollectSystemMetrics() {
+	c.gather(c.systemInputs)
+}
 
-	c.gauges["Alloc"] = float64(memStats.Alloc)
-	c.gauges["BuckHashSys"] = float64(memStats.BuckHashSys)
-	c.gauges["Frees"] = float64(memStats.Frees)
-	c.gauges["GCCPUFraction"] = memStats.GCCPUFraction
-	c.gauges["GCSys"] = float64(memStats.GCSys)
-	c.gauges["HeapAlloc"] = float64(memStats.HeapAlloc)
-	c.gauges["HeapIdle"] = float64(memStats.HeapIdle)
-	c.gauges["HeapInuse"] = float64(memStats.HeapInuse)
-	c.gauges["HeapObjects"] = float64(memStats.HeapObjects)
-	c.gauges["HeapReleased"] = float64(memStats.HeapReleased)
-	c.gauges["HeapSys"] = float64(memStats.HeapSys)
-	c.gauges["LastGC"] = float64(memStats.LastGC)
-	c.gauges["Lookups"] = float64(memStats.Lookups)
-	c.gauges["MCacheInuse"] = float64(memStats.MCacheInuse)
-	c.gauges["MCacheSys"] = float64(memStats.MCacheSys)
-	c.gauges["MSpanInuse"] = float64(memStats.MSpanInuse)
-	c.gauges["MSpanSys"] = float64(memStats.MSpanSys)
-	c.gauges["Mallocs"] = float64(memStats.Mallocs)
-	c.gauges["NextGC"] = float64(memStats.NextGC)
-	c.gauges["NumForcedGC"] = float64(memStats.NumForcedGC)
-	c.gauges["NumGC"] = float64(memStats.NumGC)
-	c.gauges["OtherSys"] = float64(memStats.OtherSys)
-	c.gauges["PauseTotalNs"] = float64(memStats.PauseTotalNs)
-	c.gauges["StackInuse"] = float64(memStats.StackInuse)
-	c.gauges["StackSys"] = float64(memStats.StackSys)
-	c.gauges["Sys"] = float64(memStats.Sys)
-	c.gauges["TotalAlloc"] = float64(memStats.TotalAlloc)
-
-	c.gauges["RandomValue"] = rand.Float64()
+// gather runs each input and merges its metrics into gauges/counters under
+// the collector's lock. A failing input is skipped so it can't block the
+// others from reporting.
+func (c *Collector) gather(inputs []Input) {
+	ctx := context.Background()
+	for _, in := range inputs {
+		metrics, err := in.Gather(ctx)
+		if err != nil {
+			continue
+		}
 
-	c.pollCount++
-	c.counters["PollCount"] = c.pollCount
+		c.mu.Lock()
+		for _, m := range metrics {
+			if m.IsCounter {
+				c.counters[m.Name] += int64(m.Value)
+			} else {
+				c.gauges[m.Name] = m.Value
+			}
+		}
+		c.mu.Unlock()
+	}
 }
 
-func (c *Collector) Report(serverAddress string) error {
+// GetMetrics returns a snapshot copy of the current gauges and counters.
+func (c *Collector) GetMetrics() (map[string]float64, map[string]int64) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	for name, value := range c.gauges {
-		url := fmt.Sprintf("%s/update/gauge/%s/%f", serverAddress, name, value)
-		if err := sendMetric(url); err != nil {
-			return err
-		}
+	gauges := make(map[string]float64, len(c.gauges))
+	for k, v := range c.gauges {
+		gauges[k] = v
 	}
-	for name, value := range c.counters {
-		url := fmt.Sprintf("%s/update/counter/%s/%d", serverAddress, name, value)
-		if err := sendMetric(url); err != nil {
-			return err
-		}
+	counters := make(map[string]int64, len(c.counters))
+	for k, v := range c.counters {
+		counters[k] = v
 	}
-	return nil
+	return gauges, counters
 }
 
-func sendMetric(url string) error {
-	req, err := http.NewRequest(http.MethodPost, url, nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "text/plain")
+// Report sends the collected gauges and counters to sink. Supported sinks
+// include an HTTP per-metric POST sink and a StatsD/DogStatsD UDP sink; see
+// NewHTTPSink and NewStatsDSink.
+func (c *Collector) Report(sink Sink) error {
+	gauges, counters := c.GetMetrics()
 
-	client := &http.Client{Timeout: 5 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
+	if err := sink.SendGauges(gauges); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	return sink.SendCounters(counters)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-	return nil
+// runtimeInput gathers the Go runtime.MemStats gauges plus RandomValue, the
+// metrics the original spec required before the Input framework existed.
+type runtimeInput struct{}
+
+func (runtimeInput) Name() string { return "runtime" }
+
+func (runtimeInput) Gather(_ context.Context) ([]Metric, error) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	return []Metric{
+		{Name: "Alloc", Value: float64(memStats.Alloc)},
+		{Name: "BuckHashSys", Value: float64(memStats.BuckHashSys)},
+		{Name: "Frees", Value: float64(memStats.Frees)},
+		{Name: "GCCPUFraction", Value: memStats.GCCPUFraction},
+		{Name: "GCSys", Value: float64(memStats.GCSys)},
+		{Name: "HeapAlloc", Value: float64(memStats.HeapAlloc)},
+		{Name: "HeapIdle", Value: float64(memStats.HeapIdle)},
+		{Name: "HeapInuse", Value: float64(memStats.HeapInuse)},
+		{Name: "HeapObjects", Value: float64(memStats.HeapObjects)},
+		{Name: "HeapReleased", Value: float64(memStats.HeapReleased)},
+		{Name: "HeapSys", Value: float64(memStats.HeapSys)},
+		{Name: "LastGC", Value: float64(memStats.LastGC)},
+		{Name: "Lookups", Value: float64(memStats.Lookups)},
+		{Name: "MCacheInuse", Value: float64(memStats.MCacheInuse)},
+		{Name: "MCacheSys", Value: float64(memStats.MCacheSys)},
+		{Name: "MSpanInuse", Value: float64(memStats.MSpanInuse)},
+		{Name: "MSpanSys", Value: float64(memStats.MSpanSys)},
+		{Name: "Mallocs", Value: float64(memStats.Mallocs)},
+		{Name: "NextGC", Value: float64(memStats.NextGC)},
+		{Name: "NumForcedGC", Value: float64(memStats.NumForcedGC)},
+		{Name: "NumGC", Value: float64(memStats.NumGC)},
+		{Name: "OtherSys", Value: float64(memStats.OtherSys)},
+		{Name: "PauseTotalNs", Value: float64(memStats.PauseTotalNs)},
+		{Name: "StackInuse", Value: float64(memStats.StackInuse)},
+		{Name: "StackSys", Value: float64(memStats.StackSys)},
+		{Name: "Sys", Value: float64(memStats.Sys)},
+		{Name: "TotalAlloc", Value: float64(memStats.TotalAlloc)},
+		{Name: "RandomValue", Value: rand.Float64()},
+	}, nil
 }
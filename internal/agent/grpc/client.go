@@ -3,16 +3,20 @@ package grpc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/idudko/go-musthave-metrics/internal/model"
 	"github.com/idudko/go-musthave-metrics/internal/proto"
+	"github.com/idudko/go-musthave-metrics/pkg/hash"
 	"github.com/rs/zerolog/log"
+	protobuf "google.golang.org/protobuf/proto"
 )
 
 // MetricsClient представляет gRPC клиент для отправки метрик
@@ -20,20 +24,42 @@ type MetricsClient struct {
 	client     proto.MetricsClient
 	conn       *grpc.ClientConn
 	serverAddr string
+	key        string
+	retry      RetryConfig
 }
 
-// NewMetricsClient создаёт новый gRPC клиент для работы с метриками
-func NewMetricsClient(serverAddr string) (*MetricsClient, error) {
+// NewMetricsClient создаёт новый gRPC клиент для работы с метриками.
+// key, если задан, используется для подписи запросов HMAC-SHA256 в метаданных
+// "hashsha256", зеркалируя HashSHA256 заголовок на HTTP-транспорте. retry
+// controls how UpdateMetrics retries transient failures; its zero value
+// falls back to DefaultRetryConfig. tlsConfig, when TLSConfig.Enabled, dials
+// over TLS (optionally mutual TLS) instead of the plaintext insecure
+// credentials used otherwise.
+func NewMetricsClient(serverAddr string, key string, retry RetryConfig, tlsConfig TLSConfig) (*MetricsClient, error) {
+	creds, err := tlsConfig.credentials()
+	if err != nil {
+		return nil, err
+	}
+	if creds == nil {
+		creds = insecure.NewCredentials()
+	}
+
 	// Устанавливаем соединение с сервером
-	conn, err := grpc.NewClient(serverAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := grpc.NewClient(serverAddr, grpc.WithTransportCredentials(creds))
 	if err != nil {
 		return nil, err
 	}
 
+	if retry == (RetryConfig{}) {
+		retry = DefaultRetryConfig
+	}
+
 	client := &MetricsClient{
 		client:     proto.NewMetricsClient(conn),
 		conn:       conn,
 		serverAddr: serverAddr,
+		key:        key,
+		retry:      retry,
 	}
 
 	return client, nil
@@ -82,20 +108,59 @@ func (c *MetricsClient) UpdateMetrics(ctx context.Context, metrics []model.Metri
 	defer cancel()
 
 	// Создаём метаданные с IP-адресом клиента
-	md := &metadata.MD{
+	md := metadata.MD{
 		"x-real-ip": []string{clientIP},
 	}
-	ctx = metadata.NewOutgoingContext(ctx, *md)
 
-	// Отправляем запрос на сервер
-	_, err := c.client.UpdateMetrics(ctx, req)
-	if err != nil {
-		log.Error().Err(err).Str("server_addr", c.serverAddr).Int("metrics_count", len(metrics)).Msg("Failed to send metrics via gRPC")
-		return err
+	if c.key != "" {
+		data, err := protobuf.Marshal(req)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request for HMAC signing: %w", err)
+		}
+		md.Set("hashsha256", hash.ComputeHash(data, c.key, hash.SHA256))
 	}
 
-	log.Debug().Int("metrics_count", len(metrics)).Str("client_ip", clientIP).Msg("Successfully sent metrics via gRPC")
-	return nil
+	ctx = metadata.NewOutgoingContext(ctx, md)
+
+	// Отправляем запрос на сервер, повторяя попытку при транзиентных ошибках
+	var lastErr error
+	var pushback time.Duration
+	havePushback := false
+
+	for attempt := 0; attempt <= c.retry.MaxRetries; attempt++ {
+		if attempt > 0 {
+			d := c.retry.delay(attempt)
+			if havePushback {
+				d = pushback
+			}
+			log.Warn().Int("attempt", attempt).Str("last_error_code", status.Code(lastErr).String()).Dur("delay", d).Msg("Retrying gRPC UpdateMetrics")
+
+			select {
+			case <-time.After(d):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		var trailer metadata.MD
+		_, err := c.client.UpdateMetrics(ctx, req, grpc.Trailer(&trailer))
+		if err == nil {
+			log.Debug().Int("metrics_count", len(metrics)).Str("client_ip", clientIP).Msg("Successfully sent metrics via gRPC")
+			return nil
+		}
+
+		lastErr = err
+		pushback, havePushback = parsePushback(trailer)
+		if havePushback && pushback < 0 {
+			break
+		}
+		if !isRetryableError(err) {
+			break
+		}
+	}
+
+	log.Error().Err(lastErr).Str("server_addr", c.serverAddr).Int("metrics_count", len(metrics)).Msg("Failed to send metrics via gRPC")
+	return lastErr
 }
 
 // convertStringToProtoMType конвертирует строковый тип метрики в protobuf тип
@@ -0,0 +1,95 @@
+package grpc
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RetryConfig controls how MetricsClient retries a transient gRPC failure.
+// It is exposed as a struct (rather than package-level constants) so tests
+// can shrink the delays and production deployments can tune them per
+// environment.
+type RetryConfig struct {
+	// MaxRetries is the number of retry attempts after the first try. 0
+	// disables retries entirely.
+	MaxRetries int
+	// BaseDelay is the backoff for the first retry (n=1).
+	BaseDelay time.Duration
+	// MaxDelay caps the computed backoff before jitter is applied.
+	MaxDelay time.Duration
+	// Jitter is the fraction of the computed delay to randomize by, applied
+	// as delay * (1 ± Jitter). 0 disables jitter.
+	Jitter float64
+}
+
+// DefaultRetryConfig backs off starting at 500ms, doubling up to a 30s cap,
+// jittered by ±50%, for up to 5 retries.
+var DefaultRetryConfig = RetryConfig{
+	MaxRetries: 5,
+	BaseDelay:  500 * time.Millisecond,
+	MaxDelay:   30 * time.Second,
+	Jitter:     0.5,
+}
+
+// delay computes the backoff before retry attempt n (n starts at 1):
+// min(MaxDelay, BaseDelay*2^(n-1)) jittered by ±Jitter.
+func (cfg RetryConfig) delay(n int) time.Duration {
+	d := float64(cfg.BaseDelay) * math.Pow(2, float64(n-1))
+	if cfg.MaxDelay > 0 && d > float64(cfg.MaxDelay) {
+		d = float64(cfg.MaxDelay)
+	}
+	if cfg.Jitter > 0 {
+		d += d * cfg.Jitter * (2*rand.Float64() - 1)
+		if d < 0 {
+			d = 0
+		}
+	}
+	return time.Duration(d)
+}
+
+// retryableCodes are the gRPC status codes considered transient and worth
+// retrying.
+var retryableCodes = map[codes.Code]bool{
+	codes.Unavailable:       true,
+	codes.DeadlineExceeded:  true,
+	codes.ResourceExhausted: true,
+	codes.Aborted:           true,
+	codes.Internal:          true,
+}
+
+// isRetryableError reports whether err is a transient failure worth
+// retrying: one of retryableCodes, or a non-status error such as a network
+// failure that never reached the server.
+func isRetryableError(err error) bool {
+	st, ok := status.FromError(err)
+	if !ok {
+		return true
+	}
+	return retryableCodes[st.Code()]
+}
+
+// pushbackTrailer is the gRFC A6 retry-pushback trailer key: a
+// non-negative value overrides the client's own backoff with the exact
+// delay (in milliseconds) the server wants before the next retry; a
+// negative value tells the client not to retry at all.
+const pushbackTrailer = "grpc-retry-pushback-ms"
+
+// parsePushback extracts a grpc-retry-pushback-ms value from trailer, if
+// present.
+func parsePushback(trailer metadata.MD) (time.Duration, bool) {
+	values := trailer.Get(pushbackTrailer)
+	if len(values) == 0 {
+		return 0, false
+	}
+	ms, err := strconv.Atoi(values[0])
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(ms) * time.Millisecond, true
+}
@@ -0,0 +1,87 @@
+package grpc
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryConfig_Delay(t *testing.T) {
+	cfg := RetryConfig{BaseDelay: 500 * time.Millisecond, MaxDelay: 2 * time.Second, Jitter: 0}
+
+	cases := []struct {
+		n    int
+		want time.Duration
+	}{
+		{1, 500 * time.Millisecond},
+		{2, time.Second},
+		{3, 2 * time.Second}, // would be 2s exactly
+		{4, 2 * time.Second}, // capped, would be 4s uncapped
+	}
+	for _, tc := range cases {
+		if got := cfg.delay(tc.n); got != tc.want {
+			t.Errorf("delay(%d) = %v, want %v", tc.n, got, tc.want)
+		}
+	}
+}
+
+func TestRetryConfig_DelayJitterStaysInRange(t *testing.T) {
+	cfg := RetryConfig{BaseDelay: time.Second, MaxDelay: 10 * time.Second, Jitter: 0.5}
+	for i := 0; i < 50; i++ {
+		d := cfg.delay(1)
+		if d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("delay(1) = %v, want in [500ms, 1500ms]", d)
+		}
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"unavailable", status.Error(codes.Unavailable, "down"), true},
+		{"deadline_exceeded", status.Error(codes.DeadlineExceeded, "slow"), true},
+		{"resource_exhausted", status.Error(codes.ResourceExhausted, "busy"), true},
+		{"aborted", status.Error(codes.Aborted, "conflict"), true},
+		{"internal", status.Error(codes.Internal, "oops"), true},
+		{"invalid_argument", status.Error(codes.InvalidArgument, "bad"), false},
+		{"not_found", status.Error(codes.NotFound, "missing"), false},
+		{"non_status_error", errors.New("connection refused"), true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isRetryableError(tc.err); got != tc.want {
+				t.Errorf("isRetryableError(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParsePushback(t *testing.T) {
+	if _, ok := parsePushback(metadata.MD{}); ok {
+		t.Error("parsePushback on empty trailer should report not-present")
+	}
+
+	trailer := metadata.MD{pushbackTrailer: []string{"250"}}
+	d, ok := parsePushback(trailer)
+	if !ok || d != 250*time.Millisecond {
+		t.Errorf("parsePushback(%v) = %v, %v, want 250ms, true", trailer, d, ok)
+	}
+
+	negative := metadata.MD{pushbackTrailer: []string{"-1"}}
+	d, ok = parsePushback(negative)
+	if !ok || d >= 0 {
+		t.Errorf("parsePushback(%v) = %v, %v, want a negative duration, true", negative, d, ok)
+	}
+
+	malformed := metadata.MD{pushbackTrailer: []string{"not-a-number"}}
+	if _, ok := parsePushback(malformed); ok {
+		t.Error("parsePushback on a malformed value should report not-present")
+	}
+}
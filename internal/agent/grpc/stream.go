@@ -0,0 +1,66 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/idudko/go-musthave-metrics/internal/model"
+	"github.com/idudko/go-musthave-metrics/internal/proto"
+)
+
+// StreamSender wraps one long-lived StreamMetrics stream, so a caller that
+// reports metrics continuously pays the dial/handshake cost once instead
+// of once per UpdateMetrics call. Callers must drain Recv to observe
+// backpressure: a StreamAck.Applied lagging StreamAck.Received means the
+// server is falling behind.
+type StreamSender struct {
+	stream proto.Metrics_StreamMetricsClient
+}
+
+// StartStream opens a single StreamMetrics stream that Send reuses for
+// every subsequent metric, instead of dialing a new unary UpdateMetrics
+// call per batch. The stream stays open until ctx is canceled, the server
+// closes it, or CloseSend is called.
+func (c *MetricsClient) StartStream(ctx context.Context) (*StreamSender, error) {
+	stream, err := c.client.StreamMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StreamSender{stream: stream}, nil
+}
+
+// Send converts m to its protobuf representation and writes it to the
+// stream.
+func (s *StreamSender) Send(m model.Metrics) error {
+	protoMetric := &proto.Metric{
+		Id:   m.ID,
+		Type: convertStringToProtoMType(m.MType),
+	}
+
+	switch m.MType {
+	case model.Gauge:
+		if m.Value != nil {
+			protoMetric.Value = *m.Value
+		}
+	case model.Counter:
+		if m.Delta != nil {
+			protoMetric.Delta = *m.Delta
+		}
+	}
+
+	return s.stream.Send(protoMetric)
+}
+
+// Recv blocks for the next StreamAck from the server, or returns io.EOF
+// once the server has finished acking after the stream closed.
+func (s *StreamSender) Recv() (*proto.StreamAck, error) {
+	return s.stream.Recv()
+}
+
+// CloseSend half-closes the stream, signalling the server that no more
+// Metric messages are coming. The server keeps sending StreamAcks until it
+// has applied everything already received, so callers should keep calling
+// Recv until it returns io.EOF.
+func (s *StreamSender) CloseSend() error {
+	return s.stream.CloseSend()
+}
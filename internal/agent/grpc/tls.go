@@ -0,0 +1,68 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// TLSConfig configures optional TLS (and, with CertFile/KeyFile set,
+// mutual TLS) for MetricsClient's connection to the server. The zero value
+// disables TLS, leaving the client on insecure.NewCredentials() as before.
+type TLSConfig struct {
+	// CAFile, if set, verifies the server certificate against this CA
+	// instead of the host's default trust store. Required for a server
+	// certificate that isn't publicly trusted (e.g. self-signed or a
+	// private CA).
+	CAFile string
+
+	// CertFile and KeyFile, if both set, present a client certificate for
+	// mutual TLS, matching a server started with TLSConfig.ClientCAFile.
+	CertFile string
+	KeyFile  string
+
+	// ServerName overrides the name used for both server certificate
+	// verification and SNI, for when it differs from the host in the
+	// dial address (e.g. dialing by IP).
+	ServerName string
+}
+
+// Enabled reports whether c configures TLS at all.
+func (c TLSConfig) Enabled() bool {
+	return c.CAFile != "" || c.CertFile != "" || c.ServerName != ""
+}
+
+// credentials builds transport credentials from c, or returns nil, nil if
+// TLS is disabled so callers can fall back to insecure.NewCredentials().
+func (c TLSConfig) credentials() (credentials.TransportCredentials, error) {
+	if !c.Enabled() {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{ServerName: c.ServerName}
+
+	if c.CAFile != "" {
+		caCert, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read server CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse server CA certificate %q", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if c.CertFile != "" && c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(cfg), nil
+}
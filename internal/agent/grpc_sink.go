@@ -0,0 +1,158 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/idudko/go-musthave-metrics/internal/proto"
+	"github.com/idudko/go-musthave-metrics/pkg/pool"
+)
+
+// defaultGRPCBatchSize and defaultGRPCFlushInterval bound how long metrics
+// sit in grpcSink's buffer before being flushed as one MetricBatch message,
+// whichever limit is hit first.
+const (
+	defaultGRPCBatchSize     = 100
+	defaultGRPCFlushInterval = 2 * time.Second
+)
+
+// grpcSink reports metrics over a single long-lived PushBatch stream,
+// buffering up to maxBatchSize metrics (or flushInterval, whichever comes
+// first) into one MetricBatch message instead of httpSink's one-POST-per-
+// metric fan-out. Batches are drawn from a pool.Pool to avoid a fresh
+// allocation on every flush.
+type grpcSink struct {
+	conn   *grpc.ClientConn
+	stream proto.Metrics_PushBatchClient
+	pool   *pool.Pool[*proto.MetricBatch]
+
+	mu           sync.Mutex
+	buf          *proto.MetricBatch
+	maxBatchSize int
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewGRPCSink dials addr and opens a PushBatch stream, starting a background
+// goroutine that flushes the buffer every defaultGRPCFlushInterval even if
+// it hasn't reached defaultGRPCBatchSize yet.
+func NewGRPCSink(addr string) (Sink, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial grpc address %q: %w", addr, err)
+	}
+
+	stream, err := proto.NewMetricsClient(conn).PushBatch(context.Background())
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open PushBatch stream: %w", err)
+	}
+
+	batchPool := pool.New(func() *proto.MetricBatch { return &proto.MetricBatch{} })
+
+	s := &grpcSink{
+		conn:         conn,
+		stream:       stream,
+		pool:         batchPool,
+		buf:          batchPool.Get(),
+		maxBatchSize: defaultGRPCBatchSize,
+		done:         make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.flushOnTicker()
+
+	return s, nil
+}
+
+func (s *grpcSink) flushOnTicker() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(defaultGRPCFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			_ = s.flushLocked()
+			s.mu.Unlock()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+func (s *grpcSink) SendGauges(gauges map[string]float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, value := range gauges {
+		s.buf.Metrics = append(s.buf.Metrics, &proto.Metric{Id: name, Type: proto.Metric_GAUGE, Value: value})
+		if len(s.buf.Metrics) >= s.maxBatchSize {
+			if err := s.flushLocked(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *grpcSink) SendCounters(counters map[string]int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, value := range counters {
+		s.buf.Metrics = append(s.buf.Metrics, &proto.Metric{Id: name, Type: proto.Metric_COUNTER, Delta: value})
+		if len(s.buf.Metrics) >= s.maxBatchSize {
+			if err := s.flushLocked(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// flushLocked sends the current buffer as one MetricBatch message, puts the
+// sent buffer back into the pool, and takes a fresh one from it. Must be
+// called with s.mu held.
+func (s *grpcSink) flushLocked() error {
+	if len(s.buf.Metrics) == 0 {
+		return nil
+	}
+
+	err := s.stream.Send(s.buf)
+
+	s.pool.Put(s.buf)
+	s.buf = s.pool.Get()
+
+	return err
+}
+
+// Close flushes any buffered metrics, closes the send side of the stream,
+// waits for the server's Ack, and tears down the connection.
+func (s *grpcSink) Close() error {
+	close(s.done)
+	s.wg.Wait()
+
+	s.mu.Lock()
+	err := s.flushLocked()
+	s.mu.Unlock()
+	if err != nil {
+		s.conn.Close()
+		return err
+	}
+
+	if _, err := s.stream.CloseAndRecv(); err != nil {
+		s.conn.Close()
+		return err
+	}
+
+	return s.conn.Close()
+}
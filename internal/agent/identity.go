@@ -0,0 +1,41 @@
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/idudko/go-musthave-metrics/internal/netutil"
+)
+
+// AgentIdentity is the stable identity a MetricsService presents to the
+// server's heartbeat endpoint. ID is derived from the hostname and the MAC
+// address of the interface netutil.GetLocalIP would pick, so it survives
+// restarts and IP changes but still distinguishes two agents on the same
+// host (different containers typically get different MACs).
+type AgentIdentity struct {
+	ID       string
+	Hostname string
+	IP       string
+}
+
+// NewAgentIdentity derives an AgentIdentity for the local host.
+func NewAgentIdentity() (AgentIdentity, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return AgentIdentity{}, fmt.Errorf("failed to determine hostname: %w", err)
+	}
+
+	ip, mac, err := netutil.GetLocalIPAndMAC()
+	if err != nil {
+		return AgentIdentity{}, fmt.Errorf("failed to determine local IP/MAC: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(hostname + mac))
+	return AgentIdentity{
+		ID:       hex.EncodeToString(sum[:])[:16],
+		Hostname: hostname,
+		IP:       ip,
+	}, nil
+}
@@ -5,10 +5,11 @@ import (
 	"compress/gzip"
 	"context"
 	"crypto/rsa"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
-	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/goccy/go-json"
@@ -21,72 +22,106 @@ import (
 
 type Sender struct {
 	key       string
-	cryptoKey *rsa.PublicKey
+	cryptoKey atomic.Pointer[rsa.PublicKey]
+
+	// scheme is "http" or "https"; set via NewSender's scheme argument.
+	scheme string
+	// transport carries TLS settings (certificate pinning) for the "https"
+	// scheme; nil means the http.DefaultTransport settings are used.
+	transport *http.Transport
+
+	// RetryBackoff computes the delay between retry attempts. It defaults to
+	// DefaultRetryBackoff but can be overridden, e.g. in tests or to tune
+	// how aggressively the agent backs off under server rate-limiting.
+	RetryBackoff RetryBackoff
 }
 
-func NewSender(key string, cryptoKeyPath string) *Sender {
-	var cryptoKey *rsa.PublicKey
+// NewSender creates a Sender that talks to the server over the given scheme
+// ("http" or "https"; empty defaults to "http"). If certFingerprint is set,
+// the Sender pins the server's certificate to that SHA-256 fingerprint
+// (hex-encoded) instead of validating it against the system trust store,
+// which is useful for zero-trust deployments with self-issued certificates.
+func NewSender(key string, cryptoKeyPath string, scheme string, certFingerprint string) *Sender {
+	if scheme == "" {
+		scheme = "http"
+	}
+
+	var transport *http.Transport
+	if scheme == "https" && certFingerprint != "" {
+		transport = &http.Transport{
+			TLSClientConfig: &tls.Config{
+				// The system trust store is bypassed in favor of pinning the
+				// exact certificate fingerprint below.
+				InsecureSkipVerify:    true,
+				VerifyPeerCertificate: pinnedCertVerifier(certFingerprint),
+			},
+		}
+	}
+
+	s := &Sender{
+		key:          key,
+		scheme:       scheme,
+		transport:    transport,
+		RetryBackoff: DefaultRetryBackoff,
+	}
+
 	if cryptoKeyPath != "" {
-		pubKey, err := crypto.LoadPublicKey(cryptoKeyPath)
-		if err != nil {
+		if err := s.SetCryptoKey(cryptoKeyPath); err != nil {
 			// Log error but continue - encryption will be disabled
 			log.Printf("Failed to load public key: %v. Encryption will be disabled.", err)
-		} else {
-			cryptoKey = pubKey
 		}
 	}
 
-	return &Sender{
-		key:       key,
-		cryptoKey: cryptoKey,
-	}
+	return s
 }
 
-func (s *Sender) SendMetricJSON(ctx context.Context, serverAddress string, m *model.Metrics) error {
-	url := fmt.Sprintf("http://%s/update", serverAddress)
-	retryIntervals := []time.Duration{1 * time.Second, 3 * time.Second, 5 * time.Second}
-
-	err := s.doSendMetricJSON(ctx, url, m)
-	if err == nil {
+// SetCryptoKey (re)loads the PEM-encoded public key at path and swaps it in
+// atomically, so a config reload can rotate or newly enable encryption
+// without racing an in-flight SendMetricJSON/SendMetricsBatch call. An empty
+// path disables encryption by clearing the key.
+func (s *Sender) SetCryptoKey(path string) error {
+	if path == "" {
+		s.cryptoKey.Store(nil)
 		return nil
 	}
 
-	for _, interval := range retryIntervals {
-		select {
-		case <-time.After(interval):
-			err = s.doSendMetricJSON(ctx, url, m)
-			if err == nil {
-				return nil
-			}
-		case <-ctx.Done():
-			return ctx.Err()
-		}
+	pubKey, err := crypto.LoadPublicKey(path)
+	if err != nil {
+		return err
 	}
+	s.cryptoKey.Store(pubKey)
+	return nil
+}
 
-	return err
+func (s *Sender) SendMetricJSON(ctx context.Context, serverAddress string, m *model.Metrics) error {
+	url := fmt.Sprintf("%s://%s/update", s.scheme, serverAddress)
+	return s.sendWithRetry(ctx, func() (*http.Request, *http.Response, error) {
+		return s.doSendMetricJSON(ctx, url, m)
+	})
 }
 
-func (s *Sender) doSendMetricJSON(ctx context.Context, url string, m *model.Metrics) error {
+func (s *Sender) doSendMetricJSON(ctx context.Context, url string, m *model.Metrics) (*http.Request, *http.Response, error) {
 	data, err := json.Marshal(m)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	// Get local IP address
 	localIP, err := getLocalIP()
 	if err != nil {
-		return fmt.Errorf("failed to get local IP: %w", err)
+		return nil, nil, fmt.Errorf("failed to get local IP: %w", err)
 	}
 
 	var b bytes.Buffer
 	var requestBody []byte
 	var contentEncoding string
 
-	if s.cryptoKey != nil {
-		// Encrypt with RSA public key
-		encryptedData, err := crypto.Encrypt(data, s.cryptoKey)
+	cryptoKey := s.cryptoKey.Load()
+	if cryptoKey != nil {
+		// Encrypt with hybrid RSA+AES-GCM framing (handles arbitrarily large payloads)
+		encryptedData, err := crypto.EncryptHybrid(data, cryptoKey)
 		if err != nil {
-			return fmt.Errorf("failed to encrypt data: %w", err)
+			return nil, nil, fmt.Errorf("failed to encrypt data: %w", err)
 		}
 		requestBody = encryptedData
 		b.Write(encryptedData)
@@ -95,10 +130,10 @@ func (s *Sender) doSendMetricJSON(ctx context.Context, url string, m *model.Metr
 		// Compress with gzip
 		gw := gzip.NewWriter(&b)
 		if _, err := gw.Write(data); err != nil {
-			return err
+			return nil, nil, err
 		}
 		if err := gw.Close(); err != nil {
-			return err
+			return nil, nil, err
 		}
 		requestBody = b.Bytes()
 		contentEncoding = "gzip"
@@ -106,79 +141,58 @@ func (s *Sender) doSendMetricJSON(ctx context.Context, url string, m *model.Metr
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &b)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Content-Encoding", contentEncoding)
 	req.Header.Set("X-Real-IP", localIP)
 
-	if s.key != "" && s.cryptoKey == nil {
-		hashValue := hash.ComputeHash(requestBody, s.key)
+	if s.key != "" && cryptoKey == nil {
+		hashValue := hash.ComputeHash(requestBody, s.key, hash.SHA256)
 		req.Header.Set("HashSHA256", hashValue)
 	}
 
-	client := &http.Client{Timeout: 5 * time.Second}
+	client := &http.Client{Timeout: 5 * time.Second, Transport: s.transport}
 	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return req, nil, err
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return req, resp, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
-	return nil
+	return req, resp, nil
 }
 
 func (s *Sender) SendMetricsBatch(ctx context.Context, serverAddress string, metrics []*model.Metrics) error {
-	url := fmt.Sprintf("http://%s/updates", serverAddress)
-	retryIntervals := []time.Duration{1 * time.Second, 3 * time.Second, 5 * time.Second}
-
-	err := s.doSendMetricsBatch(ctx, url, metrics)
-	if err == nil {
-		return nil
-	}
-
-	if strings.Contains(err.Error(), "400") {
-		return nil
-	}
-
-	for _, interval := range retryIntervals {
-		select {
-		case <-time.After(interval):
-			err = s.doSendMetricsBatch(ctx, url, metrics)
-			if err == nil {
-				return nil
-			}
-		case <-ctx.Done():
-			return ctx.Err()
-		}
-	}
-
-	return err
+	url := fmt.Sprintf("%s://%s/updates", s.scheme, serverAddress)
+	return s.sendWithRetry(ctx, func() (*http.Request, *http.Response, error) {
+		return s.doSendMetricsBatch(ctx, url, metrics)
+	})
 }
 
-func (s *Sender) doSendMetricsBatch(ctx context.Context, url string, metrics []*model.Metrics) error {
+func (s *Sender) doSendMetricsBatch(ctx context.Context, url string, metrics []*model.Metrics) (*http.Request, *http.Response, error) {
 	data, err := json.Marshal(metrics)
 	if err != nil {
-		return fmt.Errorf("failed to marshal metrics: %w", err)
+		return nil, nil, fmt.Errorf("failed to marshal metrics: %w", err)
 	}
 
 	// Get local IP address
 	localIP, err := getLocalIP()
 	if err != nil {
-		return fmt.Errorf("failed to get local IP: %w", err)
+		return nil, nil, fmt.Errorf("failed to get local IP: %w", err)
 	}
 
 	var b bytes.Buffer
 	var requestBody []byte
 	var contentEncoding string
 
-	if s.cryptoKey != nil {
-		// Encrypt with RSA public key
-		encryptedData, err := crypto.Encrypt(data, s.cryptoKey)
+	cryptoKey := s.cryptoKey.Load()
+	if cryptoKey != nil {
+		// Encrypt with hybrid RSA+AES-GCM framing (handles arbitrarily large payloads)
+		encryptedData, err := crypto.EncryptHybrid(data, cryptoKey)
 		if err != nil {
-			return fmt.Errorf("failed to encrypt data: %w", err)
+			return nil, nil, fmt.Errorf("failed to encrypt data: %w", err)
 		}
 		requestBody = encryptedData
 		b.Write(encryptedData)
@@ -187,10 +201,10 @@ func (s *Sender) doSendMetricsBatch(ctx context.Context, url string, metrics []*
 		// Compress with gzip
 		gw := gzip.NewWriter(&b)
 		if _, err := gw.Write(data); err != nil {
-			return fmt.Errorf("failed to write data to gzip writer: %w", err)
+			return nil, nil, fmt.Errorf("failed to write data to gzip writer: %w", err)
 		}
 		if err := gw.Close(); err != nil {
-			return fmt.Errorf("failed to close gzip writer: %w", err)
+			return nil, nil, fmt.Errorf("failed to close gzip writer: %w", err)
 		}
 		requestBody = b.Bytes()
 		contentEncoding = "gzip"
@@ -198,29 +212,102 @@ func (s *Sender) doSendMetricsBatch(ctx context.Context, url string, metrics []*
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &b)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Content-Encoding", contentEncoding)
 	req.Header.Set("X-Real-IP", localIP)
 
-	if s.key != "" && s.cryptoKey == nil {
-		hashValue := hash.ComputeHash(requestBody, s.key)
+	if s.key != "" && cryptoKey == nil {
+		hashValue := hash.ComputeHash(requestBody, s.key, hash.SHA256)
 		req.Header.Set("HashSHA256", hashValue)
 	}
 
-	client := &http.Client{Timeout: 30 * time.Second}
+	client := &http.Client{Timeout: 30 * time.Second, Transport: s.transport}
 	resp, err := client.Do(req)
 	if err != nil {
-		return err
+		return req, nil, err
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return req, resp, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return req, resp, nil
+}
+
+// SendHeartbeat posts hb to the server's agent registry. Unlike
+// SendMetricJSON/SendMetricsBatch, the body is plain JSON - heartbeats carry
+// no metric values worth compressing or encrypting, just identity and
+// liveness metadata.
+func (s *Sender) SendHeartbeat(ctx context.Context, serverAddress string, hb *model.AgentHeartbeat) error {
+	url := fmt.Sprintf("%s://%s/api/v1/agents/heartbeat", s.scheme, serverAddress)
+	return s.sendWithRetry(ctx, func() (*http.Request, *http.Response, error) {
+		return s.doSendHeartbeat(ctx, url, hb)
+	})
+}
+
+func (s *Sender) doSendHeartbeat(ctx context.Context, url string, hb *model.AgentHeartbeat) (*http.Request, *http.Response, error) {
+	data, err := json.Marshal(hb)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal heartbeat: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if s.key != "" {
+		hashValue := hash.ComputeHash(data, s.key, hash.SHA256)
+		req.Header.Set("HashSHA256", hashValue)
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second, Transport: s.transport}
+	resp, err := client.Do(req)
+	if err != nil {
+		return req, nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return req, resp, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return req, resp, nil
+}
+
+// sendWithRetry runs send repeatedly, waiting between attempts as determined by
+// s.RetryBackoff, until it succeeds, the backoff aborts the loop (delay <= 0), or
+// ctx is canceled.
+func (s *Sender) sendWithRetry(ctx context.Context, send func() (*http.Request, *http.Response, error)) error {
+	backoff := s.RetryBackoff
+	if backoff == nil {
+		backoff = DefaultRetryBackoff
+	}
+
+	for n := 1; ; n++ {
+		req, resp, err := send()
+		if err == nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil
+		}
+
+		delay := backoff(n, req, resp)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if delay <= 0 {
+			return err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
-	return nil
 }
 
 // getLocalIP возвращает локальный IP адрес хоста
@@ -3,14 +3,21 @@ package agent
 import (
 	"context"
 	"log"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/idudko/go-musthave-metrics/internal/agent/grpc"
+	"github.com/idudko/go-musthave-metrics/internal/config"
 	"github.com/idudko/go-musthave-metrics/internal/model"
 	"github.com/idudko/go-musthave-metrics/internal/netutil"
 )
 
+// agentVersion is reported in the MetricsService's heartbeat so operators
+// can tell which build each agent in the fleet is running.
+const agentVersion = "dev"
+
 type MetricsService struct {
 	collector     *Collector
 	sender        *Sender
@@ -24,19 +31,27 @@ type MetricsService struct {
 	grpcClient  *grpc.MetricsClient
 	useGRPC     bool
 	localIP     string
+	identity    AgentIdentity
 	ctx         context.Context
 	cancel      context.CancelFunc
 	wg          sync.WaitGroup
 
 	workerPool *WorkerPool
+
+	// pollIntervalSec and reportIntervalSec hold the intervals currently in
+	// effect; the ticker loops below poll them each tick and reset their
+	// ticker when ApplyConfigChange has changed one, so a reload takes
+	// effect within one tick instead of requiring a restart.
+	pollIntervalSec   atomic.Int64
+	reportIntervalSec atomic.Int64
 }
 
-func NewMetricsService(serverAddress, grpcAddress, key string, useBatch bool, rateLimit int, cryptoKey string) *MetricsService {
+func NewMetricsService(serverAddress, grpcAddress, key string, useBatch bool, rateLimit int, cryptoKey string, scheme string, certFingerprint string, grpcTLS grpc.TLSConfig) *MetricsService {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	service := &MetricsService{
 		collector:     NewCollector(key),
-		sender:        NewSender(key, cryptoKey),
+		sender:        NewSender(key, cryptoKey, scheme, certFingerprint),
 		serverAddress: serverAddress,
 		grpcAddress:   grpcAddress,
 		useBatch:      useBatch,
@@ -45,12 +60,12 @@ func NewMetricsService(serverAddress, grpcAddress, key string, useBatch bool, ra
 		metricsChan:   make(chan []byte, 100),
 		ctx:           ctx,
 		cancel:        cancel,
-		workerPool:    NewWorkerPool(rateLimit),
+		workerPool:    NewWorkerPool("agent_worker_pool", rateLimit, 100),
 	}
 
 	// Инициализируем gRPC клиент, если указан адрес
 	if grpcAddress != "" {
-		client, err := grpc.NewMetricsClient(grpcAddress)
+		client, err := grpc.NewMetricsClient(grpcAddress, key, grpc.DefaultRetryConfig, grpcTLS)
 		if err != nil {
 			log.Printf("Failed to create gRPC client: %v. Falling back to HTTP.", err)
 		} else {
@@ -71,10 +86,24 @@ func NewMetricsService(serverAddress, grpcAddress, key string, useBatch bool, ra
 		}
 	}
 
+	identity, err := NewAgentIdentity()
+	if err != nil {
+		log.Printf("Failed to derive agent identity: %v. Heartbeats and agent_id stamping will be disabled.", err)
+	} else {
+		service.identity = identity
+	}
+
 	return service
 }
 
-func (s *MetricsService) Start(pollInterval, reportInterval int) {
+// Start begins metric collection and reporting. heartbeatInterval is the
+// cadence, in seconds, at which the agent registers with the server's
+// agent registry; it's independent of reportInterval since operators may
+// want fleet visibility refreshed on a different schedule than metrics
+// themselves. A heartbeatInterval of 0 disables heartbeats.
+func (s *MetricsService) Start(pollInterval, reportInterval, heartbeatInterval int) {
+	s.pollIntervalSec.Store(int64(pollInterval))
+	s.reportIntervalSec.Store(int64(reportInterval))
 	s.workerPool.Start(s.ctx)
 
 	s.wg.Add(1)
@@ -85,6 +114,11 @@ func (s *MetricsService) Start(pollInterval, reportInterval int) {
 
 	s.wg.Add(1)
 	go s.sendMetrics(reportInterval)
+
+	if heartbeatInterval > 0 && s.identity.ID != "" {
+		s.wg.Add(1)
+		go s.sendHeartbeats(heartbeatInterval)
+	}
 }
 
 func (s *MetricsService) Stop() {
@@ -137,17 +171,19 @@ func (s *MetricsService) sendFinalMetrics() {
 
 	for name, value := range counters {
 		m := model.Metrics{
-			ID:    name,
-			MType: model.Counter,
-			Delta: &value,
+			ID:      name,
+			MType:   model.Counter,
+			Delta:   &value,
+			AgentID: s.identity.ID,
 		}
 		metrics = append(metrics, m)
 	}
 	for name, value := range gauges {
 		m := model.Metrics{
-			ID:    name,
-			MType: model.Gauge,
-			Value: &value,
+			ID:      name,
+			MType:   model.Gauge,
+			Value:   &value,
+			AgentID: s.identity.ID,
 		}
 		metrics = append(metrics, m)
 	}
@@ -193,6 +229,7 @@ func (s *MetricsService) collectRuntimeMetrics(pollInterval int) {
 	for {
 		select {
 		case <-ticker.C:
+			s.resyncTicker(ticker, &pollInterval, s.pollIntervalSec.Load())
 			s.collector.Collect()
 		case <-s.ctx.Done():
 			return
@@ -209,6 +246,7 @@ func (s *MetricsService) collectSystemMetrics(pollInterval int) {
 	for {
 		select {
 		case <-ticker.C:
+			s.resyncTicker(ticker, &pollInterval, s.pollIntervalSec.Load())
 			s.collector.CollectSystemMetrics()
 		case <-s.ctx.Done():
 			return
@@ -225,6 +263,7 @@ func (s *MetricsService) sendMetrics(reportInterval int) {
 	for {
 		select {
 		case <-ticker.C:
+			s.resyncTicker(ticker, &reportInterval, s.reportIntervalSec.Load())
 			s.enqueueMetricsForSending()
 		case <-s.ctx.Done():
 			return
@@ -232,6 +271,53 @@ func (s *MetricsService) sendMetrics(reportInterval int) {
 	}
 }
 
+// resyncTicker resets ticker to desiredSec, in seconds, when it differs from
+// *currentSec, and updates *currentSec to match. Called at the top of each
+// tick so a config reload's new interval takes effect within one tick
+// instead of requiring the collector/sender goroutines to restart.
+func (s *MetricsService) resyncTicker(ticker *time.Ticker, currentSec *int, desiredSec int64) {
+	if desiredSec <= 0 || int64(*currentSec) == desiredSec {
+		return
+	}
+	*currentSec = int(desiredSec)
+	ticker.Reset(time.Duration(desiredSec) * time.Second)
+}
+
+// sendHeartbeats posts the agent's identity and liveness to the server's
+// agent registry every heartbeatInterval seconds, until s.ctx is canceled.
+func (s *MetricsService) sendHeartbeats(heartbeatInterval int) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(time.Duration(heartbeatInterval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.sendHeartbeat()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *MetricsService) sendHeartbeat() {
+	gauges, counters := s.collector.GetMetrics()
+
+	hb := &model.AgentHeartbeat{
+		AgentID:      s.identity.ID,
+		Hostname:     s.identity.Hostname,
+		IP:           s.identity.IP,
+		Version:      agentVersion,
+		MetricsCount: len(gauges) + len(counters),
+		LastReport:   time.Now(),
+	}
+
+	if err := s.sender.SendHeartbeat(s.ctx, s.serverAddress, hb); err != nil {
+		log.Printf("Error sending heartbeat: %v", err)
+	}
+}
+
 func (s *MetricsService) enqueueMetricsForSending() {
 	gauges, counters := s.collector.GetMetrics()
 
@@ -240,17 +326,19 @@ func (s *MetricsService) enqueueMetricsForSending() {
 
 	for name, value := range counters {
 		m := model.Metrics{
-			ID:    name,
-			MType: model.Counter,
-			Delta: &value,
+			ID:      name,
+			MType:   model.Counter,
+			Delta:   &value,
+			AgentID: s.identity.ID,
 		}
 		metrics = append(metrics, m)
 	}
 	for name, value := range gauges {
 		m := model.Metrics{
-			ID:    name,
-			MType: model.Gauge,
-			Value: &value,
+			ID:      name,
+			MType:   model.Gauge,
+			Value:   &value,
+			AgentID: s.identity.ID,
 		}
 		metrics = append(metrics, m)
 	}
@@ -259,7 +347,7 @@ func (s *MetricsService) enqueueMetricsForSending() {
 		// Send via gRPC (always batch)
 		metricsCopy := make([]model.Metrics, len(metrics))
 		copy(metricsCopy, metrics)
-		s.workerPool.EnqueueTask(func(ctx context.Context) error {
+		err := s.workerPool.Submit(s.ctx, func(ctx context.Context) error {
 			err := s.grpcClient.UpdateMetrics(ctx, metricsCopy, s.localIP)
 			if err != nil {
 				log.Printf("Error sending metrics via gRPC: %v. Falling back to HTTP.", err)
@@ -268,12 +356,57 @@ func (s *MetricsService) enqueueMetricsForSending() {
 			}
 			return nil
 		})
+		if err != nil {
+			log.Printf("Failed to enqueue metrics send task: %v", err)
+		}
 	} else if len(metrics) > 0 {
 		// Send via HTTP
 		metricsCopy := make([]model.Metrics, len(metrics))
 		copy(metricsCopy, metrics)
-		s.workerPool.EnqueueTask(func(ctx context.Context) error {
+		err := s.workerPool.Submit(s.ctx, func(ctx context.Context) error {
 			return s.sendMetricsHTTP(ctx, metricsCopy)
 		})
+		if err != nil {
+			log.Printf("Failed to enqueue metrics send task: %v", err)
+		}
+	}
+}
+
+// ApplyConfigChange applies a config.ConfigChange to the subset of agent
+// settings that can be adjusted without a restart: poll_interval,
+// report_interval, and rate_limit (the worker pool's target worker count).
+// It's meant to be driven by a config.Watcher subscription in the entry
+// point, consistent with how the gRPC server wires TrustedSubnetState - the
+// running cmd/agent/main.go doesn't instantiate MetricsService today, but
+// the reload path is kept current so that wiring is a drop-in when it does.
+// Fields it doesn't recognize are ignored.
+func (s *MetricsService) ApplyConfigChange(change config.ConfigChange) {
+	switch change.Field {
+	case "poll_interval":
+		s.reloadIntervalField(&s.pollIntervalSec, change)
+	case "report_interval":
+		s.reloadIntervalField(&s.reportIntervalSec, change)
+	case "rate_limit":
+		n, err := strconv.Atoi(change.New)
+		if err != nil {
+			log.Printf("Ignoring invalid reloaded rate_limit %q: %v", change.New, err)
+			return
+		}
+		s.workerPool.Resize(n)
+		log.Printf("Reloaded rate_limit: %q -> %q", change.Old, change.New)
+	}
+}
+
+// reloadIntervalField parses change.New as a duration string (e.g. "10s")
+// and stores it into target as whole seconds; a value under one second is
+// ignored.
+func (s *MetricsService) reloadIntervalField(target *atomic.Int64, change config.ConfigChange) {
+	d, err := time.ParseDuration(change.New)
+	n := int64(d / time.Second)
+	if err != nil || n <= 0 {
+		log.Printf("Ignoring invalid reloaded %s %q", change.Field, change.New)
+		return
 	}
+	target.Store(int64(n))
+	log.Printf("Reloaded %s: %q -> %q", change.Field, change.Old, change.New)
 }
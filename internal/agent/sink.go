@@ -0,0 +1,236 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/idudko/go-musthave-metrics/internal/model"
+	"github.com/idudko/go-musthave-metrics/pkg/hash"
+	"github.com/idudko/go-musthave-metrics/pkg/httpretry"
+)
+
+// httpSinkTimeout bounds a single metric POST, including any retries
+// httpretry.RoundTripper performs underneath it.
+const httpSinkTimeout = 30 * time.Second
+
+// Sink is an output backend for the agent's collected metrics. Collector.Report
+// sends the current gauges and counters to a Sink on every report tick.
+type Sink interface {
+	SendGauges(gauges map[string]float64) error
+	SendCounters(counters map[string]int64) error
+	Close() error
+}
+
+// httpSink reports each metric as an individual HTTP POST, matching the
+// server's /update/{type}/{name}/{value} endpoint.
+type httpSink struct {
+	serverAddress string
+	// signer signs each request via hash.Headers()'s matching header when
+	// configured with a key, matching HashValidationMiddleware's negotiation
+	// on the server. An operator can roll the key or switch Algorithm by
+	// restarting the agent with new flags, without touching the server.
+	signer hash.Signer
+	// authToken, when non-empty, is sent as an "Authorization: Bearer"
+	// header, matching security.BearerAuthMiddleware on the server.
+	authToken string
+	client    *http.Client
+}
+
+// NewHTTPSink creates a Sink that reports each metric as an individual HTTP
+// POST to the server's /update/{type}/{name}/{value} endpoint. signer and
+// authToken, when set, sign and authenticate every request respectively; an
+// empty signer key or authToken disables the corresponding check, as on the
+// server. backoff controls how failed requests are retried; a nil backoff
+// uses httpretry.DefaultBackoff.
+func NewHTTPSink(serverAddress string, signer hash.Signer, authToken string, backoff httpretry.RetryBackoff) Sink {
+	client := &http.Client{
+		Timeout:   httpSinkTimeout,
+		Transport: httpretry.NewRoundTripper(nil, backoff),
+	}
+	return &httpSink{serverAddress: serverAddress, signer: signer, authToken: authToken, client: client}
+}
+
+func (s *httpSink) SendGauges(gauges map[string]float64) error {
+	for name, value := range gauges {
+		url := fmt.Sprintf("%s/update/gauge/%s/%f", s.serverAddress, name, value)
+		if err := sendMetric(s.client, url, s.signer, s.authToken); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *httpSink) SendCounters(counters map[string]int64) error {
+	for name, value := range counters {
+		url := fmt.Sprintf("%s/update/counter/%s/%d", s.serverAddress, name, value)
+		if err := sendMetric(s.client, url, s.signer, s.authToken); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *httpSink) Close() error {
+	return nil
+}
+
+// senderSink adapts a *Sender to the Sink interface, round-tripping through
+// model.Metrics instead of httpSink's URL-encoded, body-less POSTs, so the
+// http transport can opt into Sender's hybrid RSA+AES-GCM encryption and TLS
+// certificate pinning - capabilities a body carries that a value-in-the-URL
+// request has no room for.
+type senderSink struct {
+	sender        *Sender
+	serverAddress string
+	useBatch      bool
+}
+
+// NewSenderSink creates a Sink that reports gauges/counters as a JSON body
+// POSTed through sender to serverAddress, batched into a single /updates
+// request when useBatch is set, or as individual /update requests otherwise.
+func NewSenderSink(serverAddress string, sender *Sender, useBatch bool) Sink {
+	return &senderSink{sender: sender, serverAddress: serverAddress, useBatch: useBatch}
+}
+
+func (s *senderSink) SendGauges(gauges map[string]float64) error {
+	metrics := make([]*model.Metrics, 0, len(gauges))
+	for name, value := range gauges {
+		v := value
+		metrics = append(metrics, &model.Metrics{ID: name, MType: model.Gauge, Value: &v})
+	}
+	return s.send(metrics)
+}
+
+func (s *senderSink) SendCounters(counters map[string]int64) error {
+	metrics := make([]*model.Metrics, 0, len(counters))
+	for name, value := range counters {
+		v := value
+		metrics = append(metrics, &model.Metrics{ID: name, MType: model.Counter, Delta: &v})
+	}
+	return s.send(metrics)
+}
+
+func (s *senderSink) send(metrics []*model.Metrics) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	if s.useBatch {
+		return s.sender.SendMetricsBatch(ctx, s.serverAddress, metrics)
+	}
+	for _, m := range metrics {
+		if err := s.sender.SendMetricJSON(ctx, s.serverAddress, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *senderSink) Close() error { return nil }
+
+// defaultStatsDMaxPayload is the default max UDP datagram payload size, chosen
+// to fit within a standard 1500-byte Ethernet MTU after IP/UDP headers.
+const defaultStatsDMaxPayload = 1432
+
+// statsdSink reports metrics as StatsD/DogStatsD line-protocol datagrams:
+// "name:value|g" for gauges, "name:value|c" for counters. Lines are batched
+// with newline separators into UDP datagrams no larger than maxPayload bytes,
+// flushing a datagram whenever the next line would overflow it.
+type statsdSink struct {
+	conn       net.Conn
+	maxPayload int
+}
+
+// NewStatsDSink creates a Sink that reports metrics as StatsD/DogStatsD
+// line-protocol UDP datagrams to addr (host:port). Dialing UDP does not
+// itself perform a handshake, so this only fails on malformed addresses.
+func NewStatsDSink(addr string) (Sink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial statsd address %q: %w", addr, err)
+	}
+	return &statsdSink{conn: conn, maxPayload: defaultStatsDMaxPayload}, nil
+}
+
+func (s *statsdSink) SendGauges(gauges map[string]float64) error {
+	lines := make([]string, 0, len(gauges))
+	for name, value := range gauges {
+		lines = append(lines, fmt.Sprintf("%s:%g|g", name, value))
+	}
+	return s.flush(lines)
+}
+
+func (s *statsdSink) SendCounters(counters map[string]int64) error {
+	lines := make([]string, 0, len(counters))
+	for name, value := range counters {
+		lines = append(lines, fmt.Sprintf("%s:%d|c", name, value))
+	}
+	return s.flush(lines)
+}
+
+// flush batches lines into as few UDP datagrams as fit within maxPayload,
+// separating lines within a datagram with newlines per the StatsD protocol.
+func (s *statsdSink) flush(lines []string) error {
+	var batch strings.Builder
+
+	send := func() error {
+		if batch.Len() == 0 {
+			return nil
+		}
+		_, err := s.conn.Write([]byte(batch.String()))
+		batch.Reset()
+		return err
+	}
+
+	for _, line := range lines {
+		// +1 accounts for the newline separator before this line.
+		if batch.Len() > 0 && batch.Len()+1+len(line) > s.maxPayload {
+			if err := send(); err != nil {
+				return err
+			}
+		}
+		if batch.Len() > 0 {
+			batch.WriteByte('\n')
+		}
+		batch.WriteString(line)
+	}
+
+	return send()
+}
+
+func (s *statsdSink) Close() error {
+	return s.conn.Close()
+}
+
+// sendMetric POSTs an empty-bodied request to url via client, signing it with
+// signer and authenticating it with authToken when they are set.
+func sendMetric(client *http.Client, url string, signer hash.Signer, authToken string) error {
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain")
+
+	if header, value := signer.Sign(nil); header != "" {
+		req.Header.Set(header, value)
+	}
+	if authToken != "" {
+		req.Header.Set("Authorization", "Bearer "+authToken)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -1,57 +1,433 @@
 package agent
 
 import (
+	"container/heap"
 	"context"
+	"errors"
+	"expvar"
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/idudko/go-musthave-metrics/internal/model"
 )
 
 type Task func(ctx context.Context) error
 
+// ErrWorkerPoolStopped is returned by Submit once the pool has started
+// shutting down, whether via Stop or the context passed to Start being
+// canceled.
+var ErrWorkerPoolStopped = errors.New("agent: worker pool is stopped")
+
+// ErrQueueFull is returned by Submit when the queue is at MaxQueueDepth and
+// the call's DropPolicy is DropPolicyReject.
+var ErrQueueFull = errors.New("agent: worker pool queue is full")
+
+// DropPolicy controls what Submit does when the queue is already at
+// MaxQueueDepth.
+type DropPolicy int
+
+const (
+	// DropPolicyBlock waits for room, honoring the ctx passed to Submit.
+	// This is the default, matching EnqueueTask's old blocking-channel-send
+	// behavior.
+	DropPolicyBlock DropPolicy = iota
+	// DropPolicyDropOldest evicts the queue's lowest-priority (or, among
+	// equal priorities, longest-waiting) task to make room for the new one.
+	DropPolicyDropOldest
+	// DropPolicyDropNewest silently discards the incoming task, leaving the
+	// queue untouched.
+	DropPolicyDropNewest
+	// DropPolicyReject returns ErrQueueFull immediately instead of blocking
+	// or dropping anything.
+	DropPolicyReject
+)
+
+// SubmitOption customizes one Submit call's priority and backpressure
+// behavior.
+type SubmitOption func(*submitOptions)
+
+type submitOptions struct {
+	priority   int
+	dropPolicy DropPolicy
+}
+
+// WithPriority sets the task's priority; a task with a higher priority is
+// dequeued before one with a lower priority, regardless of submit order.
+// Tasks at the same priority run FIFO. Default 0.
+func WithPriority(priority int) SubmitOption {
+	return func(o *submitOptions) { o.priority = priority }
+}
+
+// WithDropPolicy sets what Submit does once the queue is at
+// MaxQueueDepth. Default DropPolicyBlock.
+func WithDropPolicy(policy DropPolicy) SubmitOption {
+	return func(o *submitOptions) { o.dropPolicy = policy }
+}
+
+// queuedTask is one pending item in WorkerPool's priority queue.
+type queuedTask struct {
+	task     Task
+	priority int
+	seq      uint64 // breaks priority ties FIFO
+}
+
+// taskHeap orders queuedTasks by descending priority, then ascending seq -
+// container/heap pops the highest-priority, longest-waiting task first.
+type taskHeap []*queuedTask
+
+func (h taskHeap) Len() int { return len(h) }
+func (h taskHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h taskHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *taskHeap) Push(x any)   { *h = append(*h, x.(*queuedTask)) }
+func (h *taskHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// worstIndex returns the index of the lowest-priority, longest-waiting task
+// in the heap - the one DropPolicyDropOldest evicts. This is a linear scan
+// since the heap only orders around its root; eviction is rare enough
+// (only once the queue is already full) that this is cheaper to reason
+// about than maintaining a second index.
+func (h taskHeap) worstIndex() int {
+	worst := 0
+	for i := 1; i < len(h); i++ {
+		if h.worseThan(i, worst) {
+			worst = i
+		}
+	}
+	return worst
+}
+
+// worseThan reports whether the task at i is a better eviction candidate
+// than the task at j: lower priority wins, and ties go to the smaller seq
+// (the task that's been waiting the longest).
+func (h taskHeap) worseThan(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority < h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+// taskDurationBuckets are the bucket upper bounds (seconds) task_duration_seconds
+// is tracked across, mirroring repository.Histogram's Prometheus-style
+// default boundaries.
+var taskDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// durationHistogram is WorkerPool's own minimal cumulative-bucket
+// histogram for task_duration_seconds; it intentionally doesn't reuse
+// repository.Histogram, since the agent package has no other reason to
+// depend on the server-side repository package.
+type durationHistogram struct {
+	mu     sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newDurationHistogram() *durationHistogram {
+	return &durationHistogram{counts: make([]uint64, len(taskDurationBuckets))}
+}
+
+func (h *durationHistogram) observe(seconds float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, le := range taskDurationBuckets {
+		if seconds <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+func (h *durationHistogram) snapshot() model.HistogramValue {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	buckets := make(map[float64]uint64, len(taskDurationBuckets))
+	for i, le := range taskDurationBuckets {
+		buckets[le] = h.counts[i]
+	}
+	return model.HistogramValue{Buckets: buckets, Count: h.count, Sum: h.sum}
+}
+
+// WorkerPoolStats is a point-in-time snapshot of a WorkerPool's counters,
+// published via expvar under the pool's name (see NewWorkerPool).
+type WorkerPoolStats struct {
+	EnqueuedTotal int64
+	DroppedOldest int64
+	DroppedNewest int64
+	Rejected      int64
+	Inflight      int64
+	QueueDepth    int64
+	TaskDuration  model.HistogramValue
+}
+
+// WorkerPool runs submitted Tasks across a resizable set of goroutines,
+// dequeuing the highest-priority task first from a bounded heap-based
+// queue. Unlike a plain buffered channel, a full queue can reject, drop,
+// or evict work instead of only blocking the submitter - see DropPolicy.
 type WorkerPool struct {
-	workerCount int
-	tasks       chan Task
-	wg          sync.WaitGroup
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	queue         taskHeap
+	nextSeq       uint64
+	maxQueueDepth int
+
+	workerCount int // target worker count; Resize changes this
+	spawned     int // goroutines currently running
+	shutdown    bool
+	runCtx      context.Context // set by Start; Resize spawns new workers with it
+
+	wg sync.WaitGroup
+
+	enqueuedTotal atomic.Int64
+	droppedOldest atomic.Int64
+	droppedNewest atomic.Int64
+	rejected      atomic.Int64
+	inflight      atomic.Int64
+	queueDepth    atomic.Int64
+	taskDuration  *durationHistogram
 }
 
-func NewWorkerPool(workerCount int) *WorkerPool {
-	return &WorkerPool{
-		workerCount: workerCount,
-		tasks:       make(chan Task, 100),
+// NewWorkerPool creates a WorkerPool with workerCount initial workers and a
+// queue bounded at maxQueueDepth (0 means unbounded). If name is non-empty,
+// the pool's WorkerPoolStats are additionally published under that key via
+// expvar for operators to scrape; name must be unique per process, and a
+// duplicate name is silently skipped rather than panicking.
+func NewWorkerPool(name string, workerCount, maxQueueDepth int) *WorkerPool {
+	p := &WorkerPool{
+		workerCount:   workerCount,
+		maxQueueDepth: maxQueueDepth,
+		taskDuration:  newDurationHistogram(),
+	}
+	p.cond = sync.NewCond(&p.mu)
+
+	if name != "" && expvar.Get(name) == nil {
+		expvar.Publish(name, expvar.Func(func() interface{} { return p.Stats() }))
 	}
+	return p
 }
 
+// Start launches the pool's initial worker goroutines. Workers exit once
+// ctx is canceled and the queue has drained, or once Stop is called.
 func (p *WorkerPool) Start(ctx context.Context) {
-	for i := 0; i < p.workerCount; i++ {
+	p.mu.Lock()
+	p.runCtx = ctx
+	p.spawned = p.workerCount
+	n := p.spawned
+	p.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		p.shutdown = true
+		p.mu.Unlock()
+		p.cond.Broadcast()
+	}()
+
+	for range n {
 		p.wg.Add(1)
 		go p.worker(ctx)
 	}
 }
 
+// Stop signals every worker to exit once the queue has drained, and waits
+// for them to do so.
 func (p *WorkerPool) Stop() {
-	close(p.tasks)
+	p.mu.Lock()
+	p.shutdown = true
+	p.mu.Unlock()
+	p.cond.Broadcast()
 	p.wg.Wait()
 }
 
-func (p *WorkerPool) EnqueueTask(task Task) {
-	p.tasks <- task
+// Resize changes the pool's target worker count. Growing spawns the
+// additional workers immediately; shrinking lets the excess workers exit
+// on their own once they finish their current task, so no in-flight task
+// is interrupted.
+func (p *WorkerPool) Resize(n int) {
+	if n < 1 {
+		n = 1
+	}
+
+	p.mu.Lock()
+	delta := n - p.workerCount
+	p.workerCount = n
+	if delta > 0 {
+		p.spawned += delta
+	}
+	ctx := p.runCtx
+	p.mu.Unlock()
+
+	if delta > 0 && ctx != nil {
+		for range delta {
+			p.wg.Add(1)
+			go p.worker(ctx)
+		}
+	} else if delta < 0 {
+		// Wake idle workers so they notice the lower target and exit.
+		p.cond.Broadcast()
+	}
+}
+
+// Submit enqueues task, applying opts' priority and drop policy. It
+// returns ErrWorkerPoolStopped once the pool has started shutting down,
+// or ErrQueueFull if the queue is full and DropPolicyReject applies. With
+// the default DropPolicyBlock, Submit blocks until there's room, honoring
+// ctx's cancellation.
+func (p *WorkerPool) Submit(ctx context.Context, task Task, opts ...SubmitOption) error {
+	options := submitOptions{dropPolicy: DropPolicyBlock}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for p.maxQueueDepth > 0 && p.queue.Len() >= p.maxQueueDepth {
+		if p.shutdown {
+			return ErrWorkerPoolStopped
+		}
+
+		switch options.dropPolicy {
+		case DropPolicyDropNewest:
+			p.droppedNewest.Add(1)
+			return nil
+		case DropPolicyDropOldest:
+			heap.Remove(&p.queue, p.queue.worstIndex())
+			p.droppedOldest.Add(1)
+		case DropPolicyReject:
+			p.rejected.Add(1)
+			return ErrQueueFull
+		default: // DropPolicyBlock
+			waitErr := p.waitForRoom(ctx)
+			if waitErr != nil {
+				return waitErr
+			}
+		}
+	}
+
+	if p.shutdown {
+		return ErrWorkerPoolStopped
+	}
+
+	item := &queuedTask{task: task, priority: options.priority, seq: p.nextSeq}
+	p.nextSeq++
+	heap.Push(&p.queue, item)
+	p.queueDepth.Store(int64(p.queue.Len()))
+	p.enqueuedTotal.Add(1)
+	p.cond.Signal()
+	return nil
+}
+
+// waitForRoom blocks on p.cond until the queue has room, the pool shuts
+// down, or ctx is canceled. p.mu must be held on entry and is held again
+// on return.
+func (p *WorkerPool) waitForRoom(ctx context.Context) error {
+	if ctx.Done() == nil {
+		p.cond.Wait()
+		return nil
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.mu.Lock()
+			p.cond.Broadcast()
+			p.mu.Unlock()
+		case <-stopped:
+		}
+		close(done)
+	}()
+
+	p.cond.Wait()
+
+	close(stopped)
+	<-done
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return nil
 }
 
 func (p *WorkerPool) worker(ctx context.Context) {
 	defer p.wg.Done()
 
 	for {
-		select {
-		case task, ok := <-p.tasks:
-			if !ok {
-				return
-			}
+		task, ok := p.dequeue()
+		if !ok {
+			return
+		}
 
-			if err := task(ctx); err != nil {
-				log.Printf("Error executing task: %v", err)
-			}
-		case <-ctx.Done():
+		p.inflight.Add(1)
+		start := time.Now()
+		if err := task(ctx); err != nil {
+			log.Printf("Error executing task: %v", err)
+		}
+		p.taskDuration.observe(time.Since(start).Seconds())
+		p.inflight.Add(-1)
+
+		if p.exitOnShrink() {
 			return
 		}
 	}
 }
+
+// dequeue blocks until a task is available or the pool is shutting down
+// with an empty queue, in which case it returns ok=false.
+func (p *WorkerPool) dequeue() (Task, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for p.queue.Len() == 0 {
+		if p.shutdown {
+			return nil, false
+		}
+		p.cond.Wait()
+	}
+
+	item := heap.Pop(&p.queue).(*queuedTask)
+	p.queueDepth.Store(int64(p.queue.Len()))
+	p.cond.Broadcast() // wake any Submit blocked on a full queue
+	return item.task, true
+}
+
+// exitOnShrink returns true, and decrements p.spawned, if Resize has
+// lowered the target worker count below how many are currently running.
+func (p *WorkerPool) exitOnShrink() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.spawned > p.workerCount {
+		p.spawned--
+		return true
+	}
+	return false
+}
+
+// Stats returns a snapshot of the pool's counters.
+func (p *WorkerPool) Stats() WorkerPoolStats {
+	return WorkerPoolStats{
+		EnqueuedTotal: p.enqueuedTotal.Load(),
+		DroppedOldest: p.droppedOldest.Load(),
+		DroppedNewest: p.droppedNewest.Load(),
+		Rejected:      p.rejected.Load(),
+		Inflight:      p.inflight.Load(),
+		QueueDepth:    p.queueDepth.Load(),
+		TaskDuration:  p.taskDuration.snapshot(),
+	}
+}
@@ -5,41 +5,85 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"net"
 	"net/http"
 	"os"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
+
+	"github.com/idudko/go-musthave-metrics/pkg/hash"
 )
 
 type AuditEvent struct {
 	Timestamp int64    `json:"ts"`
 	Metrics   []string `json:"metrics"`
 	IPAddress string   `json:"ip_address"`
+	// Nonce is a random per-event value that keeps two otherwise-identical
+	// events (same timestamp, metrics, IP) from producing the same chain
+	// hash, so a replayed copy of an old entry is still detectable. Set by
+	// chainSigner; empty when the observer isn't signing.
+	Nonce string `json:"nonce,omitempty"`
+	// PrevHash is the previous event's chain hash, forming the tamper-
+	// evident hash chain chainSigner signs and Verifier replays. Empty for
+	// the first event a sink signs, and always empty when the observer
+	// isn't signing.
+	PrevHash string `json:"prev_hash,omitempty"`
 }
 
 type Observer interface {
 	Notify(event AuditEvent)
 }
 
+// Closer is an optional interface Observer implementations can satisfy when
+// they hold an external connection (a Kafka producer, an MQTT client) that
+// needs to flush pending messages and disconnect on shutdown. Subject.Close
+// calls Close on every attached observer that implements it.
+type Closer interface {
+	Close() error
+}
+
 type FileObserver struct {
 	filePath string
 	mu       sync.Mutex
+	signer   *chainSigner
 }
 
 func NewFileObserver(filePath string) *FileObserver {
 	return &FileObserver{
 		filePath: filePath,
+		signer:   newChainSigner("", nil),
 	}
 }
 
+// NewSignedFileObserver creates a FileObserver that additionally HMAC-signs
+// a tamper-evident hash chain into every event it appends, under key and
+// algo (SHA256 if algo is nil). The chain hash and signature travel as
+// extra "chain_hash"/"signature" JSON fields alongside the event; Verifier
+// replays the resulting file to detect dropped or modified entries.
+func NewSignedFileObserver(filePath, key string, algo hash.Algorithm) *FileObserver {
+	return &FileObserver{
+		filePath: filePath,
+		signer:   newChainSigner(key, algo),
+	}
+}
+
+// signedEntry is the shape FileObserver appends to disk: the event itself
+// plus the chain hash and HMAC signature that cover it, when signing is
+// enabled. Both are omitted for an unsigned FileObserver.
+type signedEntry struct {
+	AuditEvent
+	ChainHash string `json:"chain_hash,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
 func (o *FileObserver) Notify(event AuditEvent) {
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
+	chainHash, signature := o.signer.sign(&event)
+
 	file, err := os.OpenFile(o.filePath, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
 	if err != nil {
 		log.Printf("Failed to open audit file: %v", err)
@@ -47,7 +91,7 @@ func (o *FileObserver) Notify(event AuditEvent) {
 	}
 	defer file.Close()
 
-	data, err := json.Marshal(event)
+	data, err := json.Marshal(signedEntry{AuditEvent: event, ChainHash: chainHash, Signature: signature})
 	if err != nil {
 		log.Printf("Failed to marshal audit event: %v", err)
 		return
@@ -61,6 +105,8 @@ func (o *FileObserver) Notify(event AuditEvent) {
 type HTTPObserver struct {
 	client *retryablehttp.Client
 	url    string
+	mu     sync.Mutex
+	signer *chainSigner
 }
 
 func NewHTTPObserver(url string) *HTTPObserver {
@@ -74,10 +120,34 @@ func NewHTTPObserver(url string) *HTTPObserver {
 	return &HTTPObserver{
 		client: retryClient,
 		url:    url,
+		signer: newChainSigner("", nil),
 	}
 }
 
+// NewSignedHTTPObserver creates an HTTPObserver that additionally HMAC-signs
+// a tamper-evident hash chain into every event it posts, under key and algo
+// (SHA256 if algo is nil). The chain hash and signature travel in the
+// X-Audit-Chain and X-Audit-Signature request headers; Verifier replays a
+// FileObserver's log signed with the same key to detect dropped or
+// modified entries.
+func NewSignedHTTPObserver(url, key string, algo hash.Algorithm) *HTTPObserver {
+	o := NewHTTPObserver(url)
+	o.signer = newChainSigner(key, algo)
+	return o
+}
+
+// Notify signs event and POSTs it, holding o.mu for the whole sign-then-send
+// sequence. Subject dispatches to the same attached observer from a pool of
+// concurrent workers, so without this lock two goroutines could be assigned
+// chain links in one order but deliver them to the receiver in the other
+// under ordinary network jitter - producing spurious chain breaks on an
+// otherwise healthy delivery.
 func (o *HTTPObserver) Notify(event AuditEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	chainHash, signature := o.signer.sign(&event)
+
 	data, err := json.Marshal(event)
 	if err != nil {
 		log.Printf("Failed to marshal audit event: %v", err)
@@ -90,6 +160,10 @@ func (o *HTTPObserver) Notify(event AuditEvent) {
 		return
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if chainHash != "" {
+		req.Header.Set("X-Audit-Chain", chainHash)
+		req.Header.Set("X-Audit-Signature", signature)
+	}
 
 	resp, err := o.client.Do(req)
 	if err != nil {
@@ -103,62 +177,364 @@ func (o *HTTPObserver) Notify(event AuditEvent) {
 	}
 }
 
+// SubjectConfig configures Subject's async delivery pipeline: how many
+// worker goroutines fan events out to observers, how deep the in-memory
+// buffer is before NotifyAll has to fall back to the durable queue (or the
+// DropOldest/backpressure policy when there is none), and the on-disk
+// spill queue itself.
+type SubjectConfig struct {
+	// Workers is the number of goroutines fanning events out to observers.
+	Workers int
+	// QueueCapacity is the in-memory channel's buffer size.
+	QueueCapacity int
+	// SpillDir, if set, enables a durable BoltDB-backed spill queue in
+	// this directory: events that don't fit in the in-memory channel are
+	// persisted here instead of dropped, and replayed in order on the
+	// next NewSubjectWithConfig call before live traffic resumes.
+	SpillDir string
+	// MaxQueueSize caps how many events the spill queue may hold; 0 means
+	// unbounded. Once full, Push fails and the event is dropped.
+	MaxQueueSize int64
+	// SpillDrainInterval controls how often a background goroutine drains
+	// the spill queue and re-enqueues its events onto the in-memory
+	// channel, so events that overflowed while a worker was struggling
+	// still get delivered once it recovers instead of waiting for the
+	// next restart. 0 defaults to 5 seconds; only meaningful when SpillDir
+	// is set.
+	SpillDrainInterval time.Duration
+	// DropOldest selects the overflow policy when the in-memory channel
+	// is full and no spill queue is configured: true drops the oldest
+	// queued event to make room for the new one, false applies
+	// backpressure by blocking NotifyAll until a worker frees up space.
+	DropOldest bool
+}
+
+// DefaultSubjectConfig returns the configuration NewSubject uses: four
+// workers, a 256-event in-memory buffer, no durable spill queue, and
+// backpressure (rather than drop-oldest) when that buffer fills up.
+func DefaultSubjectConfig() SubjectConfig {
+	return SubjectConfig{
+		Workers:       4,
+		QueueCapacity: 256,
+		MaxQueueSize:  100_000,
+	}
+}
+
+// SubjectMetrics reports Subject's pipeline counters, for wiring into
+// expvar/Prometheus by callers that want fleet-wide audit-pipeline health.
+type SubjectMetrics struct {
+	// Enqueued counts events accepted into the in-memory channel.
+	Enqueued int64
+	// Dropped counts events lost outright: DropOldest evictions, or spill
+	// queue pushes that failed (e.g. because MaxQueueSize was reached).
+	Dropped int64
+	// Retried counts events that spilled to the durable queue because the
+	// in-memory channel was full, including those replayed on startup.
+	Retried int64
+}
+
+// AttachOption customizes how Subject routes events to one attached
+// Observer, e.g. restricting a webhook or syslog observer to a subset of
+// events while a FileObserver keeps receiving everything.
+type AttachOption func(*observerBinding)
+
+// WithEventFilter attaches observer so deliver only calls its Notify for
+// events where filter returns true, letting a single Subject fan out
+// different event subsets to different observers (e.g. a FileObserver
+// that logs everything alongside a WebhookObserver that only cares about
+// a specific metric).
+func WithEventFilter(filter func(AuditEvent) bool) AttachOption {
+	return func(b *observerBinding) { b.filter = filter }
+}
+
+// observerBinding pairs an attached Observer with the optional filter an
+// AttachOption gave it; a nil filter means "every event".
+type observerBinding struct {
+	observer Observer
+	filter   func(AuditEvent) bool
+}
+
+func (b observerBinding) accepts(event AuditEvent) bool {
+	return b.filter == nil || b.filter(event)
+}
+
+// Subject is an Observer fan-out pipeline: NotifyAll enqueues events onto a
+// bounded in-memory channel, a pool of workers drain it and call every
+// attached Observer, and - when configured with a SpillDir - a durable
+// on-disk queue absorbs events the workers can't keep up with (e.g. a
+// struggling HTTPObserver) instead of losing them. On startup, any events
+// left in the spill queue from a previous run are drained and redelivered
+// before NewSubjectWithConfig returns, so they land before any new
+// traffic; a background loop repeats that drain every
+// cfg.SpillDrainInterval so events spilled during a later slowdown are
+// redelivered once the workers catch up, instead of sitting until the
+// next restart.
 type Subject struct {
-	observers []Observer
+	observers []observerBinding
 	mu        sync.RWMutex
+
+	cfg    SubjectConfig
+	events chan AuditEvent
+	queue  *spillQueue
+	wg     sync.WaitGroup
+
+	done    chan struct{}
+	spillWG sync.WaitGroup
+
+	enqueued atomic.Int64
+	dropped  atomic.Int64
+	retried  atomic.Int64
 }
 
+// NewSubject creates a Subject using DefaultSubjectConfig: an in-memory-only
+// pipeline with no durable spill queue.
 func NewSubject() *Subject {
-	return &Subject{
-		observers: make([]Observer, 0),
+	// DefaultSubjectConfig has no SpillDir, so opening the spill queue -
+	// the only fallible step - never runs; the error is always nil.
+	subject, _ := NewSubjectWithConfig(DefaultSubjectConfig())
+	return subject
+}
+
+// NewSubjectWithConfig creates a Subject per cfg, opening its durable spill
+// queue (if cfg.SpillDir is set) and draining any events left over from a
+// previous run before returning.
+func NewSubjectWithConfig(cfg SubjectConfig) (*Subject, error) {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+	if cfg.QueueCapacity <= 0 {
+		cfg.QueueCapacity = 1
+	}
+
+	s := &Subject{
+		observers: make([]observerBinding, 0),
+		cfg:       cfg,
+		events:    make(chan AuditEvent, cfg.QueueCapacity),
+		done:      make(chan struct{}),
+	}
+
+	if cfg.SpillDir != "" {
+		queue, err := newSpillQueue(cfg.SpillDir, cfg.MaxQueueSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit spill queue: %w", err)
+		}
+		s.queue = queue
+	}
+
+	s.startWorkers()
+
+	if s.queue != nil {
+		s.drainSpill()
+		s.startSpillLoop()
+	}
+
+	return s, nil
+}
+
+// drainSpill pulls every event currently in the durable spill queue and
+// re-enqueues it onto the in-memory channel for delivery, counting each as
+// retried. It's called once at startup (so events left over from a
+// previous run land before new traffic) and periodically by spillLoop (so
+// events that overflowed while workers were struggling get delivered once
+// they recover, instead of sitting until the next restart).
+func (s *Subject) drainSpill() {
+	drained, err := s.queue.Drain()
+	if err != nil {
+		log.Printf("Failed to drain audit spill queue: %v", err)
+		return
+	}
+	for _, event := range drained {
+		select {
+		case s.events <- event:
+			s.retried.Add(1)
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// startSpillLoop launches the background goroutine that periodically
+// drains the spill queue at cfg.SpillDrainInterval (default 5s) until
+// Close stops it.
+func (s *Subject) startSpillLoop() {
+	interval := s.cfg.SpillDrainInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	s.spillWG.Add(1)
+	go func() {
+		defer s.spillWG.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				s.drainSpill()
+			}
+		}
+	}()
+}
+
+func (s *Subject) startWorkers() {
+	for range s.cfg.Workers {
+		s.wg.Add(1)
+		go s.worker()
+	}
+}
+
+func (s *Subject) worker() {
+	defer s.wg.Done()
+	for event := range s.events {
+		s.deliver(event)
 	}
 }
 
-func (s *Subject) Attach(observer Observer) {
+func (s *Subject) deliver(event AuditEvent) {
+	s.mu.RLock()
+	bindings := make([]observerBinding, len(s.observers))
+	copy(bindings, s.observers)
+	s.mu.RUnlock()
+
+	for _, binding := range bindings {
+		if binding.accepts(event) {
+			binding.observer.Notify(event)
+		}
+	}
+}
+
+// Attach adds observer to the fan-out pipeline. By default every delivered
+// event reaches it; pass WithEventFilter to restrict it to a subset, so a
+// single Subject can route, say, everything to a FileObserver while a
+// WebhookObserver or SyslogObserver only sees the events it cares about.
+func (s *Subject) Attach(observer Observer, opts ...AttachOption) {
+	binding := observerBinding{observer: observer}
+	for _, opt := range opts {
+		opt(&binding)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.observers = append(s.observers, observer)
+	s.observers = append(s.observers, binding)
 }
 
 func (s *Subject) Detach(observer Observer) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	for i, obs := range s.observers {
-		if obs == observer {
+	for i, binding := range s.observers {
+		if binding.observer == observer {
 			s.observers = append(s.observers[:i], s.observers[i+1:]...)
 			break
 		}
 	}
 }
 
+// NotifyAll enqueues event for async delivery to every attached Observer.
+// It only blocks the caller when the in-memory channel is full, there's no
+// durable spill queue, and cfg.DropOldest is false (backpressure); in every
+// other overflow case it returns immediately.
 func (s *Subject) NotifyAll(event AuditEvent) {
+	select {
+	case s.events <- event:
+		s.enqueued.Add(1)
+		return
+	default:
+	}
+
+	if s.queue != nil {
+		if err := s.queue.Push(event); err != nil {
+			log.Printf("Failed to spill audit event to durable queue: %v", err)
+			s.dropped.Add(1)
+			return
+		}
+		s.retried.Add(1)
+		return
+	}
+
+	if s.cfg.DropOldest {
+		select {
+		case <-s.events:
+			s.dropped.Add(1)
+		default:
+		}
+		select {
+		case s.events <- event:
+			s.enqueued.Add(1)
+		default:
+			s.dropped.Add(1)
+		}
+		return
+	}
+
+	s.events <- event
+	s.enqueued.Add(1)
+}
+
+// Metrics reports the pipeline's enqueued/dropped/retried counters.
+func (s *Subject) Metrics() SubjectMetrics {
+	return SubjectMetrics{
+		Enqueued: s.enqueued.Load(),
+		Dropped:  s.dropped.Load(),
+		Retried:  s.retried.Load(),
+	}
+}
+
+// Close stops accepting new events - callers must not call NotifyAll after
+// Close - stops the spill-drain loop, waits for the worker pool to drain
+// the in-memory channel, closes every attached observer that implements
+// Closer, and closes the durable spill queue, if any. It returns the
+// first error encountered, but still attempts every close.
+func (s *Subject) Close() error {
+	close(s.done)
+	s.spillWG.Wait()
+
+	close(s.events)
+	s.wg.Wait()
+
+	var firstErr error
+
 	s.mu.RLock()
-	observers := make([]Observer, len(s.observers))
-	copy(observers, s.observers)
+	bindings := make([]observerBinding, len(s.observers))
+	copy(bindings, s.observers)
 	s.mu.RUnlock()
 
-	for _, observer := range observers {
-		observer.Notify(event)
+	for _, binding := range bindings {
+		closer, ok := binding.observer.(Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
 	}
+
+	if s.queue != nil {
+		if err := s.queue.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
 }
 
+// GetClientIP resolves the client IP for an audit event by checking
+// DefaultHeaderPriority in order: the leftmost (original client) hop of
+// X-Forwarded-For, then X-Real-IP, then r.RemoteAddr. Callers that need a
+// configurable header order or reverse-proxy-aware trust boundary (e.g.
+// middleware.TrustedSubnetMiddleware) should use ForwardedForHops,
+// ForwardedHeaderHops, and RemoteAddrIP directly instead.
 func GetClientIP(r *http.Request) string {
-	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-		ips := strings.Split(forwarded, ",")
-		if len(ips) > 0 {
-			return strings.TrimSpace(ips[0])
-		}
+	if hops := ForwardedForHops(r.Header.Get("X-Forwarded-For")); len(hops) > 0 {
+		return hops[0]
 	}
 
 	if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
 		return realIP
 	}
 
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return r.RemoteAddr
-	}
-	return ip
+	return RemoteAddrIP(r.RemoteAddr)
 }
 
 func CreateAuditEvent(r *http.Request, metrics []string) AuditEvent {
@@ -138,6 +138,52 @@ func TestSubjectConcurrency(t *testing.T) {
 	wg.Wait()
 }
 
+func TestSubject_AttachWithEventFilter(t *testing.T) {
+	subject := NewSubject()
+	defer subject.Close()
+
+	fileObserved := make(chan AuditEvent, 2)
+	filtered := make(chan AuditEvent, 2)
+
+	subject.Attach(observerFunc(func(e AuditEvent) { fileObserved <- e }))
+	subject.Attach(observerFunc(func(e AuditEvent) { filtered <- e }),
+		WithEventFilter(func(e AuditEvent) bool { return e.IPAddress == "10.0.0.1" }))
+
+	subject.NotifyAll(AuditEvent{IPAddress: "10.0.0.1"})
+	subject.NotifyAll(AuditEvent{IPAddress: "10.0.0.2"})
+
+	deadline := time.After(time.Second)
+	got := 0
+	for got < 2 {
+		select {
+		case <-fileObserved:
+			got++
+		case <-deadline:
+			t.Fatalf("unfiltered observer only saw %d/2 events before timeout", got)
+		}
+	}
+
+	select {
+	case e := <-filtered:
+		if e.IPAddress != "10.0.0.1" {
+			t.Errorf("filtered observer saw IPAddress %q, want 10.0.0.1", e.IPAddress)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("filtered observer saw no events before timeout")
+	}
+
+	select {
+	case e := <-filtered:
+		t.Errorf("filtered observer unexpectedly saw a second event: %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// observerFunc adapts a plain function to the Observer interface for tests.
+type observerFunc func(AuditEvent)
+
+func (f observerFunc) Notify(event AuditEvent) { f(event) }
+
 func TestGetClientIP(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -0,0 +1,89 @@
+package audit
+
+import (
+	"net"
+	"strings"
+)
+
+// Header names for the client-IP sources GetClientIP and callers like
+// middleware.TrustedSubnetMiddleware can check, in HeaderPriority order.
+const (
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderForwarded     = "Forwarded"
+	HeaderXRealIP       = "X-Real-IP"
+	HeaderRemoteAddr    = "RemoteAddr"
+)
+
+// DefaultHeaderPriority is the header order GetClientIP has always checked:
+// X-Forwarded-For, then X-Real-IP, then RemoteAddr.
+var DefaultHeaderPriority = []string{HeaderXForwardedFor, HeaderXRealIP, HeaderRemoteAddr}
+
+// ForwardedForHops splits an X-Forwarded-For header value into its
+// comma-separated hops, trimmed of surrounding whitespace, in the order
+// they were added: left (original client) to right (nearest proxy). It
+// returns nil for an empty header.
+func ForwardedForHops(header string) []string {
+	if header == "" {
+		return nil
+	}
+
+	parts := strings.Split(header, ",")
+	hops := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if ip := strings.TrimSpace(part); ip != "" {
+			hops = append(hops, ip)
+		}
+	}
+	return hops
+}
+
+// ForwardedHeaderHops extracts the for= tokens from an RFC 7239 Forwarded
+// header, stripping quotes, bracketed IPv6 literals, and :port suffixes, in
+// the same left-to-right orientation as ForwardedForHops. It returns nil
+// for an empty header or one with no for= parameters.
+func ForwardedHeaderHops(header string) []string {
+	if header == "" {
+		return nil
+	}
+
+	var hops []string
+	for _, element := range strings.Split(header, ",") {
+		for _, pair := range strings.Split(element, ";") {
+			key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if !found || !strings.EqualFold(strings.TrimSpace(key), "for") {
+				continue
+			}
+			if ip := stripForwardedPort(strings.Trim(strings.TrimSpace(value), `"`)); ip != "" {
+				hops = append(hops, ip)
+			}
+			break
+		}
+	}
+	return hops
+}
+
+// stripForwardedPort strips a Forwarded for= token's :port suffix, and the
+// brackets around an IPv6 literal, returning the bare IP.
+func stripForwardedPort(value string) string {
+	if strings.HasPrefix(value, "[") {
+		if end := strings.Index(value, "]"); end != -1 {
+			return value[1:end]
+		}
+		return value
+	}
+
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		return host
+	}
+	return value
+}
+
+// RemoteAddrIP strips the port from remoteAddr, falling back to the raw
+// value if it isn't a valid host:port pair.
+func RemoteAddrIP(remoteAddr string) string {
+	ip, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return ip
+}
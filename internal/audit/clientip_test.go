@@ -0,0 +1,54 @@
+package audit
+
+import "testing"
+
+func TestForwardedForHops(t *testing.T) {
+	got := ForwardedForHops("203.0.113.1, 10.0.0.2,10.0.0.1")
+	want := []string{"203.0.113.1", "10.0.0.2", "10.0.0.1"}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("hop %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestForwardedHeaderHops(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []string
+	}{
+		{
+			name:   "simple",
+			header: `for=192.0.2.60;proto=http;by=203.0.113.43, for=198.51.100.17`,
+			want:   []string{"192.0.2.60", "198.51.100.17"},
+		},
+		{
+			name:   "quoted IPv6 with port",
+			header: `for="[2001:db8:cafe::17]:4711"`,
+			want:   []string{"2001:db8:cafe::17"},
+		},
+		{
+			name:   "empty",
+			header: "",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ForwardedHeaderHops(tt.header)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("hop %d: expected %q, got %q", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"net/http"
+	"time"
+)
+
+// AuditEventSchemaVersion is AuditEventV1's Version field. It should be
+// bumped whenever the schema changes in a way a decoder needs to know
+// about (a field is removed or repurposed); additive fields don't need a
+// bump, since a decoder can just ignore fields it doesn't recognize.
+const AuditEventSchemaVersion = 1
+
+// MutationDirection describes how a metric's value changed.
+type MutationDirection string
+
+const (
+	// MutationCreate marks a metric's first write: there is no previous
+	// value to compare against, so OldValue is left empty.
+	MutationCreate MutationDirection = "create"
+	// MutationSet marks a gauge overwrite: NewValue replaces OldValue.
+	MutationSet MutationDirection = "set"
+	// MutationIncrement marks a counter delta: NewValue is OldValue plus
+	// the applied delta.
+	MutationIncrement MutationDirection = "increment"
+)
+
+// MetricMutation is one metric write inside an AuditEventV1: the metric's
+// name, which direction it moved, and its value before and after the
+// write, formatted as strings so the same shape covers both gauge floats
+// and counter deltas.
+type MetricMutation struct {
+	Name      string            `json:"name"`
+	Direction MutationDirection `json:"direction"`
+	OldValue  string            `json:"old_value,omitempty"`
+	NewValue  string            `json:"new_value"`
+}
+
+// AuditEventV1 is the self-describing, versioned audit event Sink
+// implementations encode. Unlike AuditEvent (metric names only, built for
+// the original Observer/Notify path), it carries full request identity
+// and before/after mutation state, so a downstream consumer can
+// reconstruct what changed without knowing the server version or
+// re-deriving it from storage.
+type AuditEventV1 struct {
+	Version int `json:"version"`
+	// RequestID identifies the originating request, e.g. chi middleware's
+	// X-Request-Id, so events from the same request can be correlated
+	// across sinks.
+	RequestID string `json:"request_id,omitempty"`
+	Timestamp int64  `json:"ts"`
+	RemoteIP  string `json:"remote_ip"`
+	// Method and Path are set for HTTP requests; GRPCMethod is set
+	// instead for requests that arrived over the gRPC transport.
+	Method     string `json:"method,omitempty"`
+	Path       string `json:"path,omitempty"`
+	GRPCMethod string `json:"grpc_method,omitempty"`
+	// Actor is the caller's identity: the actor given to NewAuditEventV1
+	// (e.g. an mTLS client certificate CN/SAN, see
+	// grpc.PeerIdentityInterceptor), falling back to the request's
+	// X-Real-IP header when that is empty.
+	Actor       string           `json:"actor,omitempty"`
+	Mutations   []MetricMutation `json:"mutations"`
+	OutcomeCode int              `json:"outcome_code"`
+}
+
+// NewAuditEventV1 builds an AuditEventV1 for an HTTP request, stamping
+// Version, Timestamp, and RemoteIP (via GetClientIP) automatically. actor
+// is the strongest identity available for the caller, e.g. an mTLS
+// certificate CN if the request arrived over mutual TLS; an empty actor is
+// replaced by the request's X-Real-IP header.
+func NewAuditEventV1(r *http.Request, requestID, actor string, mutations []MetricMutation, outcomeCode int) AuditEventV1 {
+	if actor == "" {
+		actor = r.Header.Get("X-Real-IP")
+	}
+	return AuditEventV1{
+		Version:     AuditEventSchemaVersion,
+		RequestID:   requestID,
+		Timestamp:   time.Now().Unix(),
+		RemoteIP:    GetClientIP(r),
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		Actor:       actor,
+		Mutations:   mutations,
+		OutcomeCode: outcomeCode,
+	}
+}
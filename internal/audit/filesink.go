@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FileSinkConfig configures NewFileSink.
+type FileSinkConfig struct {
+	// Path is the active log file. Rotated files are renamed Path.1,
+	// Path.2, ... up to MaxBackups, oldest evicted first.
+	Path string
+	// MaxSizeBytes rotates the active file once writing the next event
+	// would exceed this size; 0 disables rotation.
+	MaxSizeBytes int64
+	// MaxBackups caps how many rotated files are kept; 0 means unbounded.
+	MaxBackups int
+}
+
+// FileSink appends one JSON-encoded AuditEventV1 per line to
+// FileSinkConfig.Path, rotating to Path.1, Path.2, ... once the active
+// file would exceed MaxSizeBytes.
+type FileSink struct {
+	cfg  FileSinkConfig
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// NewFileSink opens cfg.Path (creating it if needed) and returns a
+// FileSink ready to Write, with size tracked from the file's existing
+// length so rotation still triggers at the right point across restarts.
+func NewFileSink(cfg FileSinkConfig) (*FileSink, error) {
+	s := &FileSink{cfg: cfg}
+	if err := s.openCurrent(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileSink) openCurrent() error {
+	file, err := os.OpenFile(s.cfg.Path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit file %q: %w", s.cfg.Path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat audit file %q: %w", s.cfg.Path, err)
+	}
+
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// Write appends event as one JSON line, rotating first if it would push
+// the active file past MaxSizeBytes.
+func (s *FileSink) Write(ctx context.Context, event AuditEventV1) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cfg.MaxSizeBytes > 0 && s.size+int64(len(data)) > s.cfg.MaxSizeBytes {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write audit event: %w", err)
+	}
+	return nil
+}
+
+// rotate closes the active file, shifts Path.1..Path.N-1 to
+// Path.2..Path.N (dropping the oldest once MaxBackups is reached), renames
+// Path to Path.1, and reopens a fresh Path for writing.
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit file for rotation: %w", err)
+	}
+
+	backups := s.cfg.MaxBackups
+	if backups > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", s.cfg.Path, backups))
+	} else {
+		// Unbounded: shift every existing backup, however many there are.
+		backups = 1
+		for {
+			if _, err := os.Stat(fmt.Sprintf("%s.%d", s.cfg.Path, backups)); err != nil {
+				break
+			}
+			backups++
+		}
+	}
+	for n := backups - 1; n >= 1; n-- {
+		os.Rename(fmt.Sprintf("%s.%d", s.cfg.Path, n), fmt.Sprintf("%s.%d", s.cfg.Path, n+1))
+	}
+
+	if err := os.Rename(s.cfg.Path, s.cfg.Path+".1"); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate audit file %q: %w", s.cfg.Path, err)
+	}
+
+	return s.openCurrent()
+}
+
+// Close closes the active file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
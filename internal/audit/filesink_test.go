@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestFileSinkWriteAndRotate(t *testing.T) {
+	testFile := "/tmp/test_audit_filesink.log"
+	defer os.Remove(testFile)
+	defer os.Remove(testFile + ".1")
+	defer os.Remove(testFile + ".2")
+
+	sink, err := NewFileSink(FileSinkConfig{Path: testFile, MaxSizeBytes: 1, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("NewFileSink failed: %v", err)
+	}
+	defer sink.Close()
+
+	event := AuditEventV1{
+		Version:   AuditEventSchemaVersion,
+		Mutations: []MetricMutation{{Name: "test-metric", Direction: MutationSet, OldValue: "1", NewValue: "2"}},
+	}
+
+	for range 3 {
+		if err := sink.Write(context.Background(), event); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	if _, err := os.Stat(testFile + ".1"); err != nil {
+		t.Errorf("expected rotated file %s.1 to exist: %v", testFile, err)
+	}
+
+	content, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("failed to read active file: %v", err)
+	}
+
+	var decoded AuditEventV1
+	if err := json.Unmarshal(content[:len(content)-1], &decoded); err != nil {
+		t.Fatalf("failed to decode written event: %v", err)
+	}
+	if len(decoded.Mutations) != 1 || decoded.Mutations[0].Name != "test-metric" {
+		t.Errorf("unexpected decoded event: %+v", decoded)
+	}
+}
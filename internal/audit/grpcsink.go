@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/idudko/go-musthave-metrics/internal/proto"
+)
+
+// GRPCSinkConfig configures NewGRPCSink.
+type GRPCSinkConfig struct {
+	// Address is the remote audit collector's gRPC address.
+	Address string
+	// TLS configures the connection to the collector; its zero value
+	// dials insecurely.
+	TLS ObserverTLSConfig
+}
+
+// GRPCSink streams AuditEventV1 to a remote collector over one long-lived
+// StreamAuditEvents RPC, instead of dialing a new call per event the way
+// HTTPObserver POSTs one event per call.
+type GRPCSink struct {
+	conn   *grpc.ClientConn
+	stream proto.AuditCollector_StreamAuditEventsClient
+}
+
+// NewGRPCSink dials cfg.Address and opens a StreamAuditEvents stream.
+func NewGRPCSink(cfg GRPCSinkConfig) (*GRPCSink, error) {
+	tlsConfig, err := cfg.TLS.buildTLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build audit gRPC sink TLS config: %w", err)
+	}
+
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	conn, err := grpc.NewClient(cfg.Address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial audit collector %q: %w", cfg.Address, err)
+	}
+
+	stream, err := proto.NewAuditCollectorClient(conn).StreamAuditEvents(context.Background())
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open StreamAuditEvents stream: %w", err)
+	}
+
+	return &GRPCSink{conn: conn, stream: stream}, nil
+}
+
+// Write sends event as a framed proto.AuditEvent message on the stream.
+func (s *GRPCSink) Write(ctx context.Context, event AuditEventV1) error {
+	return s.stream.Send(toProtoAuditEvent(event))
+}
+
+// Close half-closes the stream, waits for the collector's final Ack, and
+// closes the underlying connection.
+func (s *GRPCSink) Close() error {
+	_, err := s.stream.CloseAndRecv()
+	if closeErr := s.conn.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// toProtoAuditEvent converts event to its protobuf representation.
+func toProtoAuditEvent(event AuditEventV1) *proto.AuditEvent {
+	mutations := make([]*proto.AuditMutation, 0, len(event.Mutations))
+	for _, m := range event.Mutations {
+		mutations = append(mutations, &proto.AuditMutation{
+			Name:      m.Name,
+			Direction: string(m.Direction),
+			OldValue:  m.OldValue,
+			NewValue:  m.NewValue,
+		})
+	}
+
+	return &proto.AuditEvent{
+		Version:     int32(event.Version),
+		RequestId:   event.RequestID,
+		Timestamp:   event.Timestamp,
+		RemoteIp:    event.RemoteIP,
+		Method:      event.Method,
+		Path:        event.Path,
+		GrpcMethod:  event.GRPCMethod,
+		Actor:       event.Actor,
+		Mutations:   mutations,
+		OutcomeCode: int32(event.OutcomeCode),
+	}
+}
@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+// KafkaObserverConfig configures NewKafkaObserver.
+type KafkaObserverConfig struct {
+	Brokers  []string
+	Topic    string
+	ClientID string
+	TLS      ObserverTLSConfig
+	// RetryMax is the number of times the producer retries a failed
+	// publish before surfacing it on Errors(); 0 uses sarama's default.
+	RetryMax int
+	// RetryBackoff is the delay between retries; 0 uses sarama's default.
+	RetryBackoff time.Duration
+}
+
+// KafkaObserver publishes AuditEvent JSON to a Kafka topic via an async
+// producer, partitioned by IPAddress so events from the same client stay
+// in relative order on the same partition.
+type KafkaObserver struct {
+	producer sarama.AsyncProducer
+	topic    string
+}
+
+// NewKafkaObserver creates a KafkaObserver per cfg and starts a background
+// goroutine logging any publish errors the async producer reports.
+func NewKafkaObserver(cfg KafkaObserverConfig) (*KafkaObserver, error) {
+	config := sarama.NewConfig()
+	config.ClientID = cfg.ClientID
+	config.Producer.Return.Successes = false
+	config.Producer.Return.Errors = true
+	config.Producer.Partitioner = sarama.NewHashPartitioner
+	if cfg.RetryMax > 0 {
+		config.Producer.Retry.Max = cfg.RetryMax
+	}
+	if cfg.RetryBackoff > 0 {
+		config.Producer.Retry.Backoff = cfg.RetryBackoff
+	}
+
+	tlsConfig, err := cfg.TLS.buildTLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Kafka TLS config: %w", err)
+	}
+	if tlsConfig != nil {
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+	}
+
+	producer, err := sarama.NewAsyncProducer(cfg.Brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+
+	o := &KafkaObserver{producer: producer, topic: cfg.Topic}
+	go o.logErrors()
+	return o, nil
+}
+
+func (o *KafkaObserver) logErrors() {
+	for err := range o.producer.Errors() {
+		log.Printf("Failed to publish audit event to Kafka: %v", err)
+	}
+}
+
+// Notify publishes event to the configured topic, keyed by its IPAddress.
+func (o *KafkaObserver) Notify(event AuditEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to marshal audit event for Kafka: %v", err)
+		return
+	}
+
+	o.producer.Input() <- &sarama.ProducerMessage{
+		Topic: o.topic,
+		Key:   sarama.StringEncoder(event.IPAddress),
+		Value: sarama.ByteEncoder(data),
+	}
+}
+
+// Close flushes pending messages and shuts down the producer, satisfying
+// the optional Closer interface Subject.Close checks for.
+func (o *KafkaObserver) Close() error {
+	return o.producer.Close()
+}
@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/Shopify/sarama"
+)
+
+// KafkaPublisher implements Publisher over a synchronous sarama producer,
+// so PubSubSink.Write's error return reflects the actual publish outcome -
+// unlike KafkaObserver, which fires into an async producer and only logs
+// errors in the background.
+type KafkaPublisher struct {
+	producer sarama.SyncProducer
+}
+
+// NewKafkaPublisher creates a KafkaPublisher per cfg; see KafkaObserverConfig
+// for field docs (TLS and retry tuning are shared with KafkaObserver).
+func NewKafkaPublisher(cfg KafkaObserverConfig) (*KafkaPublisher, error) {
+	config := sarama.NewConfig()
+	config.ClientID = cfg.ClientID
+	config.Producer.Return.Successes = true
+	if cfg.RetryMax > 0 {
+		config.Producer.Retry.Max = cfg.RetryMax
+	}
+	if cfg.RetryBackoff > 0 {
+		config.Producer.Retry.Backoff = cfg.RetryBackoff
+	}
+
+	tlsConfig, err := cfg.TLS.buildTLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Kafka TLS config: %w", err)
+	}
+	if tlsConfig != nil {
+		config.Net.TLS.Enable = true
+		config.Net.TLS.Config = tlsConfig
+	}
+
+	producer, err := sarama.NewSyncProducer(cfg.Brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka producer: %w", err)
+	}
+
+	return &KafkaPublisher{producer: producer}, nil
+}
+
+func (p *KafkaPublisher) Publish(topic string, key, value []byte) error {
+	_, _, err := p.producer.SendMessage(&sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.ByteEncoder(key),
+		Value: sarama.ByteEncoder(value),
+	})
+	return err
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.producer.Close()
+}
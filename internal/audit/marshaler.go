@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Marshaler serializes a batch of AuditEvents into a WebhookObserver
+// request body, returning the payload alongside the Content-Type it
+// should be sent under.
+type Marshaler interface {
+	Marshal(events []AuditEvent) (payload []byte, contentType string, err error)
+}
+
+// JSONMarshaler is the default Marshaler: events encoded as a plain JSON
+// array, one object per AuditEvent.
+type JSONMarshaler struct{}
+
+func (JSONMarshaler) Marshal(events []AuditEvent) ([]byte, string, error) {
+	payload, err := json.Marshal(events)
+	if err != nil {
+		return nil, "", fmt.Errorf("marshaling audit events as JSON: %w", err)
+	}
+	return payload, "application/json", nil
+}
+
+// cloudEvent is one AuditEvent wrapped in a CloudEvents v1.0 structured-
+// mode envelope, for receivers built against the CloudEvents spec instead
+// of this package's native AuditEvent shape.
+type cloudEvent struct {
+	SpecVersion     string      `json:"specversion"`
+	Type            string      `json:"type"`
+	Source          string      `json:"source"`
+	ID              string      `json:"id"`
+	Time            string      `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data"`
+}
+
+// CloudEventsMarshaler is an alternative Marshaler that wraps each
+// AuditEvent in a CloudEvents v1.0 structured-mode envelope, batched per
+// the CloudEvents "batched structured mode" extension (a JSON array of
+// structured-mode events, content type
+// application/cloudevents-batch+json), for interoperability with event
+// pipelines already built against CloudEvents.
+type CloudEventsMarshaler struct {
+	// Source is the CloudEvents "source" attribute identifying this
+	// producer, e.g. "go-musthave-metrics/audit". Required by the spec;
+	// left empty, the zero value is sent as-is.
+	Source string
+}
+
+func (m CloudEventsMarshaler) Marshal(events []AuditEvent) ([]byte, string, error) {
+	wrapped := make([]cloudEvent, len(events))
+	for i, event := range events {
+		wrapped[i] = cloudEvent{
+			SpecVersion:     "1.0",
+			Type:            "com.go-musthave-metrics.audit.event",
+			Source:          m.Source,
+			ID:              fmt.Sprintf("%s-%d-%d", event.IPAddress, event.Timestamp, i),
+			Time:            time.Unix(event.Timestamp, 0).UTC().Format(time.RFC3339),
+			DataContentType: "application/json",
+			Data:            event,
+		}
+	}
+
+	payload, err := json.Marshal(wrapped)
+	if err != nil {
+		return nil, "", fmt.Errorf("marshaling audit events as CloudEvents: %w", err)
+	}
+	return payload, "application/cloudevents-batch+json", nil
+}
@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// MQTTObserverConfig configures NewMQTTObserver.
+type MQTTObserverConfig struct {
+	Brokers  []string
+	Topic    string
+	ClientID string
+	// QoS is clamped to [0, 2], MQTT's valid range.
+	QoS                  byte
+	TLS                  ObserverTLSConfig
+	ConnectTimeout       time.Duration
+	ConnectRetryInterval time.Duration
+}
+
+// MQTTObserver publishes AuditEvent JSON to an MQTT topic at a configurable
+// QoS, reconnecting automatically on connection loss.
+type MQTTObserver struct {
+	client mqtt.Client
+	topic  string
+	qos    byte
+}
+
+// NewMQTTObserver creates an MQTTObserver per cfg and connects to the
+// broker, failing if the connection isn't established within
+// cfg.ConnectTimeout (default 10s).
+func NewMQTTObserver(cfg MQTTObserverConfig) (*MQTTObserver, error) {
+	tlsConfig, err := cfg.TLS.buildTLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build MQTT TLS config: %w", err)
+	}
+
+	opts := mqtt.NewClientOptions()
+	for _, broker := range cfg.Brokers {
+		opts.AddBroker(broker)
+	}
+	opts.SetClientID(cfg.ClientID)
+	opts.SetAutoReconnect(true)
+	if cfg.ConnectRetryInterval > 0 {
+		opts.SetConnectRetryInterval(cfg.ConnectRetryInterval)
+	}
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+	opts.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		log.Printf("MQTT audit observer lost connection: %v", err)
+	})
+
+	client := mqtt.NewClient(opts)
+
+	timeout := cfg.ConnectTimeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	token := client.Connect()
+	if !token.WaitTimeout(timeout) {
+		return nil, fmt.Errorf("timed out connecting to MQTT broker")
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+
+	qos := cfg.QoS
+	if qos > 2 {
+		qos = 2
+	}
+
+	return &MQTTObserver{client: client, topic: cfg.Topic, qos: qos}, nil
+}
+
+// Notify publishes event to the configured topic at the observer's QoS.
+func (o *MQTTObserver) Notify(event AuditEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to marshal audit event for MQTT: %v", err)
+		return
+	}
+
+	token := o.client.Publish(o.topic, o.qos, false, data)
+	token.Wait()
+	if err := token.Error(); err != nil {
+		log.Printf("Failed to publish audit event to MQTT: %v", err)
+	}
+}
+
+// Close disconnects from the broker, waiting up to 250ms for in-flight
+// publishes to flush, satisfying the optional Closer interface
+// Subject.Close checks for.
+func (o *MQTTObserver) Close() error {
+	o.client.Disconnect(250)
+	return nil
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NatsPublisherConfig configures NewNatsPublisher.
+type NatsPublisherConfig struct {
+	URL  string
+	Name string
+}
+
+// NatsPublisher implements Publisher over a core NATS connection.
+// Publish's key argument is ignored, since NATS subjects have no
+// Kafka-style partition key.
+type NatsPublisher struct {
+	conn *nats.Conn
+}
+
+// NewNatsPublisher connects to cfg.URL, identifying itself as cfg.Name.
+func NewNatsPublisher(cfg NatsPublisherConfig) (*NatsPublisher, error) {
+	conn, err := nats.Connect(cfg.URL, nats.Name(cfg.Name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS at %q: %w", cfg.URL, err)
+	}
+	return &NatsPublisher{conn: conn}, nil
+}
+
+func (p *NatsPublisher) Publish(subject string, _, value []byte) error {
+	return p.conn.Publish(subject, value)
+}
+
+// Close drains and closes the connection, giving in-flight publishes a
+// chance to reach the server before shutting down.
+func (p *NatsPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Publisher abstracts the message-broker client a PubSubSink publishes
+// through, so the same Write/encoding logic backs both Kafka
+// (KafkaPublisher) and NATS (NatsPublisher) without duplicating it per
+// broker.
+type Publisher interface {
+	// Publish sends value to subject (a Kafka topic or NATS subject). key
+	// is used for Kafka partitioning and ignored by NATS.
+	Publish(subject string, key, value []byte) error
+	Close() error
+}
+
+// PubSubSink publishes each AuditEventV1 as JSON to subject via Publisher,
+// keyed by RemoteIP so events from the same client stay in relative order
+// on the same Kafka partition.
+type PubSubSink struct {
+	publisher Publisher
+	subject   string
+}
+
+// NewPubSubSink creates a PubSubSink that publishes to subject via
+// publisher.
+func NewPubSubSink(publisher Publisher, subject string) *PubSubSink {
+	return &PubSubSink{publisher: publisher, subject: subject}
+}
+
+func (s *PubSubSink) Write(ctx context.Context, event AuditEventV1) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit event: %w", err)
+	}
+
+	if err := s.publisher.Publish(s.subject, []byte(event.RemoteIP), data); err != nil {
+		return fmt.Errorf("failed to publish audit event: %w", err)
+	}
+	return nil
+}
+
+func (s *PubSubSink) Close() error {
+	return s.publisher.Close()
+}
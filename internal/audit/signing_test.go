@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSignedFileObserverChainVerifies(t *testing.T) {
+	testFile := "/tmp/test_audit_signed.log"
+	defer os.Remove(testFile)
+
+	observer := NewSignedFileObserver(testFile, "secret", nil)
+	for i := 0; i < 3; i++ {
+		observer.Notify(AuditEvent{Timestamp: int64(i), Metrics: []string{"m"}, IPAddress: "127.0.0.1"})
+	}
+
+	breaks, err := NewVerifier("secret", nil).VerifyFile(testFile)
+	if err != nil {
+		t.Fatalf("VerifyFile failed: %v", err)
+	}
+	if len(breaks) != 0 {
+		t.Fatalf("expected no chain breaks, got %v", breaks)
+	}
+}
+
+func TestVerifierDetectsTamperedEntry(t *testing.T) {
+	testFile := "/tmp/test_audit_tampered.log"
+	defer os.Remove(testFile)
+
+	observer := NewSignedFileObserver(testFile, "secret", nil)
+	observer.Notify(AuditEvent{Timestamp: 1, Metrics: []string{"m"}, IPAddress: "127.0.0.1"})
+	observer.Notify(AuditEvent{Timestamp: 2, Metrics: []string{"m"}, IPAddress: "127.0.0.1"})
+
+	data, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	corrupted := append([]byte(nil), data...)
+	for i := range corrupted {
+		if corrupted[i] == '1' {
+			corrupted[i] = '9'
+			break
+		}
+	}
+	if err := os.WriteFile(testFile, corrupted, 0644); err != nil {
+		t.Fatalf("failed to write tampered file: %v", err)
+	}
+
+	breaks, err := NewVerifier("secret", nil).VerifyFile(testFile)
+	if err != nil {
+		t.Fatalf("VerifyFile failed: %v", err)
+	}
+	if len(breaks) == 0 {
+		t.Fatal("expected tampering to produce at least one chain break")
+	}
+}
+
+func TestVerifierDetectsWrongKey(t *testing.T) {
+	testFile := "/tmp/test_audit_wrongkey.log"
+	defer os.Remove(testFile)
+
+	observer := NewSignedFileObserver(testFile, "secret", nil)
+	observer.Notify(AuditEvent{Timestamp: 1, Metrics: []string{"m"}, IPAddress: "127.0.0.1"})
+
+	breaks, err := NewVerifier("wrong-key", nil).VerifyFile(testFile)
+	if err != nil {
+		t.Fatalf("VerifyFile failed: %v", err)
+	}
+	if len(breaks) == 0 {
+		t.Fatal("expected a signature break when verifying with the wrong key")
+	}
+}
@@ -0,0 +1,15 @@
+package audit
+
+import "context"
+
+// Sink is a structured alternative to Observer: Write takes a context (so
+// a slow downstream can be cancelled) and returns an error the caller can
+// act on, and it carries an AuditEventV1 with full request identity and
+// before/after mutation state instead of Observer's metric-names-only
+// AuditEvent. Subject's async fan-out pipeline is built around Observer;
+// callers that want a Sink's richer payload or error return call Write
+// directly, or wrap it behind their own retry/fan-out policy.
+type Sink interface {
+	Write(ctx context.Context, event AuditEventV1) error
+	Close() error
+}
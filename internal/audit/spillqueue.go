@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.etcd.io/bbolt"
+)
+
+// spillEventsBucket holds every spilled AuditEvent, keyed by an
+// auto-incrementing sequence number so Drain always replays them in the
+// order they were pushed.
+var spillEventsBucket = []byte("events")
+
+// errSpillQueueFull is returned by spillQueue.Push once the queue holds
+// maxSize events.
+var errSpillQueueFull = errors.New("audit: spill queue is full")
+
+// spillQueue is a durable, FIFO, on-disk queue of AuditEvents backed by a
+// single BoltDB file. It's Subject's overflow path: events that don't fit
+// in the in-memory channel are persisted here instead of lost, and
+// replayed on the next startup before live traffic resumes.
+type spillQueue struct {
+	db      *bbolt.DB
+	maxSize int64
+}
+
+// newSpillQueue opens (creating if needed) a BoltDB-backed queue under dir.
+// A maxSize of 0 means unbounded.
+func newSpillQueue(dir string, maxSize int64) (*spillQueue, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create spill directory: %w", err)
+	}
+
+	db, err := bbolt.Open(filepath.Join(dir, "audit-spill.db"), 0o600, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spill queue file: %w", err)
+	}
+
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(spillEventsBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize spill queue bucket: %w", err)
+	}
+
+	return &spillQueue{db: db, maxSize: maxSize}, nil
+}
+
+// Push persists event at the tail of the queue, failing with
+// errSpillQueueFull once the queue holds q.maxSize events.
+func (q *spillQueue) Push(event AuditEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal spilled audit event: %w", err)
+	}
+
+	return q.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(spillEventsBucket)
+		if q.maxSize > 0 && int64(b.Stats().KeyN) >= q.maxSize {
+			return errSpillQueueFull
+		}
+
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		return b.Put(sequenceKey(seq), data)
+	})
+}
+
+// Drain removes and returns every event currently queued, in the order
+// they were pushed.
+func (q *spillQueue) Drain() ([]AuditEvent, error) {
+	var events []AuditEvent
+
+	err := q.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(spillEventsBucket)
+		c := b.Cursor()
+
+		var keys [][]byte
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var event AuditEvent
+			if err := json.Unmarshal(v, &event); err != nil {
+				return fmt.Errorf("failed to unmarshal spilled audit event: %w", err)
+			}
+			events = append(events, event)
+			keys = append(keys, append([]byte(nil), k...))
+		}
+
+		for _, k := range keys {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return events, err
+}
+
+// Close releases the underlying BoltDB file.
+func (q *spillQueue) Close() error {
+	return q.db.Close()
+}
+
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSpillQueue_PushAndDrainPreservesOrder(t *testing.T) {
+	q, err := newSpillQueue(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("newSpillQueue() error = %v", err)
+	}
+	defer q.Close()
+
+	for i := range 3 {
+		event := AuditEvent{Timestamp: int64(i), Metrics: []string{"m"}}
+		if err := q.Push(event); err != nil {
+			t.Fatalf("Push() error = %v", err)
+		}
+	}
+
+	events, err := q.Drain()
+	if err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	for i, event := range events {
+		if event.Timestamp != int64(i) {
+			t.Errorf("event %d: Timestamp = %d, want %d", i, event.Timestamp, i)
+		}
+	}
+
+	if remaining, err := q.Drain(); err != nil || len(remaining) != 0 {
+		t.Errorf("expected queue to be empty after Drain, got %d events, err %v", len(remaining), err)
+	}
+}
+
+func TestSpillQueue_PushFailsWhenFull(t *testing.T) {
+	q, err := newSpillQueue(t.TempDir(), 1)
+	if err != nil {
+		t.Fatalf("newSpillQueue() error = %v", err)
+	}
+	defer q.Close()
+
+	if err := q.Push(AuditEvent{Timestamp: time.Now().Unix()}); err != nil {
+		t.Fatalf("first Push() error = %v", err)
+	}
+	if err := q.Push(AuditEvent{Timestamp: time.Now().Unix()}); err != errSpillQueueFull {
+		t.Errorf("second Push() error = %v, want %v", err, errSpillQueueFull)
+	}
+}
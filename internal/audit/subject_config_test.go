@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingObserver records how many events it was notified of.
+type countingObserver struct {
+	notified chan struct{}
+}
+
+func newCountingObserver() *countingObserver {
+	return &countingObserver{notified: make(chan struct{}, 1000)}
+}
+
+func (o *countingObserver) Notify(event AuditEvent) {
+	o.notified <- struct{}{}
+}
+
+func (o *countingObserver) waitFor(t *testing.T, n int, timeout time.Duration) {
+	t.Helper()
+	deadline := time.After(timeout)
+	for i := 0; i < n; i++ {
+		select {
+		case <-o.notified:
+		case <-deadline:
+			t.Fatalf("timed out waiting for %d notifications, got %d", n, i)
+		}
+	}
+}
+
+func TestSubject_NotifyAllDeliversAsync(t *testing.T) {
+	subject := NewSubject()
+	defer subject.Close()
+
+	observer := newCountingObserver()
+	subject.Attach(observer)
+
+	subject.NotifyAll(AuditEvent{Timestamp: 1})
+	observer.waitFor(t, 1, time.Second)
+
+	if got := subject.Metrics().Enqueued; got != 1 {
+		t.Errorf("Enqueued = %d, want 1", got)
+	}
+}
+
+func TestSubject_DrainsSpillQueueOnStartup(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spill")
+
+	first, err := NewSubjectWithConfig(SubjectConfig{Workers: 1, QueueCapacity: 1, SpillDir: dir})
+	if err != nil {
+		t.Fatalf("NewSubjectWithConfig() error = %v", err)
+	}
+	if err := first.queue.Push(AuditEvent{Timestamp: 42}); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	observer := newCountingObserver()
+	second, err := NewSubjectWithConfig(SubjectConfig{Workers: 1, QueueCapacity: 1, SpillDir: dir})
+	if err != nil {
+		t.Fatalf("NewSubjectWithConfig() error = %v", err)
+	}
+	defer second.Close()
+	second.Attach(observer)
+
+	observer.waitFor(t, 1, time.Second)
+
+	if got := second.Metrics().Retried; got != 1 {
+		t.Errorf("Retried = %d, want 1", got)
+	}
+}
+
+func TestSubject_DrainsSpillQueueAtRuntime(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spill")
+
+	subject, err := NewSubjectWithConfig(SubjectConfig{
+		Workers:            1,
+		QueueCapacity:      1,
+		SpillDir:           dir,
+		SpillDrainInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewSubjectWithConfig() error = %v", err)
+	}
+	defer subject.Close()
+
+	observer := newCountingObserver()
+	subject.Attach(observer)
+
+	// Push straight to the spill queue, bypassing NotifyAll, to simulate an
+	// event that overflowed earlier in the process's life rather than one
+	// left over from a previous run.
+	if err := subject.queue.Push(AuditEvent{Timestamp: 7}); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	observer.waitFor(t, 1, time.Second)
+
+	if got := subject.Metrics().Retried; got != 1 {
+		t.Errorf("Retried = %d, want 1", got)
+	}
+}
+
+// blockingObserver signals started the first time Notify is called, then
+// blocks until release is closed - used to pin the single worker busy so a
+// test can deterministically fill the in-memory channel behind it.
+type blockingObserver struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingObserver() *blockingObserver {
+	return &blockingObserver{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (o *blockingObserver) Notify(event AuditEvent) {
+	select {
+	case o.started <- struct{}{}:
+	default:
+	}
+	<-o.release
+}
+
+func TestSubject_DropOldestEvictsUnderPressure(t *testing.T) {
+	subject, err := NewSubjectWithConfig(SubjectConfig{Workers: 1, QueueCapacity: 1, DropOldest: true})
+	if err != nil {
+		t.Fatalf("NewSubjectWithConfig() error = %v", err)
+	}
+	observer := newBlockingObserver()
+	subject.Attach(observer)
+
+	// The worker picks this up immediately and blocks inside Notify,
+	// leaving the channel empty but the pipeline busy.
+	subject.NotifyAll(AuditEvent{Timestamp: 1})
+	<-observer.started
+
+	subject.NotifyAll(AuditEvent{Timestamp: 2}) // fills the now-empty channel
+	subject.NotifyAll(AuditEvent{Timestamp: 3}) // must evict event 2
+
+	close(observer.release)
+
+	if got := subject.Metrics().Dropped; got != 1 {
+		t.Errorf("Dropped = %d, want 1", got)
+	}
+}
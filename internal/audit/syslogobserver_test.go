@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogObserver_WritesRFC5424Message(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket: %v", err)
+	}
+	defer conn.Close()
+
+	o := NewSyslogObserver(SyslogObserverConfig{
+		Network: "udp",
+		Addr:    conn.LocalAddr().String(),
+		AppName: "audit-test",
+	})
+	defer o.Close()
+
+	o.Notify(AuditEvent{Timestamp: time.Now().Unix(), IPAddress: "127.0.0.1", Metrics: []string{"PollCount"}})
+
+	buf := make([]byte, 2048)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	msg := string(buf[:n])
+
+	if !strings.HasPrefix(msg, "<") {
+		t.Errorf("message %q doesn't start with a PRI header", msg)
+	}
+	if !strings.Contains(msg, "audit-test") {
+		t.Errorf("message %q doesn't contain AppName %q", msg, "audit-test")
+	}
+	if !strings.Contains(msg, "PollCount") {
+		t.Errorf("message %q doesn't contain the event's JSON payload", msg)
+	}
+}
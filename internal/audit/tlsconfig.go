@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// ObserverTLSConfig configures optional TLS for observers and sinks that
+// dial an external endpoint (KafkaObserver, MQTTObserver, GRPCSink): a
+// client certificate for mutual TLS, a private CA to validate the remote
+// end against, or skipping verification entirely for local/dev endpoints
+// using self-signed certs.
+type ObserverTLSConfig struct {
+	CertFile           string
+	KeyFile            string
+	CAFile             string
+	InsecureSkipVerify bool
+}
+
+// buildTLSConfig returns nil, nil when c is the zero value, so callers can
+// treat that as "TLS disabled" without an extra check.
+func (c ObserverTLSConfig) buildTLSConfig() (*tls.Config, error) {
+	if c.CertFile == "" && c.KeyFile == "" && c.CAFile == "" && !c.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+
+	if c.CertFile != "" && c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if c.CAFile != "" {
+		caCert, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse CA certificate %q", c.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,30 @@
+package audit
+
+import "testing"
+
+func TestObserverTLSConfig_ZeroValueDisablesTLS(t *testing.T) {
+	cfg, err := ObserverTLSConfig{}.buildTLSConfig()
+	if err != nil {
+		t.Fatalf("buildTLSConfig() error = %v", err)
+	}
+	if cfg != nil {
+		t.Errorf("expected nil *tls.Config for the zero value, got %+v", cfg)
+	}
+}
+
+func TestObserverTLSConfig_InsecureSkipVerify(t *testing.T) {
+	cfg, err := ObserverTLSConfig{InsecureSkipVerify: true}.buildTLSConfig()
+	if err != nil {
+		t.Fatalf("buildTLSConfig() error = %v", err)
+	}
+	if cfg == nil || !cfg.InsecureSkipVerify {
+		t.Errorf("expected InsecureSkipVerify to carry through, got %+v", cfg)
+	}
+}
+
+func TestObserverTLSConfig_MissingCAFile(t *testing.T) {
+	_, err := ObserverTLSConfig{CAFile: "/nonexistent/ca.pem"}.buildTLSConfig()
+	if err == nil {
+		t.Error("expected an error for a missing CA file, got nil")
+	}
+}
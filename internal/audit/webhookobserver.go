@@ -0,0 +1,229 @@
+package audit
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/idudko/go-musthave-metrics/pkg/hash"
+	"github.com/idudko/go-musthave-metrics/pkg/httpretry"
+)
+
+// WebhookObserverConfig configures NewWebhookObserver.
+type WebhookObserverConfig struct {
+	URL string
+	// Secret, if set, HMAC-SHA256-signs every request body into an
+	// X-Signature-256: sha256=<hex> header alongside an X-Timestamp
+	// header, so the receiver can reject stale or tampered deliveries.
+	Secret string
+	// Marshaler serializes each batch into the request body. Defaults to
+	// JSONMarshaler.
+	Marshaler Marshaler
+	// QueueCapacity bounds the in-memory queue of events awaiting
+	// delivery; once full, Notify drops the oldest queued event to make
+	// room for the new one. Defaults to 256.
+	QueueCapacity int
+	// MaxBatch caps how many events a single POST carries. Defaults to 50.
+	MaxBatch int
+	// FlushInterval is the longest an event waits in the queue before a
+	// partial batch is flushed anyway. Defaults to 5s.
+	FlushInterval time.Duration
+	// Client is the http.Client used to deliver batches. Defaults to one
+	// whose transport retries with httpretry.DefaultBackoff, so a failed
+	// delivery is retried with jittered exponential backoff before the
+	// batch is given up on.
+	Client *http.Client
+}
+
+// WebhookObserverMetrics reports a WebhookObserver's delivery counters, for
+// wiring into expvar/Prometheus by callers that want fleet-wide webhook
+// delivery health.
+type WebhookObserverMetrics struct {
+	// Sent counts events successfully delivered (as part of any batch).
+	Sent int64
+	// Failed counts events whose batch was given up on after retries.
+	Failed int64
+	// Dropped counts events evicted from the in-memory queue to make room
+	// for newer ones, because the consumer fell behind.
+	Dropped int64
+}
+
+// WebhookObserver batches AuditEvents and POSTs them to a URL, HMAC-signing
+// each request body when configured with a Secret. Events are queued in a
+// bounded in-memory channel that drops the oldest entry on overflow; a
+// single background goroutine drains it into batches of up to MaxBatch
+// events, flushed every FlushInterval even if a batch isn't full.
+type WebhookObserver struct {
+	url       string
+	secret    string
+	marshaler Marshaler
+	client    *http.Client
+	maxBatch  int
+	interval  time.Duration
+
+	events chan AuditEvent
+	done   chan struct{}
+	wg     sync.WaitGroup
+
+	sent    atomic.Int64
+	failed  atomic.Int64
+	dropped atomic.Int64
+}
+
+// NewWebhookObserver creates a WebhookObserver per cfg and starts its
+// background batching goroutine.
+func NewWebhookObserver(cfg WebhookObserverConfig) *WebhookObserver {
+	marshaler := cfg.Marshaler
+	if marshaler == nil {
+		marshaler = JSONMarshaler{}
+	}
+	queueCapacity := cfg.QueueCapacity
+	if queueCapacity <= 0 {
+		queueCapacity = 256
+	}
+	maxBatch := cfg.MaxBatch
+	if maxBatch <= 0 {
+		maxBatch = 50
+	}
+	interval := cfg.FlushInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Transport: httpretry.NewRoundTripper(nil, nil)}
+	}
+
+	o := &WebhookObserver{
+		url:       cfg.URL,
+		secret:    cfg.Secret,
+		marshaler: marshaler,
+		client:    client,
+		maxBatch:  maxBatch,
+		interval:  interval,
+		events:    make(chan AuditEvent, queueCapacity),
+		done:      make(chan struct{}),
+	}
+
+	o.wg.Add(1)
+	go o.run()
+	return o
+}
+
+// Notify enqueues event for batched delivery, dropping the oldest queued
+// event instead of blocking if the queue is full.
+func (o *WebhookObserver) Notify(event AuditEvent) {
+	select {
+	case o.events <- event:
+		return
+	default:
+	}
+
+	select {
+	case <-o.events:
+		o.dropped.Add(1)
+	default:
+	}
+	select {
+	case o.events <- event:
+	default:
+		o.dropped.Add(1)
+	}
+}
+
+// run drains o.events into batches of up to o.maxBatch, flushing whenever a
+// batch fills up or o.interval elapses since the last flush, whichever
+// comes first.
+func (o *WebhookObserver) run() {
+	defer o.wg.Done()
+
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+
+	batch := make([]AuditEvent, 0, o.maxBatch)
+	for {
+		select {
+		case event, ok := <-o.events:
+			if !ok {
+				if len(batch) > 0 {
+					o.flush(batch)
+				}
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= o.maxBatch {
+				o.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				o.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush marshals batch, signs it if o.secret is set, and POSTs it to
+// o.url. Delivery failures are logged and counted as Failed rather than
+// retried again here - the http.Client's transport already retried with
+// backoff before surfacing the error.
+func (o *WebhookObserver) flush(batch []AuditEvent) {
+	payload, contentType, err := o.marshaler.Marshal(batch)
+	if err != nil {
+		log.Printf("Failed to marshal webhook audit batch: %v", err)
+		o.failed.Add(int64(len(batch)))
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodPost, o.url, bytes.NewReader(payload))
+	if err != nil {
+		log.Printf("Failed to create webhook audit request: %v", err)
+		o.failed.Add(int64(len(batch)))
+		return
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	if o.secret != "" {
+		req.Header.Set("X-Timestamp", strconv.FormatInt(time.Now().Unix(), 10))
+		req.Header.Set("X-Signature-256", "sha256="+hash.ComputeHash(payload, o.secret, hash.SHA256))
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		log.Printf("Failed to deliver webhook audit batch: %v", err)
+		o.failed.Add(int64(len(batch)))
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		log.Printf("Webhook audit endpoint returned status %d", resp.StatusCode)
+		o.failed.Add(int64(len(batch)))
+		return
+	}
+	o.sent.Add(int64(len(batch)))
+}
+
+// Metrics reports the observer's Sent/Failed/Dropped counters.
+func (o *WebhookObserver) Metrics() WebhookObserverMetrics {
+	return WebhookObserverMetrics{
+		Sent:    o.sent.Load(),
+		Failed:  o.failed.Load(),
+		Dropped: o.dropped.Load(),
+	}
+}
+
+// Close stops accepting new events - callers must not call Notify after
+// Close - flushes any batch still buffered, and waits for the flush to
+// complete, satisfying the optional Closer interface Subject.Close checks
+// for.
+func (o *WebhookObserver) Close() error {
+	close(o.events)
+	o.wg.Wait()
+	return nil
+}
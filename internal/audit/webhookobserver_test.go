@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWebhookObserver_SignatureValidatesOnReceiver(t *testing.T) {
+	const secret = "top-secret"
+
+	var (
+		mu       sync.Mutex
+		received []AuditEvent
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading request body: %v", err)
+			return
+		}
+
+		sigHeader := r.Header.Get("X-Signature-256")
+		if !strings.HasPrefix(sigHeader, "sha256=") {
+			t.Errorf("X-Signature-256 = %q, want sha256=<hex> prefix", sigHeader)
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		want := hex.EncodeToString(mac.Sum(nil))
+		if got := strings.TrimPrefix(sigHeader, "sha256="); got != want {
+			t.Errorf("signature = %q, want %q", got, want)
+		}
+		if r.Header.Get("X-Timestamp") == "" {
+			t.Error("X-Timestamp header is missing")
+		}
+
+		var events []AuditEvent
+		if err := json.Unmarshal(body, &events); err != nil {
+			t.Errorf("unmarshaling batch: %v", err)
+			return
+		}
+		mu.Lock()
+		received = append(received, events...)
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	o := NewWebhookObserver(WebhookObserverConfig{
+		URL:           server.URL,
+		Secret:        secret,
+		MaxBatch:      10,
+		FlushInterval: 20 * time.Millisecond,
+	})
+	defer o.Close()
+
+	o.Notify(AuditEvent{Timestamp: 1, IPAddress: "127.0.0.1"})
+	o.Notify(AuditEvent{Timestamp: 2, IPAddress: "127.0.0.1"})
+
+	deadline := time.After(time.Second)
+	for {
+		mu.Lock()
+		n := len(received)
+		mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("receiver only saw %d/2 events before timeout", n)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if got := o.Metrics().Sent; got != 2 {
+		t.Errorf("Metrics().Sent = %d, want 2", got)
+	}
+}
+
+func TestWebhookObserver_QueueDropsOldestUnderLoad(t *testing.T) {
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// MaxBatch 1 makes the first event's POST hang on block, so the
+	// consumer stops draining and the queue of 4 overflows.
+	o := NewWebhookObserver(WebhookObserverConfig{
+		URL:           server.URL,
+		QueueCapacity: 4,
+		MaxBatch:      1,
+		FlushInterval: time.Hour,
+	})
+	defer o.Close()
+	defer close(block)
+
+	for i := 0; i < 20; i++ {
+		o.Notify(AuditEvent{Timestamp: int64(i)})
+	}
+
+	if got := o.Metrics().Dropped; got == 0 {
+		t.Error("Metrics().Dropped = 0, want some events dropped once the bounded queue filled up")
+	}
+}
+
+func TestJSONMarshaler_RoundTrips(t *testing.T) {
+	events := []AuditEvent{{Timestamp: 1, Metrics: []string{"m"}, IPAddress: "1.2.3.4"}}
+
+	payload, contentType, err := JSONMarshaler{}.Marshal(events)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if contentType != "application/json" {
+		t.Errorf("contentType = %q, want application/json", contentType)
+	}
+
+	var got []AuditEvent
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(got) != 1 || got[0].IPAddress != "1.2.3.4" {
+		t.Errorf("got %+v, want one event with IPAddress 1.2.3.4", got)
+	}
+}
+
+func TestCloudEventsMarshaler_WrapsEachEvent(t *testing.T) {
+	events := []AuditEvent{{Timestamp: 1, IPAddress: "1.2.3.4"}, {Timestamp: 2, IPAddress: "5.6.7.8"}}
+
+	payload, contentType, err := CloudEventsMarshaler{Source: "test"}.Marshal(events)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if contentType != "application/cloudevents-batch+json" {
+		t.Errorf("contentType = %q, want application/cloudevents-batch+json", contentType)
+	}
+
+	var got []map[string]interface{}
+	if err := json.Unmarshal(payload, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d wrapped events, want 2", len(got))
+	}
+	if got[0]["specversion"] != "1.0" || got[0]["source"] != "test" {
+		t.Errorf("got[0] = %+v, want specversion 1.0 and source test", got[0])
+	}
+}
@@ -2,9 +2,9 @@ package config
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 )
 
@@ -38,43 +38,6 @@ func LoadConfigFile(path string, cfg interface{}) error {
 	return nil
 }
 
-// parseDuration parses duration string with optional 's' suffix.
-//
-// The function accepts strings like "10", "10s" and returns the integer value.
-//
-// Parameters:
-//   - s: Duration string to parse
-//
-// Returns:
-//   - int: Duration in seconds
-//   - error: An error if parsing fails
-//
-// Example:
-//
-//	duration, err := config.ParseDuration("10s")
-//	if err != nil {
-//	    log.Fatal(err)
-//	}
-func ParseDuration(s string) (int, error) {
-	if s == "" {
-		return 0, errors.New("empty duration")
-	}
-
-	// Remove 's' suffix if present
-	s = strings.TrimSuffix(s, "s")
-
-	var duration int
-	if _, err := fmt.Sscanf(s, "%d", &duration); err != nil {
-		return 0, fmt.Errorf("invalid duration format: %w", err)
-	}
-
-	if duration <= 0 {
-		return 0, fmt.Errorf("duration must be positive, got %d", duration)
-	}
-
-	return duration, nil
-}
-
 // GetConfigFilePath returns the path to the configuration file from the flag or CONFIG environment variable.
 //
 // This function checks if a config file path was provided via the configFlag parameter.
@@ -99,51 +62,39 @@ func GetConfigFilePath(configFlag string) string {
 	return os.Getenv("CONFIG")
 }
 
-// ApplyStringIfDefault applies string value from JSON config only if current value equals default.
+// GetConfigFormat returns the decoder format to use for the config file at
+// path: formatFlag if set, else the CONFIG_FORMAT environment variable if
+// set, else a format inferred from path's extension. Recognized formats are
+// "json", "yaml", and "toml"; an unrecognized or missing extension falls
+// back to "json".
 //
 // Parameters:
-//   - current: Pointer to current config value
-//   - defaultValue: Default value to compare against
-//   - jsonValue: Value from JSON config
+//   - formatFlag: The value from the config-format flag (-config-format)
+//   - path: The config file path, used to infer the format when formatFlag
+//     and CONFIG_FORMAT are both unset
 //
-// Example:
-//
-//	ApplyStringIfDefault(&cfg.Address, "localhost:8080", jsonCfg.Address)
-func ApplyStringIfDefault(current *string, defaultValue, jsonValue string) {
-	if jsonValue != "" && *current == defaultValue {
-		*current = jsonValue
-	}
-}
-
-// ApplyDurationIfDefault parses and applies duration from JSON config only if current value equals default.
-//
-// Parameters:
-//   - current: Pointer to current config value
-//   - defaultValue: Default value to compare against
-//   - jsonValue: Duration string from JSON config
+// Returns:
+//   - string: One of "json", "yaml", or "toml"
 //
 // Example:
 //
-//	ApplyDurationIfDefault(&cfg.PollInterval, 2, jsonCfg.PollInterval)
-func ApplyDurationIfDefault(current *int, defaultValue int, jsonValue string) {
-	if jsonValue != "" && *current == defaultValue {
-		if duration, err := ParseDuration(jsonValue); err == nil {
-			*current = duration
-		}
+//	format := config.GetConfigFormat("", "config.yaml")
+//	// format == "yaml"
+func GetConfigFormat(formatFlag, path string) string {
+	format := formatFlag
+	if format == "" {
+		format = os.Getenv("CONFIG_FORMAT")
+	}
+	if format != "" {
+		return format
 	}
-}
 
-// ApplyBoolIfDefault applies boolean value from JSON config only if current value is false and JSON value is true.
-//
-// Parameters:
-//   - current: Pointer to current config value
-//   - jsonValue: Boolean value from JSON config
-//
-// Example:
-//
-//	ApplyBoolIfDefault(&cfg.Restore, jsonCfg.Restore)
-func ApplyBoolIfDefault(current *bool, jsonValue bool) {
-	if jsonValue && !*current {
-		*current = jsonValue
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	default:
+		return "json"
 	}
 }
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// durationType lets field-walking code tell a time.Duration field (which
+// reflect otherwise reports as a plain Int64) apart from a real int64
+// field, so duration values parse uniformly through time.ParseDuration
+// ("10s", "2m", ...) instead of as bare numbers.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Loader walks a config struct's `default`, `json`, `env`, `flag`, and
+// `usage` struct tags and merges values from defaults, an optional config
+// file (JSON, YAML, or TOML - see GetConfigFormat), environment variables,
+// and command-line flags, in that strict priority order: flags beat env,
+// env beats the file, the file beats the struct tag default. Each source's
+// presence is tracked independently (a flag via flag.FlagSet.Visit, the
+// file via whether its key is present once decoded, env via
+// os.LookupEnv's second result), so a flag or env value that happens to
+// equal the default still wins over a file value - unlike the "apply file
+// value only if the field still equals its default" trick this replaces,
+// which silently discarded exactly that case.
+//
+// Supported field kinds are string, bool, int, int64, and time.Duration.
+// A `flag` tag may list comma-separated aliases (e.g. `flag:"c,config"`)
+// that all write to the same field.
+type Loader struct {
+	// FlagSet flags are registered against. A nil FlagSet is replaced with
+	// a fresh flag.NewFlagSet(os.Args[0], flag.ContinueOnError), so
+	// repeated Load/ParseFlags calls - e.g. from table-driven tests - never
+	// collide with each other or with flag.CommandLine.
+	FlagSet *flag.FlagSet
+}
+
+// ParseFlags applies every tagged field's `default` value, registers every
+// `flag`-tagged field on l.FlagSet, and parses args. It returns the set of
+// field names a flag explicitly set in args, keyed by Go field name -
+// callers use this to resolve a field Load's file/env layers depend on
+// (typically a JSON config path) before calling Load, and Load uses it to
+// avoid letting a file or env value clobber a field a flag already set.
+func (l *Loader) ParseFlags(cfg interface{}, args []string) (map[string]bool, error) {
+	elem, err := structValue(cfg)
+	if err != nil {
+		return nil, err
+	}
+	typ := elem.Type()
+
+	if err := applyDefaults(elem, typ); err != nil {
+		return nil, err
+	}
+
+	fs := l.FlagSet
+	if fs == nil {
+		fs = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	}
+
+	aliasField := make(map[string]string, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		names, ok := sf.Tag.Lookup("flag")
+		if !ok {
+			continue
+		}
+		field := elem.Field(i)
+		usage := sf.Tag.Get("usage")
+		for _, name := range strings.Split(names, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if err := registerFlag(fs, field, name, usage); err != nil {
+				return nil, fmt.Errorf("config: flag -%s for field %s: %w", name, sf.Name, err)
+			}
+			aliasField[name] = sf.Name
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	visited := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		if field, ok := aliasField[f.Name]; ok {
+			visited[field] = true
+		}
+	})
+	return visited, nil
+}
+
+// Load applies configPath's fields (skipped entirely when configPath is "")
+// and then environment variables onto cfg, in that order, skipping any
+// field whose name is in visited - fields an explicit flag already set in
+// a prior ParseFlags call. format selects the decoder for configPath - one
+// of "json", "yaml", or "toml", typically from GetConfigFormat - and is
+// ignored when configPath is "". Load does not touch defaults or flags
+// itself; call ParseFlags first.
+func (l *Loader) Load(cfg interface{}, configPath, format string, visited map[string]bool) error {
+	elem, err := structValue(cfg)
+	if err != nil {
+		return err
+	}
+	typ := elem.Type()
+
+	if configPath != "" {
+		if err := applyConfigFile(elem, typ, configPath, format, visited); err != nil {
+			return err
+		}
+	}
+
+	return applyEnv(elem, typ, visited)
+}
+
+func structValue(cfg interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("config: expected a pointer to a struct, got %T", cfg)
+	}
+	return v.Elem(), nil
+}
+
+func applyDefaults(elem reflect.Value, typ reflect.Type) error {
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		def, ok := sf.Tag.Lookup("default")
+		if !ok {
+			continue
+		}
+		if err := setFieldFromString(elem.Field(i), def); err != nil {
+			return fmt.Errorf("config: default for field %s: %w", sf.Name, err)
+		}
+	}
+	return nil
+}
+
+func applyConfigFile(elem reflect.Value, typ reflect.Type, path, format string, visited map[string]bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	raw, err := decodeConfigFile(data, format)
+	if err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if visited[sf.Name] {
+			continue
+		}
+		key, ok := sf.Tag.Lookup("json")
+		if !ok {
+			continue
+		}
+		msg, present := raw[key]
+		if !present {
+			continue
+		}
+
+		field := elem.Field(i)
+		if field.Type() == durationType {
+			var s string
+			if err := json.Unmarshal(msg, &s); err != nil {
+				return fmt.Errorf("config: field %s: %w", sf.Name, err)
+			}
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fmt.Errorf("config: field %s: %w", sf.Name, err)
+			}
+			field.SetInt(int64(d))
+			continue
+		}
+		if err := json.Unmarshal(msg, field.Addr().Interface()); err != nil {
+			return fmt.Errorf("config: field %s: %w", sf.Name, err)
+		}
+	}
+	return nil
+}
+
+// decodeConfigFile decodes data into a map of JSON tag name to raw JSON
+// value, regardless of the on-disk format: YAML and TOML are decoded into a
+// plain map first and re-marshaled to JSON, so the rest of the loader only
+// ever deals with json.RawMessage.
+func decodeConfigFile(data []byte, format string) (map[string]json.RawMessage, error) {
+	switch format {
+	case "", "json":
+		var raw map[string]json.RawMessage
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+		return raw, nil
+	case "yaml":
+		var generic map[string]interface{}
+		if err := yaml.Unmarshal(data, &generic); err != nil {
+			return nil, err
+		}
+		return reencodeAsJSON(generic)
+	case "toml":
+		var generic map[string]interface{}
+		if _, err := toml.Decode(string(data), &generic); err != nil {
+			return nil, err
+		}
+		return reencodeAsJSON(generic)
+	default:
+		return nil, fmt.Errorf("unknown config format %q: want json, yaml, or toml", format)
+	}
+}
+
+func reencodeAsJSON(generic map[string]interface{}) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(generic)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+func applyEnv(elem reflect.Value, typ reflect.Type, visited map[string]bool) error {
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		if visited[sf.Name] {
+			continue
+		}
+		name, ok := sf.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+		raw, present := os.LookupEnv(name)
+		if !present {
+			continue
+		}
+		if err := setFieldFromString(elem.Field(i), raw); err != nil {
+			return fmt.Errorf("config: env %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// registerFlag registers field on fs under name, using field's current
+// value (already populated by applyDefaults) as the flag's default so an
+// unpassed flag leaves field untouched.
+func registerFlag(fs *flag.FlagSet, field reflect.Value, name, usage string) error {
+	switch {
+	case field.Type() == durationType:
+		fs.DurationVar(field.Addr().Interface().(*time.Duration), name, time.Duration(field.Int()), usage)
+	case field.Kind() == reflect.String:
+		fs.StringVar(field.Addr().Interface().(*string), name, field.String(), usage)
+	case field.Kind() == reflect.Bool:
+		fs.BoolVar(field.Addr().Interface().(*bool), name, field.Bool(), usage)
+	case field.Kind() == reflect.Int:
+		fs.IntVar(field.Addr().Interface().(*int), name, int(field.Int()), usage)
+	case field.Kind() == reflect.Int64:
+		fs.Int64Var(field.Addr().Interface().(*int64), name, field.Int(), usage)
+	default:
+		return fmt.Errorf("unsupported flag field kind %s", field.Kind())
+	}
+	return nil
+}
+
+// setFieldFromString parses raw into field per its kind, used for both
+// `default` tags and env var values, which arrive as plain strings either
+// way.
+func setFieldFromString(field reflect.Value, raw string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	default:
+		return fmt.Errorf("unsupported field kind %s", field.Kind())
+	}
+	return nil
+}
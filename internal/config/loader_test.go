@@ -0,0 +1,227 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Address  string        `json:"address" env:"TEST_ADDRESS" flag:"a" default:"localhost:8080" usage:"address"`
+	Interval time.Duration `json:"interval" env:"TEST_INTERVAL" flag:"i" default:"2s" usage:"interval"`
+	Restore  bool          `json:"restore" env:"TEST_RESTORE" flag:"r" default:"false" usage:"restore"`
+}
+
+func writeJSONConfig(t *testing.T, body string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func writeConfigFile(t *testing.T, name, body string) string {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	return path
+}
+
+func TestLoaderPrecedence(t *testing.T) {
+	tests := []struct {
+		name         string
+		jsonBody     string
+		env          map[string]string
+		args         []string
+		wantAddress  string
+		wantInterval time.Duration
+		wantRestore  bool
+	}{
+		{
+			name:         "defaults only",
+			wantAddress:  "localhost:8080",
+			wantInterval: 2 * time.Second,
+			wantRestore:  false,
+		},
+		{
+			name:         "file overrides defaults",
+			jsonBody:     `{"address":"file:9000","interval":"5s","restore":true}`,
+			wantAddress:  "file:9000",
+			wantInterval: 5 * time.Second,
+			wantRestore:  true,
+		},
+		{
+			name:         "env overrides file",
+			jsonBody:     `{"address":"file:9000","interval":"5s","restore":true}`,
+			env:          map[string]string{"TEST_ADDRESS": "env:9001", "TEST_INTERVAL": "7s"},
+			wantAddress:  "env:9001",
+			wantInterval: 7 * time.Second,
+			wantRestore:  true,
+		},
+		{
+			name:         "flag overrides env",
+			jsonBody:     `{"address":"file:9000","interval":"5s","restore":true}`,
+			env:          map[string]string{"TEST_ADDRESS": "env:9001"},
+			args:         []string{"-a", "flag:9002"},
+			wantAddress:  "flag:9002",
+			wantInterval: 5 * time.Second,
+			wantRestore:  true,
+		},
+		{
+			name:         "flag value equal to default still wins over file",
+			jsonBody:     `{"address":"file:9000"}`,
+			args:         []string{"-a", "localhost:8080"},
+			wantAddress:  "localhost:8080",
+			wantInterval: 2 * time.Second,
+			wantRestore:  false,
+		},
+		{
+			name:         "env value equal to default still wins over file",
+			jsonBody:     `{"restore":true}`,
+			env:          map[string]string{"TEST_RESTORE": "false"},
+			wantAddress:  "localhost:8080",
+			wantInterval: 2 * time.Second,
+			wantRestore:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			var jsonPath string
+			if tt.jsonBody != "" {
+				jsonPath = writeJSONConfig(t, tt.jsonBody)
+			}
+
+			cfg := &testConfig{}
+			loader := &Loader{}
+
+			visited, err := loader.ParseFlags(cfg, tt.args)
+			if err != nil {
+				t.Fatalf("ParseFlags: %v", err)
+			}
+			if err := loader.Load(cfg, jsonPath, "json", visited); err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			if cfg.Address != tt.wantAddress {
+				t.Errorf("Address = %q, want %q", cfg.Address, tt.wantAddress)
+			}
+			if cfg.Interval != tt.wantInterval {
+				t.Errorf("Interval = %s, want %s", cfg.Interval, tt.wantInterval)
+			}
+			if cfg.Restore != tt.wantRestore {
+				t.Errorf("Restore = %v, want %v", cfg.Restore, tt.wantRestore)
+			}
+		})
+	}
+}
+
+func TestLoaderRejectsNonStructPointer(t *testing.T) {
+	loader := &Loader{}
+	if _, err := loader.ParseFlags(testConfig{}, nil); err == nil {
+		t.Error("ParseFlags with non-pointer cfg: expected error, got nil")
+	}
+}
+
+func TestLoaderFileFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		body     string
+		format   string
+	}{
+		{
+			name:     "json by extension",
+			fileName: "config.json",
+			body:     `{"address":"json:9000"}`,
+		},
+		{
+			name:     "yaml by extension",
+			fileName: "config.yaml",
+			body:     "address: yaml:9000\n",
+		},
+		{
+			name:     "yml by extension",
+			fileName: "config.yml",
+			body:     "address: yaml:9000\n",
+		},
+		{
+			name:     "toml by extension",
+			fileName: "config.toml",
+			body:     "address = \"toml:9000\"\n",
+		},
+		{
+			name:     "explicit format overrides extension",
+			fileName: "config.json",
+			body:     "address: yaml:9000\n",
+			format:   "yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfigFile(t, tt.fileName, tt.body)
+
+			cfg := &testConfig{}
+			loader := &Loader{}
+
+			visited, err := loader.ParseFlags(cfg, nil)
+			if err != nil {
+				t.Fatalf("ParseFlags: %v", err)
+			}
+
+			format := GetConfigFormat(tt.format, path)
+			if err := loader.Load(cfg, path, format, visited); err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+
+			const want = "yaml:9000"
+			wantAddress := want
+			if tt.format == "" && filepath.Ext(tt.fileName) == ".json" {
+				wantAddress = "json:9000"
+			}
+			if cfg.Address != wantAddress {
+				t.Errorf("Address = %q, want %q", cfg.Address, wantAddress)
+			}
+		})
+	}
+}
+
+func TestGetConfigFormat(t *testing.T) {
+	tests := []struct {
+		name       string
+		formatFlag string
+		envFormat  string
+		path       string
+		want       string
+	}{
+		{name: "explicit flag wins", formatFlag: "toml", path: "config.yaml", want: "toml"},
+		{name: "env used when flag unset", envFormat: "yaml", path: "config.json", want: "yaml"},
+		{name: "yaml extension", path: "config.yaml", want: "yaml"},
+		{name: "yml extension", path: "config.yml", want: "yaml"},
+		{name: "toml extension", path: "config.toml", want: "toml"},
+		{name: "unknown extension defaults to json", path: "config.ini", want: "json"},
+		{name: "no extension defaults to json", path: "config", want: "json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.envFormat != "" {
+				t.Setenv("CONFIG_FORMAT", tt.envFormat)
+			}
+			if got := GetConfigFormat(tt.formatFlag, tt.path); got != tt.want {
+				t.Errorf("GetConfigFormat(%q, %q) = %q, want %q", tt.formatFlag, tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// MarshalMasked JSON-marshals cfg's tagged fields - the same shape Load
+// merges into - with any field tagged `secret:"true"` replaced by a fixed
+// "***" placeholder, for a -print-config flag that dumps the effective
+// configuration without leaking keys or tokens.
+func MarshalMasked(cfg interface{}) ([]byte, error) {
+	elem, err := structValue(cfg)
+	if err != nil {
+		return nil, err
+	}
+	typ := elem.Type()
+
+	out := make(map[string]interface{}, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		key, ok := fieldKey(sf)
+		if !ok {
+			continue
+		}
+
+		field := elem.Field(i)
+		if sf.Tag.Get("secret") == "true" && field.Kind() == reflect.String && field.String() != "" {
+			out[key] = "***"
+			continue
+		}
+		if field.Type() == durationType {
+			out[key] = field.Interface().(time.Duration).String()
+			continue
+		}
+		out[key] = field.Interface()
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// fieldKey returns the name MarshalMasked should print a field under: its
+// `json` tag if set, else its `env` tag, else its Go field name for a
+// field that's only ever set via flag or default - so every tagged field
+// shows up in -print-config output even if it has no JSON counterpart.
+func fieldKey(sf reflect.StructField) (string, bool) {
+	if key, ok := sf.Tag.Lookup("json"); ok {
+		return key, true
+	}
+	if key, ok := sf.Tag.Lookup("env"); ok {
+		return key, true
+	}
+	if _, ok := sf.Tag.Lookup("flag"); ok {
+		return sf.Name, true
+	}
+	return "", false
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type printTestConfig struct {
+	Address  string        `json:"address"`
+	Key      string        `env:"KEY" secret:"true"`
+	Empty    string        `env:"EMPTY" secret:"true"`
+	Interval time.Duration `json:"interval"`
+}
+
+func TestMarshalMaskedMasksSecretFields(t *testing.T) {
+	cfg := printTestConfig{Address: "localhost:8080", Key: "super-secret", Interval: 3 * time.Second}
+
+	data, err := MarshalMasked(&cfg)
+	if err != nil {
+		t.Fatalf("MarshalMasked: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if decoded["address"] != "localhost:8080" {
+		t.Errorf("address = %v, want unmasked value", decoded["address"])
+	}
+	if decoded["KEY"] != "***" {
+		t.Errorf("KEY = %v, want masked ***", decoded["KEY"])
+	}
+	if decoded["EMPTY"] != "" {
+		t.Errorf("EMPTY = %v, want empty string left unmasked", decoded["EMPTY"])
+	}
+	if decoded["interval"] != "3s" {
+		t.Errorf("interval = %v, want \"3s\"", decoded["interval"])
+	}
+}
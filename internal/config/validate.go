@@ -0,0 +1,63 @@
+package config
+
+import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ValidateCIDR reports an error if cidr isn't valid CIDR notation (e.g.
+// "192.168.1.0/24"), for validating a TrustedSubnet field.
+func ValidateCIDR(cidr string) error {
+	if _, _, err := net.ParseCIDR(cidr); err != nil {
+		return fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+	}
+	return nil
+}
+
+// ValidatePEMFile reports an error if path doesn't exist or doesn't contain
+// at least one PEM block, for validating a CryptoKey field that names a key
+// or certificate file.
+func ValidatePEMFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %q: %w", path, err)
+	}
+	if block, _ := pem.Decode(data); block == nil {
+		return fmt.Errorf("%q does not contain a valid PEM block", path)
+	}
+	return nil
+}
+
+// ValidateDSN reports an error if dsn isn't a syntactically valid
+// PostgreSQL connection string, for validating a DSN field without paying
+// the cost of a network round trip on every startup.
+func ValidateDSN(dsn string) error {
+	if _, err := pgxpool.ParseConfig(dsn); err != nil {
+		return fmt.Errorf("invalid DSN: %w", err)
+	}
+	return nil
+}
+
+// ValidateDSNReachable does everything ValidateDSN does, plus actually
+// dialing the database, for a -dry-run flag where an operator has opted
+// into paying that cost to catch network/auth problems before deploying.
+func ValidateDSNReachable(ctx context.Context, dsn string) error {
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("invalid DSN: %w", err)
+	}
+	defer pool.Close()
+
+	pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := pool.Ping(pingCtx); err != nil {
+		return fmt.Errorf("failed to reach database: %w", err)
+	}
+	return nil
+}
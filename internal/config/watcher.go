@@ -0,0 +1,273 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog/log"
+)
+
+// configWatchDebounce coalesces the burst of write/rename/create events a
+// single file save can generate (some editors write via a temp file plus
+// rename) into one reload.
+const configWatchDebounce = 200 * time.Millisecond
+
+// ConfigChange describes one top-level field that changed between two
+// reloads of a watched config file. Old and New are formatted with
+// fmt.Sprintf("%v", ...), so the same shape covers every JSONConfig's mix
+// of string, bool, and numeric fields without a subscriber needing to know
+// the concrete type. Old is empty when Field was just added, New is empty
+// when it was removed.
+type ConfigChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// WatcherOption customizes a Watcher at construction time, e.g. marking
+// fields immutable via WithImmutableFields.
+type WatcherOption func(*Watcher)
+
+// WithImmutableFields marks fields (by their JSON/YAML/TOML key, e.g.
+// "address" or "database_dsn") as immutable: a reload that finds one of
+// them changed logs "reload ignored" instead of publishing a ConfigChange
+// for it, since applying a new bind address or DSN without restarting
+// listeners would silently disagree with what's actually listening.
+func WithImmutableFields(fields ...string) WatcherOption {
+	return func(w *Watcher) {
+		for _, f := range fields {
+			w.immutableFields[f] = true
+		}
+	}
+}
+
+// Watcher watches a JSON, YAML, or TOML config file for changes (see
+// GetConfigFormat), debounces the burst of filesystem events a single save
+// produces, reacts immediately to SIGHUP, and publishes a ConfigChange per
+// top-level field that differs from the previously loaded version on its
+// Changes channel. Subscribers in the gRPC server, the zerolog global
+// logger, and the agent worker pool read from Changes to apply fields that
+// are safe to reload without a process restart; fields named via
+// WithImmutableFields are logged as ignored instead of published.
+type Watcher struct {
+	path   string
+	format string
+
+	mu      sync.Mutex
+	current map[string]any
+
+	immutableFields map[string]bool
+
+	changes chan ConfigChange
+	fsw     *fsnotify.Watcher
+}
+
+// NewWatcher opens path (typically config.GetConfigFilePath's result),
+// takes its initial snapshot, and starts watching its parent directory for
+// changes - watching the directory, not the file itself, survives editors
+// that replace the file via rename instead of writing it in place. Run also
+// reloads on SIGHUP regardless of filesystem events, so operators can force
+// a reload (e.g. after only an environment variable changed) without
+// touching the file.
+func NewWatcher(path string, opts ...WatcherOption) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config watcher: %w", err)
+	}
+
+	w := &Watcher{
+		path:            path,
+		format:          GetConfigFormat("", path),
+		changes:         make(chan ConfigChange, 16),
+		fsw:             fsw,
+		immutableFields: make(map[string]bool),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	current, err := readConfigSnapshot(path, w.format)
+	if err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	w.current = current
+
+	dir := filepath.Dir(path)
+	if err := fsw.Add(dir); err != nil {
+		fsw.Close()
+		return nil, fmt.Errorf("failed to watch config directory %q: %w", dir, err)
+	}
+
+	return w, nil
+}
+
+// Changes returns the channel ConfigChange events are published on.
+func (w *Watcher) Changes() <-chan ConfigChange {
+	return w.changes
+}
+
+// Run watches for filesystem events until ctx is canceled, debouncing
+// bursts into a single reload. Run blocks, so callers typically invoke it
+// with `go w.Run(ctx)`.
+func (w *Watcher) Run(ctx context.Context) {
+	defer w.fsw.Close()
+
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	reload := make(chan struct{}, 1)
+	var debounce *time.Timer
+
+	scheduleReload := func() {
+		if debounce == nil {
+			debounce = time.AfterFunc(configWatchDebounce, func() {
+				select {
+				case reload <- struct{}{}:
+				default:
+				}
+			})
+			return
+		}
+		debounce.Reset(configWatchDebounce)
+	}
+
+	for {
+		select {
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != filepath.Clean(w.path) {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+				continue
+			}
+			scheduleReload()
+		case <-sighup:
+			log.Info().Str("path", w.path).Msg("Received SIGHUP, reloading config")
+			w.reload()
+		case <-reload:
+			w.reload()
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			log.Warn().Err(err).Str("path", w.path).Msg("Config watcher error")
+		case <-ctx.Done():
+			if debounce != nil {
+				debounce.Stop()
+			}
+			return
+		}
+	}
+}
+
+// Close stops watching and releases the underlying filesystem watcher. Run
+// already closes it when ctx is canceled; Close is for callers that set up
+// a Watcher but never call Run.
+func (w *Watcher) Close() error {
+	return w.fsw.Close()
+}
+
+// reload re-reads the watched file, diffs it against the last snapshot, and
+// publishes a ConfigChange per differing field - except fields named via
+// WithImmutableFields, which are logged as ignored instead. A file that
+// fails to read or parse is logged and otherwise ignored, leaving the
+// previous snapshot and subscribers' live values untouched.
+func (w *Watcher) reload() {
+	next, err := readConfigSnapshot(w.path, w.format)
+	if err != nil {
+		log.Warn().Err(err).Str("path", w.path).Msg("Failed to reload config file, keeping previous values")
+		return
+	}
+
+	w.mu.Lock()
+	prev := w.current
+	w.current = next
+	w.mu.Unlock()
+
+	for _, change := range diffConfigFields(prev, next) {
+		if w.immutableFields[change.Field] {
+			log.Warn().Str("field", change.Field).Str("old", change.Old).Str("new", change.New).
+				Msg("Config reload ignored for immutable field")
+			continue
+		}
+		select {
+		case w.changes <- change:
+		default:
+			log.Warn().Str("field", change.Field).Msg("Config watcher changes channel full, dropping change event")
+		}
+	}
+}
+
+// readConfigSnapshot reads and decodes path (in format, per GetConfigFormat)
+// into a generic field map, so Watcher can diff any binary's config shape
+// without depending on its concrete type.
+func readConfigSnapshot(path, format string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	rawFields, err := decodeConfigFile(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	snapshot := make(map[string]any, len(rawFields))
+	for key, msg := range rawFields {
+		var v any
+		if err := json.Unmarshal(msg, &v); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+		snapshot[key] = v
+	}
+	return snapshot, nil
+}
+
+// diffConfigFields reports a ConfigChange for every top-level key present
+// in either prev or next whose formatted value differs, including keys
+// that were added or removed. Values are compared as their formatted
+// strings rather than via ==, since next/prev may hold uncomparable types
+// (e.g. a nested JSON object) that would otherwise panic.
+func diffConfigFields(prev, next map[string]any) []ConfigChange {
+	seen := make(map[string]bool, len(prev)+len(next))
+	for field := range prev {
+		seen[field] = true
+	}
+	for field := range next {
+		seen[field] = true
+	}
+
+	var changes []ConfigChange
+	for field := range seen {
+		oldVal := formatConfigValue(prev[field])
+		newVal := formatConfigValue(next[field])
+		if oldVal == newVal {
+			continue
+		}
+		changes = append(changes, ConfigChange{Field: field, Old: oldVal, New: newVal})
+	}
+	return changes
+}
+
+// formatConfigValue renders a decoded JSON value the same way regardless
+// of its concrete type, so string, bool, and numeric fields all produce a
+// comparable, human-readable ConfigChange.Old/New.
+func formatConfigValue(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func writeWatcherFile(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+}
+
+func TestWatcherReloadsOnSIGHUP(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	writeWatcherFile(t, path, `{"trusted_subnet":"10.0.0.0/8"}`)
+
+	w, err := NewWatcher(path)
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	writeWatcherFile(t, path, `{"trusted_subnet":"192.168.0.0/16"}`)
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("sending SIGHUP: %v", err)
+	}
+
+	select {
+	case change := <-w.Changes():
+		if change.Field != "trusted_subnet" || change.New != "192.168.0.0/16" {
+			t.Errorf("got change %+v, want trusted_subnet -> 192.168.0.0/16", change)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reload triggered by SIGHUP")
+	}
+}
+
+func TestWatcherIgnoresImmutableFields(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	writeWatcherFile(t, path, `{"address":"localhost:8080","trusted_subnet":""}`)
+
+	w, err := NewWatcher(path, WithImmutableFields("address"))
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	writeWatcherFile(t, path, `{"address":"localhost:9090","trusted_subnet":"10.0.0.0/8"}`)
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("sending SIGHUP: %v", err)
+	}
+
+	select {
+	case change := <-w.Changes():
+		if change.Field != "trusted_subnet" {
+			t.Errorf("got change for field %q, want only trusted_subnet to be published (address is immutable)", change.Field)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for reload triggered by SIGHUP")
+	}
+
+	select {
+	case change := <-w.Changes():
+		t.Errorf("unexpected second change published: %+v", change)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestWatcherSupportsYAML(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeWatcherFile(t, path, "trusted_subnet: 10.0.0.0/8\n")
+
+	w, err := NewWatcher(path)
+	if err != nil {
+		t.Fatalf("NewWatcher: %v", err)
+	}
+	defer w.Close()
+
+	writeWatcherFile(t, path, "trusted_subnet: 192.168.0.0/16\n")
+	w.reload()
+
+	select {
+	case change := <-w.Changes():
+		if change.Field != "trusted_subnet" || change.New != "192.168.0.0/16" {
+			t.Errorf("got change %+v, want trusted_subnet -> 192.168.0.0/16", change)
+		}
+	default:
+		t.Fatal("expected a ConfigChange after reload, got none")
+	}
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/goccy/go-json"
+	"github.com/idudko/go-musthave-metrics/internal/model"
+)
+
+// AgentHeartbeatHandler records a heartbeat from an agent's MetricsService,
+// keeping it alive in the identities registry until its TTL lapses without
+// another heartbeat.
+func (h *Handler) AgentHeartbeatHandler(w http.ResponseWriter, r *http.Request) {
+	var hb model.AgentHeartbeat
+	if err := json.NewDecoder(r.Body).Decode(&hb); err != nil {
+		http.Error(w, "Invalid heartbeat payload", http.StatusBadRequest)
+		return
+	}
+
+	if hb.AgentID == "" {
+		http.Error(w, "agent_id is required", http.StatusBadRequest)
+		return
+	}
+
+	h.identities.Touch(hb)
+	w.WriteHeader(http.StatusOK)
+}
+
+// ListAgentsHandler returns the agents currently known to be alive, i.e.
+// those that have heartbeated within the identities registry's TTL.
+func (h *Handler) ListAgentsHandler(w http.ResponseWriter, r *http.Request) {
+	agents := h.identities.List()
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(agents); err != nil {
+		http.Error(w, "Failed to encode agents", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/idudko/go-musthave-metrics/internal/model"
+	"github.com/idudko/go-musthave-metrics/internal/repository"
+	"github.com/idudko/go-musthave-metrics/internal/service"
+)
+
+func TestAgentHeartbeatHandler(t *testing.T) {
+	storage := repository.NewMemStorage()
+	h := NewHandler(service.NewMetricsService(storage), "")
+
+	hb := model.AgentHeartbeat{AgentID: "agent-1", Hostname: "host-1", MetricsCount: 3}
+	body, _ := json.Marshal(hb)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/agents/heartbeat", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	h.AgentHeartbeatHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	agents := h.identities.List()
+	if len(agents) != 1 || agents[0].AgentID != "agent-1" {
+		t.Fatalf("expected agent-1 to be registered, got %+v", agents)
+	}
+}
+
+func TestAgentHeartbeatHandler_MissingAgentID(t *testing.T) {
+	storage := repository.NewMemStorage()
+	h := NewHandler(service.NewMetricsService(storage), "")
+
+	body, _ := json.Marshal(model.AgentHeartbeat{Hostname: "host-1"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/agents/heartbeat", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	h.AgentHeartbeatHandler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestListAgentsHandler(t *testing.T) {
+	storage := repository.NewMemStorage()
+	h := NewHandler(service.NewMetricsService(storage), "")
+	h.identities.Touch(model.AgentHeartbeat{AgentID: "agent-1", Hostname: "host-1"})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/agents", nil)
+	w := httptest.NewRecorder()
+
+	h.ListAgentsHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var agents []model.AgentHeartbeat
+	if err := json.Unmarshal(w.Body.Bytes(), &agents); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(agents) != 1 || agents[0].AgentID != "agent-1" {
+		t.Fatalf("expected agent-1 in response, got %+v", agents)
+	}
+}
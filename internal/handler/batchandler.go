@@ -20,6 +20,7 @@ func (h *Handler) UpdateMetricsBatchHandler(w http.ResponseWriter, r *http.Reque
 	}
 
 	if len(metrics) == 0 {
+		h.signResponse(w, nil)
 		w.WriteHeader(http.StatusOK)
 		return
 	}
@@ -52,5 +53,6 @@ func (h *Handler) UpdateMetricsBatchHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	h.signResponse(w, nil)
 	w.WriteHeader(http.StatusOK)
 }
@@ -0,0 +1,84 @@
+package ingest
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/idudko/go-musthave-metrics/internal/model"
+)
+
+// datadogSeries mirrors one entry of the Datadog v1 "series" payload:
+// https://docs.datadoghq.com/api/latest/metrics/#submit-metrics.
+// Points is a list of [timestamp, value] pairs; Tags are "key:value"
+// strings rather than a map.
+type datadogSeries struct {
+	Metric string       `json:"metric"`
+	Points [][2]float64 `json:"points"`
+	Type   string       `json:"type"`
+	Tags   []string     `json:"tags"`
+}
+
+type datadogPayload struct {
+	Series []datadogSeries `json:"series"`
+}
+
+// DatadogDecoder decodes the Datadog v1 "series" submit-metrics payload.
+// Series of type "gauge" become Gauge snapshots (one per point); type
+// "count" becomes Counter deltas. Any other type is rejected, matching the
+// two types the v1 API itself documents.
+type DatadogDecoder struct{}
+
+func (DatadogDecoder) Decode(r io.Reader) ([]model.Metrics, error) {
+	var payload datadogPayload
+	if err := json.NewDecoder(r).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("invalid Datadog payload: %w", err)
+	}
+
+	var metrics []model.Metrics
+	for _, series := range payload.Series {
+		if series.Metric == "" {
+			return nil, fmt.Errorf("series is missing required field %q", "metric")
+		}
+
+		id := labelID(series.Metric, datadogTags(series.Tags))
+
+		switch series.Type {
+		case "gauge":
+			for _, point := range series.Points {
+				value := point[1]
+				metrics = append(metrics, model.Metrics{ID: id, MType: model.Gauge, Value: &value})
+			}
+		case "count":
+			for _, point := range series.Points {
+				delta := int64(point[1])
+				metrics = append(metrics, model.Metrics{ID: id, MType: model.Counter, Delta: &delta})
+			}
+		default:
+			return nil, fmt.Errorf("unsupported Datadog metric type %q", series.Type)
+		}
+	}
+
+	return metrics, nil
+}
+
+// datadogTags turns Datadog's "key:value" tag strings into the map labelID
+// expects. A tag without a ":" is kept as a boolean-style tag mapped to
+// itself, e.g. "production" -> {"production": "production"}.
+func datadogTags(tags []string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	out := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		k, v, ok := strings.Cut(tag, ":")
+		if !ok {
+			out[k] = k
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,74 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDatadogDecoder_Gauge(t *testing.T) {
+	body := `{"series":[{"metric":"system.cpu.idle","points":[[1609459200,50.5]],"type":"gauge","tags":["host:web01"]}]}`
+
+	metrics, err := DatadogDecoder{}.Decode(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("got %d metrics, want 1", len(metrics))
+	}
+
+	m := metrics[0]
+	if m.ID != "system.cpu.idle{host=web01}" {
+		t.Errorf("ID = %q, want %q", m.ID, "system.cpu.idle{host=web01}")
+	}
+	if m.Value == nil || *m.Value != 50.5 {
+		t.Errorf("Value = %v, want 50.5", m.Value)
+	}
+}
+
+func TestDatadogDecoder_Count(t *testing.T) {
+	body := `{"series":[{"metric":"requests.total","points":[[1609459200,7],[1609459260,3]],"type":"count"}]}`
+
+	metrics, err := DatadogDecoder{}.Decode(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("got %d metrics, want 2", len(metrics))
+	}
+	if metrics[0].Delta == nil || *metrics[0].Delta != 7 {
+		t.Errorf("first Delta = %v, want 7", metrics[0].Delta)
+	}
+	if metrics[1].Delta == nil || *metrics[1].Delta != 3 {
+		t.Errorf("second Delta = %v, want 3", metrics[1].Delta)
+	}
+}
+
+func TestDatadogDecoder_UnsupportedType(t *testing.T) {
+	body := `{"series":[{"metric":"foo","points":[[1,1]],"type":"rate"}]}`
+
+	if _, err := (DatadogDecoder{}).Decode(strings.NewReader(body)); err == nil {
+		t.Error("expected an error for an unsupported metric type")
+	}
+}
+
+func TestDatadogDecoder_MissingMetricName(t *testing.T) {
+	body := `{"series":[{"points":[[1,1]],"type":"gauge"}]}`
+
+	if _, err := (DatadogDecoder{}).Decode(strings.NewReader(body)); err == nil {
+		t.Error("expected an error for a series missing its metric name")
+	}
+}
+
+func TestDatadogTags(t *testing.T) {
+	got := datadogTags([]string{"host:web01", "production"})
+	want := map[string]string{"host": "web01", "production": "production"}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d tags, want %d", len(got), len(want))
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("tag %q = %q, want %q", k, got[k], v)
+		}
+	}
+}
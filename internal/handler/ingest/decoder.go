@@ -0,0 +1,42 @@
+// Package ingest translates third-party metric wire formats into
+// model.Metrics, so the server can sit in as a drop-in target for agents
+// that already speak OpenTSDB, Datadog, or Graphite instead of this
+// project's own HTTP/gRPC protocol.
+package ingest
+
+import (
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/idudko/go-musthave-metrics/internal/model"
+)
+
+// Decoder parses a wire format from r into model.Metrics, so NewHandler can
+// wire any Decoder up to an HTTP endpoint without caring which third-party
+// protocol it speaks.
+type Decoder interface {
+	Decode(r io.Reader) ([]model.Metrics, error)
+}
+
+// labelID folds name and tags (sorted by key for a stable, deterministic
+// result) into a single Metrics ID, e.g. "name{k=v,...}" - mirroring how
+// handler.RemoteWriteHandler builds an ID from Prometheus labels.
+func labelID(name string, tags map[string]string) string {
+	if len(tags) == 0 {
+		return name
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + tags[k]
+	}
+
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
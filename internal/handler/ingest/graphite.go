@@ -0,0 +1,100 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/idudko/go-musthave-metrics/internal/model"
+	"github.com/idudko/go-musthave-metrics/internal/service"
+	"github.com/rs/zerolog/log"
+)
+
+// ParseGraphiteLine decodes a single Graphite plaintext protocol line,
+// "name value timestamp" (the timestamp is accepted but not used, since
+// MetricsService has no notion of a historical sample time). Every line
+// becomes a Gauge - Graphite's plaintext protocol carries no
+// counter/gauge distinction of its own.
+func ParseGraphiteLine(line string) (model.Metrics, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return model.Metrics{}, fmt.Errorf("expected 3 fields (name value timestamp), got %d", len(fields))
+	}
+
+	value, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return model.Metrics{}, fmt.Errorf("invalid value %q: %w", fields[1], err)
+	}
+
+	return model.Metrics{ID: fields[0], MType: model.Gauge, Value: &value}, nil
+}
+
+// GraphiteListener runs a TCP server speaking the Graphite plaintext
+// protocol, so operators can point a Carbon-compatible agent (e.g.
+// Telegraf's graphite output) at this server without a translation shim.
+// Each accepted connection is read line by line until EOF or a parse
+// error; malformed lines close the connection rather than desyncing the
+// stream.
+type GraphiteListener struct {
+	metricsService *service.MetricsService
+}
+
+// NewGraphiteListener creates a GraphiteListener that routes every decoded
+// line through metricsService's batch update path.
+func NewGraphiteListener(metricsService *service.MetricsService) *GraphiteListener {
+	return &GraphiteListener{metricsService: metricsService}
+}
+
+// Serve accepts connections on addr until ctx is cancelled, blocking until
+// the listener is closed.
+func (g *GraphiteListener) Serve(ctx context.Context, addr string) error {
+	lc := net.ListenConfig{}
+	ln, err := lc.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			select {
+			case <-ctx.Done():
+				return nil
+			default:
+				return fmt.Errorf("failed to accept connection: %w", err)
+			}
+		}
+		go g.handleConn(ctx, conn)
+	}
+}
+
+func (g *GraphiteListener) handleConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		metric, err := ParseGraphiteLine(line)
+		if err != nil {
+			log.Warn().Err(err).Str("line", line).Msg("discarding malformed Graphite line")
+			return
+		}
+
+		if err := g.metricsService.UpdateMetricsBatch(ctx, []model.Metrics{metric}); err != nil {
+			log.Error().Err(err).Str("metric", metric.ID).Msg("failed to update metric from Graphite line")
+			return
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/idudko/go-musthave-metrics/internal/repository"
+	"github.com/idudko/go-musthave-metrics/internal/service"
+)
+
+func TestParseGraphiteLine(t *testing.T) {
+	metric, err := ParseGraphiteLine("servers.web01.cpu 42.5 1609459200")
+	if err != nil {
+		t.Fatalf("ParseGraphiteLine failed: %v", err)
+	}
+	if metric.ID != "servers.web01.cpu" {
+		t.Errorf("ID = %q, want %q", metric.ID, "servers.web01.cpu")
+	}
+	if metric.Value == nil || *metric.Value != 42.5 {
+		t.Errorf("Value = %v, want 42.5", metric.Value)
+	}
+}
+
+func TestParseGraphiteLine_InvalidFieldCount(t *testing.T) {
+	if _, err := ParseGraphiteLine("servers.web01.cpu 42.5"); err == nil {
+		t.Error("expected an error for a line missing its timestamp")
+	}
+}
+
+func TestParseGraphiteLine_InvalidValue(t *testing.T) {
+	if _, err := ParseGraphiteLine("servers.web01.cpu notanumber 1609459200"); err == nil {
+		t.Error("expected an error for a non-numeric value")
+	}
+}
+
+func TestGraphiteListener_ServeAndUpdatesStorage(t *testing.T) {
+	storage := repository.NewMemStorage()
+	metricsService := service.NewMetricsService(storage)
+	listener := NewGraphiteListener(metricsService)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to reserve a port: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- listener.Serve(ctx, addr) }()
+
+	var conn net.Conn
+	for i := 0; i < 50; i++ {
+		conn, err = net.Dial("tcp", addr)
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("failed to dial Graphite listener: %v", err)
+	}
+
+	fmt.Fprintf(conn, "servers.web01.cpu 42.5 1609459200\n")
+	conn.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if gauges := metricsService.GetGauges(); gauges["servers.web01.cpu"] == 42.5 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("servers.web01.cpu was never recorded, got %v", metricsService.GetGauges())
+}
@@ -0,0 +1,34 @@
+package ingest
+
+import (
+	"net/http"
+
+	"github.com/idudko/go-musthave-metrics/internal/service"
+)
+
+// NewHandler adapts a Decoder into an http.HandlerFunc: it decodes the
+// request body and routes the resulting metrics through metricsService's
+// batch update path, the same one UpdateMetricsBatchHandler and
+// RemoteWriteHandler use. This lets cmd/server wire up OpenTSDB, Datadog,
+// or any future Decoder with a single line per protocol.
+func NewHandler(decoder Decoder, metricsService *service.MetricsService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		metrics, err := decoder.Decode(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if len(metrics) == 0 {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if err := metricsService.UpdateMetricsBatch(r.Context(), metrics); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,45 @@
+package ingest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/idudko/go-musthave-metrics/internal/repository"
+	"github.com/idudko/go-musthave-metrics/internal/service"
+)
+
+func TestNewHandler_OpenTSDB(t *testing.T) {
+	storage := repository.NewMemStorage()
+	metricsService := service.NewMetricsService(storage)
+	h := NewHandler(OpenTSDBDecoder{}, metricsService)
+
+	body := `{"metric":"sys.cpu.user","timestamp":1,"value":42.5}`
+	req := httptest.NewRequest(http.MethodPost, "/api/put", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	h(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if got := metricsService.GetGauges()["sys.cpu.user"]; got != 42.5 {
+		t.Errorf("sys.cpu.user = %v, want 42.5", got)
+	}
+}
+
+func TestNewHandler_DecodeError(t *testing.T) {
+	storage := repository.NewMemStorage()
+	metricsService := service.NewMetricsService(storage)
+	h := NewHandler(OpenTSDBDecoder{}, metricsService)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/put", strings.NewReader("not json"))
+	w := httptest.NewRecorder()
+
+	h(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,56 @@
+package ingest
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/goccy/go-json"
+	"github.com/idudko/go-musthave-metrics/internal/model"
+)
+
+// openTSDBPoint mirrors a single OpenTSDB /api/put data point:
+// https://opentsdb.net/docs/build/html/api_http/put.html.
+type openTSDBPoint struct {
+	Metric    string            `json:"metric"`
+	Timestamp int64             `json:"timestamp"`
+	Value     float64           `json:"value"`
+	Tags      map[string]string `json:"tags"`
+}
+
+// OpenTSDBDecoder decodes the OpenTSDB /api/put wire format: a single JSON
+// object or an array of them, each shaped as
+// {"metric","timestamp","value","tags"}. Every point becomes a Gauge -
+// OpenTSDB's put protocol carries no counter/gauge distinction of its own.
+type OpenTSDBDecoder struct{}
+
+func (OpenTSDBDecoder) Decode(r io.Reader) ([]model.Metrics, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	var points []openTSDBPoint
+	if err := json.Unmarshal(raw, &points); err != nil {
+		// /api/put also accepts a single object instead of an array.
+		var point openTSDBPoint
+		if err := json.Unmarshal(raw, &point); err != nil {
+			return nil, fmt.Errorf("invalid OpenTSDB payload: %w", err)
+		}
+		points = []openTSDBPoint{point}
+	}
+
+	metrics := make([]model.Metrics, 0, len(points))
+	for _, p := range points {
+		if p.Metric == "" {
+			return nil, fmt.Errorf("data point is missing required field %q", "metric")
+		}
+		value := p.Value
+		metrics = append(metrics, model.Metrics{
+			ID:    labelID(p.Metric, p.Tags),
+			MType: model.Gauge,
+			Value: &value,
+		})
+	}
+
+	return metrics, nil
+}
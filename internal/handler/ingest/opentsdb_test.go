@@ -0,0 +1,58 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOpenTSDBDecoder_SingleObject(t *testing.T) {
+	body := `{"metric":"sys.cpu.user","timestamp":1609459200,"value":42.5,"tags":{"host":"web01"}}`
+
+	metrics, err := OpenTSDBDecoder{}.Decode(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(metrics) != 1 {
+		t.Fatalf("got %d metrics, want 1", len(metrics))
+	}
+
+	m := metrics[0]
+	if m.ID != "sys.cpu.user{host=web01}" {
+		t.Errorf("ID = %q, want %q", m.ID, "sys.cpu.user{host=web01}")
+	}
+	if m.Value == nil || *m.Value != 42.5 {
+		t.Errorf("Value = %v, want 42.5", m.Value)
+	}
+}
+
+func TestOpenTSDBDecoder_Array(t *testing.T) {
+	body := `[
+		{"metric":"sys.cpu.user","timestamp":1,"value":1},
+		{"metric":"sys.cpu.idle","timestamp":1,"value":99}
+	]`
+
+	metrics, err := OpenTSDBDecoder{}.Decode(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(metrics) != 2 {
+		t.Fatalf("got %d metrics, want 2", len(metrics))
+	}
+	if metrics[0].ID != "sys.cpu.user" || metrics[1].ID != "sys.cpu.idle" {
+		t.Errorf("unexpected IDs: %q, %q", metrics[0].ID, metrics[1].ID)
+	}
+}
+
+func TestOpenTSDBDecoder_MissingMetricName(t *testing.T) {
+	body := `{"timestamp":1,"value":1}`
+
+	if _, err := (OpenTSDBDecoder{}).Decode(strings.NewReader(body)); err == nil {
+		t.Error("expected an error for a point missing its metric name")
+	}
+}
+
+func TestOpenTSDBDecoder_InvalidJSON(t *testing.T) {
+	if _, err := (OpenTSDBDecoder{}).Decode(strings.NewReader("not json")); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
@@ -8,16 +8,59 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/goccy/go-json"
+	"github.com/idudko/go-musthave-metrics/internal/audit"
+	"github.com/idudko/go-musthave-metrics/internal/identreg"
+	appmiddleware "github.com/idudko/go-musthave-metrics/internal/middleware"
 	"github.com/idudko/go-musthave-metrics/internal/model"
 	"github.com/idudko/go-musthave-metrics/internal/service"
+	"github.com/idudko/go-musthave-metrics/pkg/hash"
+	"github.com/idudko/go-musthave-metrics/pkg/pool"
 )
 
 type Handler struct {
 	metricsService *service.MetricsService
+	// key signs response bodies with HashSHA256 when non-empty, mirroring
+	// the request-side validation in middleware.HashValidationMiddleware.
+	key string
+	// identities tracks which agents are currently alive via their
+	// heartbeats; see AgentHeartbeatHandler and ListAgentsHandler.
+	identities *identreg.Registry
+	// remoteWriteCounters tracks the last cumulative value seen per series
+	// for RemoteWriteHandler, so repeat Prometheus remote_write scrapes of
+	// a "_total" counter translate into deltas instead of double-counting
+	// the cumulative value.
+	remoteWriteCounters *remoteWriteCounters
 }
 
-func NewHandler(metricsService *service.MetricsService) *Handler {
-	return &Handler{metricsService: metricsService}
+// responseBufferPool pools the buffers handlers use to build a response body
+// before signing it, avoiding a fresh allocation per request. It is sharded
+// by capacity class so that an occasional large response doesn't pin that
+// much memory for every subsequent small one.
+var responseBufferPool = pool.NewBufferPool("response_buffer_pool", 1<<20)
+
+// responseBufferSizeHint is the capacity Get requests for a single encoded
+// metric response; most are well under this.
+const responseBufferSizeHint = 256
+
+// NewHandler creates a Handler backed by metricsService. key, if set, is used
+// to sign response bodies with HMAC-SHA256 via the "HashSHA256" header; an
+// empty key disables signing, matching HashValidationMiddleware's behavior
+// for request validation.
+func NewHandler(metricsService *service.MetricsService, key string) *Handler {
+	return &Handler{
+		metricsService:      metricsService,
+		key:                 key,
+		identities:          identreg.New(identreg.DefaultTTL),
+		remoteWriteCounters: newRemoteWriteCounters(),
+	}
+}
+
+// signResponse sets the "HashSHA256" response header to the HMAC-SHA256 hash
+// of body when h.key is configured. It is a no-op otherwise.
+func (h *Handler) signResponse(w http.ResponseWriter, body []byte) {
+	if h.key != "" {
+		w.Header().Set("HashSHA256", hash.ComputeHash(body, h.key, hash.SHA256))
+	}
 }
 
 func (h *Handler) UpdateMetricHandler(w http.ResponseWriter, r *http.Request) {
@@ -46,15 +89,43 @@ func (h *Handler) UpdateMetricHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	h.recordMutation(r, metricType, metricName, value)
+
 	err = h.metricsService.UpdateMetric(metricType, metricName, value)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	h.signResponse(w, nil)
 	w.WriteHeader(http.StatusOK)
 }
 
+// recordMutation stamps the audit context attached to r (if
+// appmiddleware.AuditMiddleware is in the handler chain) with this
+// metric's mutation: its name, direction (MutationCreate if the metric
+// has no previous value yet), and value before and after the write. Must
+// be called before the storage write it's describing, since it reads the
+// previous value itself.
+func (h *Handler) recordMutation(r *http.Request, metricType, name string, newValue any) {
+	auditCtx := appmiddleware.GetAuditContext(r.Context())
+	if auditCtx == nil {
+		return
+	}
+
+	direction := audit.MutationCreate
+	oldValue := ""
+	if prev, err := h.metricsService.GetMetricValue(metricType, name); err == nil {
+		oldValue = fmt.Sprintf("%v", prev)
+		direction = audit.MutationSet
+		if metricType == model.Counter {
+			direction = audit.MutationIncrement
+		}
+	}
+
+	auditCtx.AddMetric(name, direction, oldValue, fmt.Sprintf("%v", newValue))
+}
+
 func (h *Handler) UpdateMetricJSONHandler(w http.ResponseWriter, r *http.Request) {
 	var metric model.Metrics
 	if err := json.NewDecoder(r.Body).Decode(&metric); err != nil {
@@ -74,13 +145,22 @@ func (h *Handler) UpdateMetricJSONHandler(w http.ResponseWriter, r *http.Request
 			http.Error(w, "Value is required for gauge", http.StatusBadRequest)
 			return
 		}
+		h.recordMutation(r, metric.MType, metric.ID, *metric.Value)
 		err = h.metricsService.UpdateMetric(metric.MType, metric.ID, *metric.Value)
 	case "counter":
 		if metric.Delta == nil {
 			http.Error(w, "Delta is required for counter", http.StatusBadRequest)
 			return
 		}
+		h.recordMutation(r, metric.MType, metric.ID, *metric.Delta)
 		err = h.metricsService.UpdateMetric(metric.MType, metric.ID, *metric.Delta)
+	case model.Histogram, model.Summary:
+		if metric.Value == nil {
+			http.Error(w, "Value is required for "+metric.MType, http.StatusBadRequest)
+			return
+		}
+		h.recordMutation(r, metric.MType, metric.ID, *metric.Value)
+		err = h.metricsService.UpdateMetric(metric.MType, metric.ID, *metric.Value)
 	default:
 		http.Error(w, "Invalid metric type", http.StatusBadRequest)
 		return
@@ -91,6 +171,7 @@ func (h *Handler) UpdateMetricJSONHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	h.signResponse(w, nil)
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -104,7 +185,9 @@ func (h *Handler) GetMetricValueHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	fmt.Fprintf(w, "%v", value)
+	body := []byte(fmt.Sprintf("%v", value))
+	h.signResponse(w, body)
+	w.Write(body)
 }
 
 func (h *Handler) GetMetricValueJSONHandler(w http.ResponseWriter, r *http.Request) {
@@ -135,9 +218,26 @@ func (h *Handler) GetMetricValueJSONHandler(w http.ResponseWriter, r *http.Reque
 		if v, ok := value.(int64); ok {
 			m.Delta = &v
 		}
+	case model.Histogram:
+		if v, ok := value.(model.HistogramValue); ok {
+			m.HistogramValue = &v
+		}
+	case model.Summary:
+		if v, ok := value.(model.SummaryValue); ok {
+			m.SummaryValue = &v
+		}
+	}
+	buf := responseBufferPool.Get(responseBufferSizeHint)
+	defer responseBufferPool.Put(buf)
+
+	if err := json.NewEncoder(buf).Encode(m); err != nil {
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
 	}
+
+	h.signResponse(w, buf.Bytes())
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(m)
+	w.Write(buf.Bytes())
 }
 
 func (h *Handler) ListMetricsHandler(w http.ResponseWriter, r *http.Request) {
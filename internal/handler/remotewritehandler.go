@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/idudko/go-musthave-metrics/internal/model"
+	"github.com/prometheus/prometheus/prompb"
+	"google.golang.org/protobuf/proto"
+)
+
+// prometheusNameLabel is the reserved Prometheus label holding the metric
+// name; all other labels are folded into the Metrics ID.
+const prometheusNameLabel = "__name__"
+
+// prometheusCounterSuffix marks a metric name as a monotonic counter in the
+// Prometheus/OpenMetrics convention, e.g. "http_requests_total".
+const prometheusCounterSuffix = "_total"
+
+// RemoteWriteHandler accepts the Prometheus remote-write wire protocol at
+// POST /api/v1/write: a snappy-compressed protobuf prompb.WriteRequest. This
+// lets Telegraf, Grafana Agent, and vmagent push directly to the server
+// without a custom shim.
+//
+// Each TimeSeries is translated into model.Metrics (metric name from the
+// "__name__" label; remaining labels folded into the ID as
+// "name{k=v,...}") and routed through the same MetricsService batch update
+// path as UpdateMetricsBatchHandler, so both MemStorage and DBStorage
+// benefit.
+func (h *Handler) RemoteWriteHandler(w http.ResponseWriter, r *http.Request) {
+	compressed, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, "Invalid snappy encoding", http.StatusBadRequest)
+		return
+	}
+
+	var wr prompb.WriteRequest
+	if err := proto.Unmarshal(data, &wr); err != nil {
+		http.Error(w, "Invalid protobuf encoding", http.StatusBadRequest)
+		return
+	}
+
+	metrics := h.translateWriteRequest(&wr)
+	if len(metrics) == 0 {
+		h.signResponse(w, nil)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if err := h.metricsService.UpdateMetricsBatch(r.Context(), metrics); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.signResponse(w, nil)
+	w.WriteHeader(http.StatusOK)
+}
+
+// translateWriteRequest flattens every sample of every TimeSeries in wr into
+// a model.Metrics. Samples of a metric whose name ends in
+// prometheusCounterSuffix are cumulative Prometheus counters, so they're run
+// through h.remoteWriteCounters to recover the delta since the series' last
+// sample; everything else becomes a Gauge snapshot.
+func (h *Handler) translateWriteRequest(wr *prompb.WriteRequest) []model.Metrics {
+	var metrics []model.Metrics
+
+	for _, ts := range wr.Timeseries {
+		name, id := metricID(ts.Labels)
+		if name == "" {
+			continue
+		}
+
+		for _, sample := range ts.Samples {
+			if strings.HasSuffix(name, prometheusCounterSuffix) {
+				delta := h.remoteWriteCounters.delta(id, sample.Value)
+				metrics = append(metrics, model.Metrics{ID: id, MType: model.Counter, Delta: &delta})
+				continue
+			}
+
+			value := sample.Value
+			metrics = append(metrics, model.Metrics{ID: id, MType: model.Gauge, Value: &value})
+		}
+	}
+
+	return metrics
+}
+
+// remoteWriteCounters converts the cumulative counter samples Prometheus
+// remote_write sends into deltas, tracking the last value seen per series ID
+// across requests. Safe for concurrent use.
+type remoteWriteCounters struct {
+	mu   sync.Mutex
+	last map[string]float64
+}
+
+func newRemoteWriteCounters() *remoteWriteCounters {
+	return &remoteWriteCounters{last: make(map[string]float64)}
+}
+
+// delta returns the change in a series' cumulative value since the last
+// call for the same id. The first sample seen for a series, and any sample
+// lower than the last one (the source process restarted and its counter
+// reset to zero), are reported as their full value rather than a negative
+// or overinflated delta.
+func (c *remoteWriteCounters) delta(id string, value float64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev, ok := c.last[id]
+	c.last[id] = value
+
+	if !ok || value < prev {
+		return int64(value)
+	}
+	return int64(value - prev)
+}
+
+// metricID extracts the Prometheus metric name from labels and builds the
+// Metrics ID, folding any remaining labels in as "name{k=v,...}" sorted by
+// key for a stable, deterministic ID.
+func metricID(labels []prompb.Label) (name string, id string) {
+	other := make([]prompb.Label, 0, len(labels))
+	for _, l := range labels {
+		if l.Name == prometheusNameLabel {
+			name = l.Value
+			continue
+		}
+		other = append(other, l)
+	}
+
+	if len(other) == 0 {
+		return name, name
+	}
+
+	sort.Slice(other, func(i, j int) bool { return other[i].Name < other[j].Name })
+
+	pairs := make([]string, len(other))
+	for i, l := range other {
+		pairs[i] = l.Name + "=" + l.Value
+	}
+
+	return name, name + "{" + strings.Join(pairs, ",") + "}"
+}
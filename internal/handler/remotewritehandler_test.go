@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/idudko/go-musthave-metrics/internal/model"
+	"github.com/idudko/go-musthave-metrics/internal/repository"
+	"github.com/idudko/go-musthave-metrics/internal/service"
+	"github.com/prometheus/prometheus/prompb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMetricID(t *testing.T) {
+	tests := []struct {
+		name     string
+		labels   []prompb.Label
+		wantName string
+		wantID   string
+	}{
+		{
+			name:     "name only",
+			labels:   []prompb.Label{{Name: "__name__", Value: "cpu_usage"}},
+			wantName: "cpu_usage",
+			wantID:   "cpu_usage",
+		},
+		{
+			name: "name with labels sorted by key",
+			labels: []prompb.Label{
+				{Name: "__name__", Value: "http_requests_total"},
+				{Name: "method", Value: "GET"},
+				{Name: "code", Value: "200"},
+			},
+			wantName: "http_requests_total",
+			wantID:   "http_requests_total{code=200,method=GET}",
+		},
+		{
+			name:     "no name label",
+			labels:   []prompb.Label{{Name: "job", Value: "node"}},
+			wantName: "",
+			wantID:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotName, gotID := metricID(tt.labels)
+			if gotName != tt.wantName {
+				t.Errorf("name = %q, want %q", gotName, tt.wantName)
+			}
+			if gotID != tt.wantID {
+				t.Errorf("id = %q, want %q", gotID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestTranslateWriteRequest(t *testing.T) {
+	h := &Handler{remoteWriteCounters: newRemoteWriteCounters()}
+
+	wr := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "cpu_usage"}},
+				Samples: []prompb.Sample{{Value: 42.5}},
+			},
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "http_requests_total"}},
+				Samples: []prompb.Sample{{Value: 7}},
+			},
+			{
+				Labels:  []prompb.Label{{Name: "job", Value: "node"}},
+				Samples: []prompb.Sample{{Value: 1}},
+			},
+		},
+	}
+
+	metrics := h.translateWriteRequest(wr)
+	if len(metrics) != 2 {
+		t.Fatalf("got %d metrics, want 2 (missing __name__ series skipped)", len(metrics))
+	}
+
+	gauge := metrics[0]
+	if gauge.MType != model.Gauge || gauge.ID != "cpu_usage" || gauge.Value == nil || *gauge.Value != 42.5 {
+		t.Errorf("gauge metric = %+v, want Gauge cpu_usage=42.5", gauge)
+	}
+
+	counter := metrics[1]
+	if counter.MType != model.Counter || counter.ID != "http_requests_total" || counter.Delta == nil || *counter.Delta != 7 {
+		t.Errorf("counter metric = %+v, want Counter http_requests_total delta=7", counter)
+	}
+}
+
+// TestTranslateWriteRequest_CounterTracksDeltaAcrossScrapes asserts a
+// "_total" series' samples translate into the change since the last scrape,
+// not the raw cumulative value, and that a value lower than the last one
+// (the source process restarted) is treated as a reset rather than a
+// negative delta.
+func TestTranslateWriteRequest_CounterTracksDeltaAcrossScrapes(t *testing.T) {
+	h := &Handler{remoteWriteCounters: newRemoteWriteCounters()}
+	series := func(value float64) *prompb.WriteRequest {
+		return &prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "http_requests_total"}},
+				Samples: []prompb.Sample{{Value: value}},
+			},
+		}}
+	}
+
+	tests := []struct {
+		value     float64
+		wantDelta int64
+	}{
+		{value: 100, wantDelta: 100}, // first sample: full value
+		{value: 130, wantDelta: 30},  // second scrape: delta since last
+		{value: 145, wantDelta: 15},
+		{value: 10, wantDelta: 10}, // process restarted, counter reset
+	}
+
+	for _, tt := range tests {
+		metrics := h.translateWriteRequest(series(tt.value))
+		if len(metrics) != 1 || metrics[0].Delta == nil {
+			t.Fatalf("translateWriteRequest(%v) = %+v, want one Counter metric", tt.value, metrics)
+		}
+		if got := *metrics[0].Delta; got != tt.wantDelta {
+			t.Errorf("translateWriteRequest(%v) delta = %d, want %d", tt.value, got, tt.wantDelta)
+		}
+	}
+}
+
+func TestRemoteWriteHandler(t *testing.T) {
+	storage := repository.NewMemStorage()
+	metricsService := service.NewMetricsService(storage)
+	h := NewHandler(metricsService, "")
+
+	wr := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels:  []prompb.Label{{Name: "__name__", Value: "cpu_usage"}},
+				Samples: []prompb.Sample{{Value: 42.5}},
+			},
+		},
+	}
+
+	data, err := proto.Marshal(wr)
+	if err != nil {
+		t.Fatalf("failed to marshal WriteRequest: %v", err)
+	}
+	compressed := snappy.Encode(nil, data)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/write", bytes.NewReader(compressed))
+	w := httptest.NewRecorder()
+
+	h.RemoteWriteHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	gauges := metricsService.GetGauges()
+	if got, want := gauges["cpu_usage"], 42.5; got != want {
+		t.Errorf("cpu_usage = %v, want %v", got, want)
+	}
+}
+
+func TestRemoteWriteHandler_InvalidSnappy(t *testing.T) {
+	storage := repository.NewMemStorage()
+	metricsService := service.NewMetricsService(storage)
+	h := NewHandler(metricsService, "")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/write", bytes.NewReader([]byte("not snappy")))
+	w := httptest.NewRecorder()
+
+	h.RemoteWriteHandler(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+// Pinger is satisfied by storage backends (repository.DBStorage,
+// repository.RedisStorage) that can verify their connection is live.
+// MemStorage doesn't implement it, so callers that don't know their
+// storage's concrete type type-assert for it, the same way audit.Subject.Close
+// type-asserts observers for audit.Closer.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// NewPingCheck builds a critical Check named name that calls pinger.Ping as
+// its probe, for storage backends with a live connection to verify (DB,
+// Redis).
+func NewPingCheck(name string, timeout time.Duration, pinger Pinger) Check {
+	return Check{Name: name, Critical: true, Timeout: timeout, Probe: pinger.Ping}
+}
+
+// NewHTTPReachabilityCheck builds an informational Check confirming url
+// answers a HEAD request within its Timeout, for sinks (an audit webhook)
+// whose temporary unavailability shouldn't by itself fail readiness.
+func NewHTTPReachabilityCheck(name, url string, timeout time.Duration) Check {
+	return Check{
+		Name:     name,
+		Critical: false,
+		Timeout:  timeout,
+		Probe: func(ctx context.Context) error {
+			req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+			if err != nil {
+				return err
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode >= 500 {
+				return fmt.Errorf("sink returned status %d", resp.StatusCode)
+			}
+			return nil
+		},
+	}
+}
+
+// NewDiskFreeCheck builds a critical Check verifying at least minFreeBytes
+// are free on the filesystem containing path (e.g. a FileObserver's audit
+// log directory), since a sink that can't write to disk should fail
+// readiness.
+func NewDiskFreeCheck(name, path string, minFreeBytes uint64, timeout time.Duration) Check {
+	return Check{
+		Name:     name,
+		Critical: true,
+		Timeout:  timeout,
+		Probe: func(ctx context.Context) error {
+			var stat syscall.Statfs_t
+			if err := syscall.Statfs(path, &stat); err != nil {
+				return fmt.Errorf("failed to stat %s: %w", path, err)
+			}
+
+			free := uint64(stat.Bavail) * uint64(stat.Bsize)
+			if free < minFreeBytes {
+				return fmt.Errorf("only %d bytes free on %s, want at least %d", free, path, minFreeBytes)
+			}
+			return nil
+		},
+	}
+}
+
+// NewCardinalityCheck builds an informational Check that fails once
+// droppedMetrics (e.g. MemStorage.MetricsDropped) reports at least one
+// metric update rejected for exceeding the storage's cardinality limit,
+// surfacing sustained memory pressure without ever failing readiness on its
+// own.
+func NewCardinalityCheck(name string, timeout time.Duration, droppedMetrics func() int64) Check {
+	return Check{
+		Name:     name,
+		Critical: false,
+		Timeout:  timeout,
+		Probe: func(ctx context.Context) error {
+			if dropped := droppedMetrics(); dropped > 0 {
+				return fmt.Errorf("%d metric updates dropped for exceeding the cardinality limit", dropped)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,191 @@
+// Package health provides a small named-component health-check registry
+// backing /livez and /readyz: components register a Check with a timeout
+// and a Critical flag, Registry.Run fans them out concurrently and caches
+// the result for a TTL, and LivezHandler/ReadyzHandler expose the result as
+// JSON or a plain status code.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status is a Check's or Report's overall outcome.
+type Status string
+
+const (
+	StatusOK    Status = "ok"
+	StatusError Status = "error"
+)
+
+// defaultTimeout bounds a Check whose Timeout is left at zero.
+const defaultTimeout = 5 * time.Second
+
+// Check is one named health probe. Critical checks that fail flip
+// Registry's overall status to StatusError; non-critical (informational)
+// checks still run and are reported, but never fail readiness on their
+// own.
+type Check struct {
+	// Name identifies the check in the JSON report.
+	Name string
+	// Critical marks whether this check's failure fails readiness.
+	Critical bool
+	// Timeout bounds how long Probe may run before it's treated as
+	// failed; defaultTimeout is used if left zero.
+	Timeout time.Duration
+	// Probe performs the check, returning a non-nil error on failure.
+	Probe func(ctx context.Context) error
+}
+
+// CheckResult is one Check's outcome in a Report.
+type CheckResult struct {
+	Name      string `json:"name"`
+	Status    Status `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report is the JSON body /livez and /readyz respond with.
+type Report struct {
+	Status Status        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Registry holds a set of named Checks and runs them concurrently, caching
+// the aggregate Report for ttl so frequent readiness probes can't hammer a
+// slow dependency (e.g. Postgres) under load.
+type Registry struct {
+	ttl time.Duration
+
+	mu     sync.Mutex
+	checks []Check
+
+	cacheMu  sync.Mutex
+	cached   Report
+	cachedAt time.Time
+}
+
+// NewRegistry creates an empty Registry that caches Run's result for ttl; a
+// ttl <= 0 disables caching, running every Check on every call.
+func NewRegistry(ttl time.Duration) *Registry {
+	return &Registry{ttl: ttl}
+}
+
+// Register adds check to the registry.
+func (r *Registry) Register(check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check)
+}
+
+// Run executes every registered Check concurrently, each bounded by its own
+// Timeout, and returns the aggregate Report - or a cached Report, if one
+// produced within ttl exists.
+func (r *Registry) Run(ctx context.Context) Report {
+	if r.ttl > 0 {
+		if cached, ok := r.cachedReport(); ok {
+			return cached
+		}
+	}
+
+	r.mu.Lock()
+	checks := make([]Check, len(r.checks))
+	copy(checks, r.checks)
+	r.mu.Unlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	status := StatusOK
+	for i, result := range results {
+		if result.Status == StatusError && checks[i].Critical {
+			status = StatusError
+		}
+	}
+	report := Report{Status: status, Checks: results}
+
+	if r.ttl > 0 {
+		r.cacheMu.Lock()
+		r.cached = report
+		r.cachedAt = time.Now()
+		r.cacheMu.Unlock()
+	}
+
+	return report
+}
+
+// cachedReport returns the cached Report and true if it was produced within
+// ttl.
+func (r *Registry) cachedReport() (Report, bool) {
+	r.cacheMu.Lock()
+	defer r.cacheMu.Unlock()
+	if r.cachedAt.IsZero() || time.Since(r.cachedAt) > r.ttl {
+		return Report{}, false
+	}
+	return r.cached, true
+}
+
+// runCheck runs check.Probe under its Timeout and turns the result into a
+// CheckResult, timing the probe itself (not the timeout bookkeeping).
+func runCheck(ctx context.Context, check Check) CheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Probe(checkCtx)
+	latency := time.Since(start)
+
+	result := CheckResult{Name: check.Name, Status: StatusOK, LatencyMs: latency.Milliseconds()}
+	if err != nil {
+		result.Status = StatusError
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// LivezHandler always reports StatusOK without running any Check: it only
+// proves the process is alive and serving, so a failing dependency can't
+// make an orchestrator kill a healthy process.
+func (r *Registry) LivezHandler(w http.ResponseWriter, req *http.Request) {
+	writeReport(w, req, Report{Status: StatusOK}, http.StatusOK)
+}
+
+// ReadyzHandler runs every registered Check (via Run, so it's subject to
+// caching) and reports a 503 if any Critical check failed, 200 otherwise.
+// With ?verbose=1 the full Report is returned as a JSON body; otherwise the
+// body is empty and only the status code carries the result.
+func (r *Registry) ReadyzHandler(w http.ResponseWriter, req *http.Request) {
+	report := r.Run(req.Context())
+
+	httpStatus := http.StatusOK
+	if report.Status != StatusOK {
+		httpStatus = http.StatusServiceUnavailable
+	}
+	writeReport(w, req, report, httpStatus)
+}
+
+func writeReport(w http.ResponseWriter, req *http.Request, report Report, httpStatus int) {
+	if req.URL.Query().Get("verbose") != "1" {
+		w.WriteHeader(httpStatus)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,79 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadyzHandlerAggregatesCriticalChecks(t *testing.T) {
+	registry := NewRegistry(0)
+	registry.Register(Check{Name: "ok-critical", Critical: true, Probe: func(ctx context.Context) error { return nil }})
+	registry.Register(Check{Name: "failing-informational", Critical: false, Probe: func(ctx context.Context) error { return errors.New("boom") }})
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rec := httptest.NewRecorder()
+	registry.ReadyzHandler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 when only an informational check fails, got %d", rec.Code)
+	}
+
+	registry.Register(Check{Name: "failing-critical", Critical: true, Probe: func(ctx context.Context) error { return errors.New("down") }})
+
+	rec = httptest.NewRecorder()
+	registry.ReadyzHandler(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 when a critical check fails, got %d", rec.Code)
+	}
+}
+
+func TestReadyzHandlerVerbose(t *testing.T) {
+	registry := NewRegistry(0)
+	registry.Register(Check{Name: "db", Critical: true, Probe: func(ctx context.Context) error { return nil }})
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz?verbose=1", nil)
+	rec := httptest.NewRecorder()
+	registry.ReadyzHandler(rec, req)
+
+	var report Report
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatalf("failed to decode verbose body: %v", err)
+	}
+	if report.Status != StatusOK || len(report.Checks) != 1 || report.Checks[0].Name != "db" {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+}
+
+func TestLivezHandlerIgnoresChecks(t *testing.T) {
+	registry := NewRegistry(0)
+	registry.Register(Check{Name: "down", Critical: true, Probe: func(ctx context.Context) error { return errors.New("down") }})
+
+	req := httptest.NewRequest(http.MethodGet, "/livez", nil)
+	rec := httptest.NewRecorder()
+	registry.LivezHandler(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected livez to always return 200, got %d", rec.Code)
+	}
+}
+
+func TestRunCachesWithinTTL(t *testing.T) {
+	calls := 0
+	registry := NewRegistry(time.Hour)
+	registry.Register(Check{Name: "counter", Probe: func(ctx context.Context) error {
+		calls++
+		return nil
+	}})
+
+	registry.Run(context.Background())
+	registry.Run(context.Background())
+
+	if calls != 1 {
+		t.Fatalf("expected the probe to run once within the TTL window, ran %d times", calls)
+	}
+}
@@ -0,0 +1,70 @@
+// Package identreg tracks which agents are currently reporting to the
+// server, inspired by the push-gateway "idents" pattern: each agent
+// periodically touches its entry via a heartbeat, and entries that go
+// quiet for longer than the configured TTL are treated as dead and
+// evicted. This gives operators fleet visibility (GET /api/v1/agents)
+// without standing up an external service-discovery layer.
+package identreg
+
+import (
+	"sync"
+	"time"
+
+	"github.com/idudko/go-musthave-metrics/internal/model"
+)
+
+// DefaultTTL is how long an agent is considered alive after its last
+// heartbeat before Registry.List stops returning it.
+const DefaultTTL = 90 * time.Second
+
+// Registry is a concurrency-safe, in-memory cache of the most recent
+// heartbeat from each known agent.
+type Registry struct {
+	ttl time.Duration
+
+	mu    sync.RWMutex
+	seen  map[string]model.AgentHeartbeat
+	touch map[string]time.Time
+}
+
+// New creates a Registry that considers an agent dead once ttl has elapsed
+// since its last heartbeat. A ttl of 0 uses DefaultTTL.
+func New(ttl time.Duration) *Registry {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Registry{
+		ttl:   ttl,
+		seen:  make(map[string]model.AgentHeartbeat),
+		touch: make(map[string]time.Time),
+	}
+}
+
+// Touch records hb as the latest heartbeat for its AgentID, resetting that
+// agent's TTL.
+func (r *Registry) Touch(hb model.AgentHeartbeat) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seen[hb.AgentID] = hb
+	r.touch[hb.AgentID] = time.Now()
+}
+
+// List returns the heartbeat of every agent that has checked in within the
+// registry's TTL, evicting any that haven't.
+func (r *Registry) List() []model.AgentHeartbeat {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	agents := make([]model.AgentHeartbeat, 0, len(r.seen))
+	for id, lastSeen := range r.touch {
+		if now.Sub(lastSeen) > r.ttl {
+			delete(r.touch, id)
+			delete(r.seen, id)
+			continue
+		}
+		agents = append(agents, r.seen[id])
+	}
+	return agents
+}
@@ -0,0 +1,43 @@
+package identreg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/idudko/go-musthave-metrics/internal/model"
+)
+
+func TestRegistry_TouchAndList(t *testing.T) {
+	r := New(time.Minute)
+	r.Touch(model.AgentHeartbeat{AgentID: "agent-1", Hostname: "host-1"})
+	r.Touch(model.AgentHeartbeat{AgentID: "agent-2", Hostname: "host-2"})
+
+	agents := r.List()
+	if len(agents) != 2 {
+		t.Fatalf("expected 2 agents, got %d", len(agents))
+	}
+}
+
+func TestRegistry_EvictsExpired(t *testing.T) {
+	r := New(10 * time.Millisecond)
+	r.Touch(model.AgentHeartbeat{AgentID: "agent-1", Hostname: "host-1"})
+
+	time.Sleep(20 * time.Millisecond)
+
+	if agents := r.List(); len(agents) != 0 {
+		t.Fatalf("expected expired agent to be evicted, got %d", len(agents))
+	}
+}
+
+func TestRegistry_TouchRefreshesTTL(t *testing.T) {
+	r := New(30 * time.Millisecond)
+	r.Touch(model.AgentHeartbeat{AgentID: "agent-1", Hostname: "host-1"})
+
+	time.Sleep(20 * time.Millisecond)
+	r.Touch(model.AgentHeartbeat{AgentID: "agent-1", Hostname: "host-1"})
+	time.Sleep(20 * time.Millisecond)
+
+	if agents := r.List(); len(agents) != 1 {
+		t.Fatalf("expected refreshed agent to still be alive, got %d", len(agents))
+	}
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"log"
 	"net/http"
 
 	"github.com/go-chi/chi/v5/middleware"
@@ -17,6 +18,8 @@ import (
 //
 // Parameters:
 //   - auditSubject: Subject that will be notified of audit events (nil to disable auditing)
+//   - sinks: structured audit.Sink implementations to additionally write full
+//     before/after mutation state to (see audit.AuditEventV1); empty to skip
 //
 // Returns:
 //   - func(http.Handler) http.Handler: Middleware function for use with HTTP router
@@ -28,6 +31,7 @@ import (
 //   - After handler completes, checks if any metrics were modified
 //   - Creates audit event with request details and modified metrics
 //   - Notifies audit subject if metrics were modified
+//   - Writes an audit.AuditEventV1 (full mutation detail) to every sink
 //
 // Use Cases:
 //   - Tracking metric updates for security purposes
@@ -42,8 +46,8 @@ import (
 //
 //	// Handlers can add metrics to audit context:
 //	// auditCtx := middleware.GetAuditContext(r.Context())
-//	// auditCtx.AddMetric("cpu_usage")
-func AuditMiddleware(auditSubject *audit.Subject) func(next http.Handler) http.Handler {
+//	// auditCtx.AddMetric("cpu_usage", audit.MutationSet, "1", "2")
+func AuditMiddleware(auditSubject *audit.Subject, sinks ...audit.Sink) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		fn := func(w http.ResponseWriter, r *http.Request) {
 			wrapped := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
@@ -54,10 +58,26 @@ func AuditMiddleware(auditSubject *audit.Subject) func(next http.Handler) http.H
 			next.ServeHTTP(wrapped, r)
 
 			auditCtx := GetAuditContext(r.Context())
+			if auditCtx == nil || len(auditCtx.Mutations) == 0 {
+				return
+			}
+
+			names := make([]string, len(auditCtx.Mutations))
+			for i, m := range auditCtx.Mutations {
+				names[i] = m.Name
+			}
+
+			if auditSubject != nil {
+				auditSubject.NotifyAll(audit.CreateAuditEvent(r, names))
+			}
 
-			if auditCtx != nil && len(auditCtx.Metrics) > 0 && auditSubject != nil {
-				event := audit.CreateAuditEvent(r, auditCtx.Metrics)
-				auditSubject.NotifyAll(event)
+			if len(sinks) > 0 {
+				event := audit.NewAuditEventV1(r, middleware.GetReqID(r.Context()), "", auditCtx.Mutations, wrapped.Status())
+				for _, sink := range sinks {
+					if err := sink.Write(r.Context(), event); err != nil {
+						log.Printf("Failed to write audit event to sink: %v", err)
+					}
+				}
 			}
 		}
 		return http.HandlerFunc(fn)
@@ -75,42 +95,52 @@ func AuditMiddleware(auditSubject *audit.Subject) func(next http.Handler) http.H
 //	This struct is NOT safe for concurrent use. A new instance should be
 //	created for each request.
 type AuditContext struct {
-	Metrics []string
+	Mutations []audit.MetricMutation
 }
 
 // NewAuditContext creates a new empty AuditContext instance.
 //
 // Returns:
-//   - *AuditContext: Context with empty metrics slice ready for use
+//   - *AuditContext: Context with empty mutation slice ready for use
 //
 // Example:
 //
 //	auditCtx := NewAuditContext()
-//	auditCtx.AddMetric("cpu_usage")
-//	auditCtx.AddMetric("memory_usage")
+//	auditCtx.AddMetric("cpu_usage", audit.MutationSet, "1", "2")
 func NewAuditContext() *AuditContext {
 	return &AuditContext{
-		Metrics: make([]string, 0),
+		Mutations: make([]audit.MetricMutation, 0),
 	}
 }
 
-// AddMetric adds a metric name to the audit context.
+// AddMetric records one metric mutation in the audit context: its name,
+// which direction it moved, and its value before and after the write.
 //
-// This method is typically called by handlers when they modify or access a metric.
-// The collected metric names will be included in the audit event after the request completes.
+// This method is typically called by handlers when they modify a metric,
+// having read its previous value from storage beforehand. The collected
+// mutations are included in the audit event after the request completes -
+// as metric names only for auditSubject, and in full (old/new values) for
+// any sinks passed to AuditMiddleware.
 //
 // Parameters:
-//   - name: Unique identifier of the metric being modified/accessed
+//   - name: Unique identifier of the metric being modified
+//   - direction: how the value moved (audit.MutationCreate/Set/Increment)
+//   - oldValue: the metric's value before this write, empty for MutationCreate
+//   - newValue: the metric's value after this write
 //
 // Example:
 //
 //	auditCtx := GetAuditContext(r.Context())
 //	if auditCtx != nil {
-//	    auditCtx.AddMetric("cpu_usage")
-//	    auditCtx.AddMetric("requests_total")
+//	    auditCtx.AddMetric("cpu_usage", audit.MutationSet, "12.5", "14.1")
 //	}
-func (c *AuditContext) AddMetric(name string) {
-	c.Metrics = append(c.Metrics, name)
+func (c *AuditContext) AddMetric(name string, direction audit.MutationDirection, oldValue, newValue string) {
+	c.Mutations = append(c.Mutations, audit.MetricMutation{
+		Name:      name,
+		Direction: direction,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+	})
 }
 
 // auditContextKey is a private type used as a key for storing AuditContext in request context.
@@ -0,0 +1,260 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	if _, err := gw.Write(data); err != nil {
+		t.Fatalf("failed to gzip test data: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCompressionMiddleware_DecodesRequestBody(t *testing.T) {
+	body := []byte(`{"id":"Alloc","type":"gauge","value":75.5}`)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body in next handler: %v", err)
+		}
+		if !bytes.Equal(got, body) {
+			t.Errorf("next handler saw body %q, want %q", got, body)
+		}
+		if enc := r.Header.Get("Content-Encoding"); enc != "" {
+			t.Errorf("expected Content-Encoding to be removed, got %q", enc)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := CompressionMiddleware(CompressionConfig{})(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/update/", bytes.NewReader(gzipBytes(t, body)))
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestCompressionMiddleware_UnsupportedContentEncoding(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next handler should not run for an unrecognized Content-Encoding")
+	})
+	handler := CompressionMiddleware(CompressionConfig{})(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/update/", strings.NewReader("payload"))
+	req.Header.Set("Content-Encoding", "br")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func compressionNextHandler(body []byte) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	})
+}
+
+func TestCompressionMiddleware_NegotiatesResponseCodec(t *testing.T) {
+	body := []byte(strings.Repeat("x", defaultMinCompressLength+1))
+	handler := CompressionMiddleware(CompressionConfig{})(compressionNextHandler(body))
+
+	tests := []struct {
+		acceptEncoding string
+		wantEncoding   string
+		decode         func(t *testing.T, data []byte) []byte
+	}{
+		{
+			acceptEncoding: "gzip",
+			wantEncoding:   "gzip",
+			decode: func(t *testing.T, data []byte) []byte {
+				r, err := gzip.NewReader(bytes.NewReader(data))
+				if err != nil {
+					t.Fatalf("gzip.NewReader: %v", err)
+				}
+				out, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatalf("reading gzip body: %v", err)
+				}
+				return out
+			},
+		},
+		{
+			acceptEncoding: "identity;q=1, zstd;q=0.9, gzip;q=0.5",
+			wantEncoding:   "zstd",
+			decode: func(t *testing.T, data []byte) []byte {
+				r, err := zstd.NewReader(bytes.NewReader(data))
+				if err != nil {
+					t.Fatalf("zstd.NewReader: %v", err)
+				}
+				defer r.Close()
+				out, err := io.ReadAll(r)
+				if err != nil {
+					t.Fatalf("reading zstd body: %v", err)
+				}
+				return out
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want %q", got, "Accept-Encoding")
+			}
+
+			decoded := tt.decode(t, w.Body.Bytes())
+			if !bytes.Equal(decoded, body) {
+				t.Errorf("decoded body mismatch: got %d bytes, want %d bytes", len(decoded), len(body))
+			}
+		})
+	}
+}
+
+func TestCompressionMiddleware_SkipsSmallAndIncompressibleResponses(t *testing.T) {
+	smallBody := []byte("ok")
+	largeBody := []byte(strings.Repeat("x", defaultMinCompressLength+1))
+
+	tests := []struct {
+		name string
+		body []byte
+		ct   string
+	}{
+		{name: "below MinLength", body: smallBody, ct: "application/json"},
+		{name: "not a compressible content type", body: largeBody, ct: "application/octet-stream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.ct)
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(tt.body)
+			})
+			handler := CompressionMiddleware(CompressionConfig{})(next)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Content-Encoding"); got != "" {
+				t.Errorf("expected no Content-Encoding, got %q", got)
+			}
+			if !bytes.Equal(w.Body.Bytes(), tt.body) {
+				t.Errorf("body was altered: got %q, want %q", w.Body.Bytes(), tt.body)
+			}
+		})
+	}
+}
+
+func TestCompressionMiddleware_NoAcceptEncodingPassesThrough(t *testing.T) {
+	body := []byte(strings.Repeat("x", defaultMinCompressLength+1))
+	handler := CompressionMiddleware(CompressionConfig{})(compressionNextHandler(body))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("expected no Content-Encoding, got %q", got)
+	}
+	if !bytes.Equal(w.Body.Bytes(), body) {
+		t.Errorf("body was altered when no Accept-Encoding was sent")
+	}
+}
+
+func TestCompressionMiddleware_GzipOnly(t *testing.T) {
+	body := []byte(strings.Repeat("x", defaultMinCompressLength+1))
+	handler := CompressionMiddleware(CompressionConfig{GzipOnly: true})(compressionNextHandler(body))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "zstd;q=1, gzip;q=0.5")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want %q (zstd not offered under GzipOnly)", got, "gzip")
+	}
+}
+
+func TestGzipRequestMiddleware(t *testing.T) {
+	body := []byte(`{"id":"Alloc","type":"gauge","value":75.5}`)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body in next handler: %v", err)
+		}
+		if !bytes.Equal(got, body) {
+			t.Errorf("next handler saw body %q, want %q", got, body)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := GzipRequestMiddleware(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/update/", bytes.NewReader(gzipBytes(t, body)))
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestGzipRequestMiddleware_UnsupportedContentType(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next handler should not run for an unsupported content type")
+	})
+	handler := GzipRequestMiddleware(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/update/", strings.NewReader("payload"))
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Content-Type", "application/octet-stream")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
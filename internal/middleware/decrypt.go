@@ -10,11 +10,13 @@ import (
 	"github.com/idudko/go-musthave-metrics/pkg/crypto"
 )
 
-// DecryptionMiddleware creates a middleware that decrypts request bodies using RSA.
+// DecryptionMiddleware creates a middleware that decrypts request bodies using
+// hybrid RSA+AES-GCM encryption (see pkg/crypto.DecryptHybrid for the frame format).
 //
 // This middleware decrypts request bodies that were encrypted using the agent's
-// public key. It uses the server's private key to decrypt the data before
-// passing it to the next handler.
+// public key. It uses the server's private key to unwrap the per-request AES key
+// before decrypting the AES-GCM payload, which removes the RSA payload-size limit
+// that plain RSA-OAEP encryption has.
 //
 // Parameters:
 //   - privateKeyPath: Path to the file containing the RSA private key (empty string to disable decryption)
@@ -24,7 +26,7 @@ import (
 //
 // Behavior:
 //   - If privateKeyPath is empty: Skips decryption and passes request through
-//   - If Content-Encoding header is "encrypt": Decrypts request body using RSA private key
+//   - If Content-Encoding header is "encrypt": Decrypts request body using the hybrid frame
 //   - On decryption failure: Returns 400 Bad Request with error message
 //   - On success: Replaces request body with decrypted data and passes to next handler
 //
@@ -78,8 +80,8 @@ func DecryptionMiddleware(privateKeyPath string) func(http.Handler) http.Handler
 			}
 			defer r.Body.Close()
 
-			// Decrypt the request body
-			decryptedBody, err := crypto.Decrypt(encryptedBody, privKey)
+			// Decrypt the request body (hybrid RSA+AES-GCM framing)
+			decryptedBody, err := crypto.DecryptHybrid(encryptedBody, privKey)
 			if err != nil {
 				log.Printf("Failed to decrypt request body: %v", err)
 				http.Error(w, "Failed to decrypt request body", http.StatusBadRequest)
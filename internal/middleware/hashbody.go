@@ -8,29 +8,38 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/idudko/go-musthave-metrics/pkg/hash"
+	"github.com/idudko/go-musthave-metrics/pkg/pool"
 )
 
-// HashValidationMiddleware creates a middleware that validates HMAC-SHA256 signatures on request bodies.
+// bodyBufferPool pools the buffers HashValidationMiddleware reads request
+// bodies into, avoiding a fresh allocation per request. It is sharded by
+// capacity class so that an occasional oversized body doesn't pin that much
+// memory for every subsequent small request.
+var bodyBufferPool = pool.NewBufferPool("hash_body_buffer_pool", 1<<20)
+
+// HashValidationMiddleware creates a middleware that validates keyed hash signatures on request bodies.
 //
 // This middleware verifies that request bodies have been signed with a shared secret key
-// to ensure data integrity and authenticity. It compares the received hash from the
-// "HashSHA256" header with a computed hash of the request body.
+// to ensure data integrity and authenticity. It scans every header registered in pkg/hash
+// (see hash.Headers) and, for whichever one is present, compares its value with a computed
+// hash of the request body under the matching Algorithm.
 //
 // Parameters:
-//   - key: Secret key used for HMAC-SHA256 signature generation (empty string to disable validation)
+//   - key: Secret key used for signature generation (empty string to disable validation)
 //
 // Returns:
 //   - func(http.Handler) http.Handler: Middleware function for use with HTTP router
 //
 // Behavior:
 //   - If key is empty: Skips validation and passes request through
-//   - If "HashSHA256" header is missing or "none": Skips validation
-//   - If header exists: Reads request body, validates hash signature
+//   - If none of the registered signature headers are set, or the one present is "none": Skips validation
+//   - If a header is present: reads the body into a pooled buffer and validates under the matching algorithm
 //   - On validation failure: Returns 400 Bad Request with "Invalid hash signature" error
 //   - On success: Passes request to next handler
 //
 // HTTP Headers:
-//   - HashSHA256: Expected HMAC-SHA256 hash of the request body (hexadecimal string)
+//   - One of hash.Headers() (e.g. HashSHA256, HashSHA1, HashSHA512, HashBLAKE2B): expected
+//     signature of the request body (hexadecimal string)
 //
 // Response Codes:
 //   - Next handler continues on valid hash
@@ -56,28 +65,44 @@ func HashValidationMiddleware(key string) func(http.Handler) http.Handler {
 				return
 			}
 
-			receivedHash := r.Header.Get("HashSHA256")
+			algo, receivedHash, ok := selectAlgorithm(r)
 
-			if receivedHash == "" || receivedHash == "none" {
+			if !ok || receivedHash == "" || receivedHash == "none" {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			body, err := io.ReadAll(r.Body)
-			if err != nil {
+			buf := bodyBufferPool.Get(int(r.ContentLength))
+			defer bodyBufferPool.Put(buf)
+
+			if _, err := io.Copy(buf, r.Body); err != nil {
 				http.Error(w, "Failed to read request body", http.StatusBadRequest)
 				return
 			}
 
-			r.Body = io.NopCloser(bytes.NewBuffer(body))
-
-			if !hash.ValidateHash(body, key, receivedHash) {
+			if !hash.ValidateHash(buf.Bytes(), key, receivedHash, algo) {
 				log.Printf("Invalid hash signature")
 				http.Error(w, "Invalid hash signature", http.StatusBadRequest)
 				return
 			}
 
+			r.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// selectAlgorithm scans every header hash.Headers() registers, in a fixed
+// order, and returns the Algorithm and value for the first one present on r.
+// It always walks the full, fixed-size header list rather than returning as
+// soon as a match is found, so the number of headers a request carries can't
+// be inferred from how long selection takes.
+func selectAlgorithm(r *http.Request) (algo hash.Algorithm, value string, ok bool) {
+	for _, header := range hash.Headers() {
+		if v := r.Header.Get(header); v != "" && !ok {
+			a, _ := hash.Lookup(header)
+			algo, value, ok = a, v, true
+		}
+	}
+	return algo, value, ok
+}
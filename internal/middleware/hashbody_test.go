@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/idudko/go-musthave-metrics/pkg/hash"
+)
+
+func TestHashValidationMiddleware(t *testing.T) {
+	const key = "my-secret-key"
+	body := []byte(`{"id":"Alloc","type":"gauge","value":75.5}`)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body in next handler: %v", err)
+		}
+		if !bytes.Equal(got, body) {
+			t.Errorf("next handler saw body %q, want %q", got, body)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := HashValidationMiddleware(key)(next)
+
+	tests := []struct {
+		name           string
+		body           []byte
+		hashHeader     string
+		expectedStatus int
+	}{
+		{
+			name:           "valid signature",
+			body:           body,
+			hashHeader:     hash.ComputeHash(body, key, hash.SHA256),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid signature",
+			body:           body,
+			hashHeader:     "0000000000000000000000000000000000000000000000000000000000000000",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "missing hash header skips validation",
+			body:           body,
+			hashHeader:     "",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "none hash header skips validation",
+			body:           body,
+			hashHeader:     "none",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "empty body with valid signature",
+			body:           nil,
+			hashHeader:     hash.ComputeHash(nil, key, hash.SHA256),
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/update/", bytes.NewReader(tt.body))
+			if tt.hashHeader != "" {
+				req.Header.Set("HashSHA256", tt.hashHeader)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+// streamingReader dribbles out the underlying body a few bytes at a time,
+// exercising the io.Copy path in HashValidationMiddleware instead of a
+// single ReadAll-sized read.
+type streamingReader struct {
+	data []byte
+}
+
+func (r *streamingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data[:min(3, len(r.data))])
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestHashValidationMiddleware_StreamingBody(t *testing.T) {
+	const key = "my-secret-key"
+	body := []byte(`{"id":"PollCount","type":"counter","delta":10}`)
+	validHash := hash.ComputeHash(body, key, hash.SHA256)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body in next handler: %v", err)
+		}
+		if !bytes.Equal(got, body) {
+			t.Errorf("next handler saw body %q, want %q", got, body)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := HashValidationMiddleware(key)(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/update/", &streamingReader{data: append([]byte(nil), body...)})
+	req.Header.Set("HashSHA256", validHash)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestHashValidationMiddleware_AlgorithmNegotiation(t *testing.T) {
+	const key = "my-secret-key"
+	body := []byte(`{"id":"Alloc","type":"gauge","value":75.5}`)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := HashValidationMiddleware(key)(next)
+
+	for _, algo := range []hash.Algorithm{hash.SHA1, hash.SHA256, hash.SHA512, hash.BLAKE2b} {
+		t.Run(algo.Name(), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/update/", bytes.NewReader(body))
+			req.Header.Set(algo.Name(), hash.ComputeHash(body, key, algo))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("expected status %d for %s, got %d", http.StatusOK, algo.Name(), w.Code)
+			}
+		})
+	}
+}
+
+func TestHashValidationMiddleware_KeyDisabled(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := HashValidationMiddleware("")(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/update/", bytes.NewReader([]byte("anything")))
+	req.Header.Set("HashSHA256", "bogus")
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected validation to be skipped when key is empty, got status %d", w.Code)
+	}
+}
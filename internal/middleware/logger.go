@@ -1,7 +1,12 @@
 package middleware
 
 import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"math/rand"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -85,3 +90,151 @@ func (lrw *loggingResponseWriter) Write(b []byte) (int, error) {
 	lrw.size += size
 	return size, err
 }
+
+// defaultMaxBodyBytes bounds how much of a request/response body
+// NewLoggingMiddleware captures when a config doesn't set MaxBodyBytes.
+const defaultMaxBodyBytes = 4096
+
+// LoggingConfig configures NewLoggingMiddleware's optional request/response
+// body capture, useful for debugging metric ingestion without paying its
+// overhead on every request in a production deployment.
+type LoggingConfig struct {
+	// SampleRate is the fraction of requests, in [0, 1], whose bodies are
+	// captured. Zero disables capture entirely; every request is still
+	// logged at the summary level LoggingMiddleware already provides.
+	SampleRate float64
+	// MaxBodyBytes bounds how much of a captured body is kept and logged.
+	// Zero uses defaultMaxBodyBytes.
+	MaxBodyBytes int
+	// RedactHeaders lists header names (case-insensitive) whose values are
+	// replaced with "[REDACTED]" in the logged headers map, e.g. the
+	// signature and bearer-token headers set by the signing/auth middleware.
+	RedactHeaders []string
+	// LogRequestBody enables capturing a sampled request's body.
+	LogRequestBody bool
+	// LogResponseBody enables capturing a sampled request's response body.
+	LogResponseBody bool
+}
+
+// NewLoggingMiddleware creates a logging middleware like LoggingMiddleware,
+// additionally capturing request/response bodies for a sampled fraction of
+// requests (per cfg.SampleRate) and logging them as base64 fields alongside a
+// redacted headers map.
+//
+// Request body capture only buffers up to cfg.MaxBodyBytes and then restores
+// r.Body so that downstream handlers and middleware still see the complete,
+// untruncated body — this composes correctly with HashValidationMiddleware
+// and other middleware further down the chain that also rewinds r.Body.
+// Response body capture tees up to cfg.MaxBodyBytes into the log without
+// altering what is actually written to the client.
+//
+// Example:
+//
+//	r.Use(middleware.NewLoggingMiddleware(middleware.LoggingConfig{
+//	    SampleRate:      0.01,
+//	    MaxBodyBytes:    4096,
+//	    RedactHeaders:   []string{"Authorization", "HashSHA256"},
+//	    LogRequestBody:  true,
+//	    LogResponseBody: true,
+//	}))
+func NewLoggingMiddleware(cfg LoggingConfig) func(http.Handler) http.Handler {
+	maxBodyBytes := cfg.MaxBodyBytes
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = defaultMaxBodyBytes
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			lrw := &loggingResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			sampled := cfg.SampleRate > 0 && (cfg.SampleRate >= 1 || rand.Float64() < cfg.SampleRate)
+
+			var reqBody []byte
+			if sampled && cfg.LogRequestBody && r.Body != nil {
+				reqBody = captureAndRestoreRequestBody(r, maxBodyBytes)
+			}
+
+			var rw http.ResponseWriter = lrw
+			var captured *bodyCapturingWriter
+			if sampled && cfg.LogResponseBody {
+				captured = &bodyCapturingWriter{loggingResponseWriter: lrw, max: maxBodyBytes}
+				rw = captured
+			}
+
+			next.ServeHTTP(rw, r)
+
+			event := log.Info().
+				Str("method", r.Method).
+				Str("uri", r.RequestURI).
+				Int("status", lrw.statusCode).
+				Int("size", lrw.size).
+				Dur("duration", time.Since(start))
+
+			if sampled {
+				event = event.Interface("headers", redactedHeaders(r.Header, cfg.RedactHeaders))
+				if reqBody != nil {
+					event = event.Str("request_body", base64.StdEncoding.EncodeToString(reqBody))
+				}
+				if captured != nil {
+					event = event.Str("response_body", base64.StdEncoding.EncodeToString(captured.buf.Bytes()))
+				}
+			}
+
+			event.Msg("handled request")
+		})
+	}
+}
+
+// captureAndRestoreRequestBody reads up to maxBytes of r.Body for logging,
+// then restores r.Body to a reader that yields those captured bytes followed
+// by whatever of the original body remains unread, so the request is left
+// exactly as downstream handlers would have seen it.
+func captureAndRestoreRequestBody(r *http.Request, maxBytes int) []byte {
+	captured, err := io.ReadAll(io.LimitReader(r.Body, int64(maxBytes)))
+	if err != nil {
+		return nil
+	}
+
+	r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(captured), r.Body))
+	return captured
+}
+
+// bodyCapturingWriter tees up to max bytes of the response body into buf for
+// logging, while still writing every byte unmodified to the real client.
+type bodyCapturingWriter struct {
+	*loggingResponseWriter
+	buf bytes.Buffer
+	max int
+}
+
+func (w *bodyCapturingWriter) Write(b []byte) (int, error) {
+	if remaining := w.max - w.buf.Len(); remaining > 0 {
+		n := remaining
+		if n > len(b) {
+			n = len(b)
+		}
+		w.buf.Write(b[:n])
+	}
+	return w.loggingResponseWriter.Write(b)
+}
+
+// redactedHeaders returns headers as a map with each header named in redact
+// (case-insensitive) replaced by "[REDACTED]", so secrets like bearer tokens
+// or request signatures never reach the log.
+func redactedHeaders(headers http.Header, redact []string) map[string]string {
+	redactSet := make(map[string]struct{}, len(redact))
+	for _, h := range redact {
+		redactSet[strings.ToLower(h)] = struct{}{}
+	}
+
+	out := make(map[string]string, len(headers))
+	for name, values := range headers {
+		if _, ok := redactSet[strings.ToLower(name)]; ok {
+			out[name] = "[REDACTED]"
+			continue
+		}
+		out[name] = strings.Join(values, ",")
+	}
+	return out
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/idudko/go-musthave-metrics/pkg/hash"
+)
+
+func TestNewLoggingMiddleware_CapturesAndRestoresRequestBody(t *testing.T) {
+	body := []byte(`{"id":"Alloc","type":"gauge","value":75.5}`)
+
+	var sawBody []byte
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		sawBody, err = io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body in next handler: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler := NewLoggingMiddleware(LoggingConfig{
+		SampleRate:     1,
+		LogRequestBody: true,
+	})(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/update/", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if !bytes.Equal(sawBody, body) {
+		t.Errorf("next handler saw body %q, want %q", sawBody, body)
+	}
+}
+
+func TestCaptureAndRestoreRequestBody_TruncatesCaptureButRestoresFullBody(t *testing.T) {
+	body := bytes.Repeat([]byte("a"), 100)
+	const maxBodyBytes = 10
+
+	req := httptest.NewRequest(http.MethodPost, "/update/", bytes.NewReader(body))
+
+	captured := captureAndRestoreRequestBody(req, maxBodyBytes)
+	if len(captured) != maxBodyBytes {
+		t.Errorf("captured %d bytes, want %d", len(captured), maxBodyBytes)
+	}
+
+	restored, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("failed to read restored body: %v", err)
+	}
+	if !bytes.Equal(restored, body) {
+		t.Errorf("restored body = %d bytes, want the full %d bytes unchanged", len(restored), len(body))
+	}
+}
+
+func TestNewLoggingMiddleware_CapturesResponseBodyForStreamingWriter(t *testing.T) {
+	chunks := []string{"hello ", "streaming ", "world"}
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		for _, c := range chunks {
+			_, _ = w.Write([]byte(c))
+		}
+	})
+
+	handler := NewLoggingMiddleware(LoggingConfig{
+		SampleRate:      1,
+		LogResponseBody: true,
+	})(next)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	want := strings.Join(chunks, "")
+	if got := w.Body.String(); got != want {
+		t.Errorf("client received %q, want %q", got, want)
+	}
+}
+
+func TestNewLoggingMiddleware_RedactsConfiguredHeaders(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("HashSHA256", "super-secret-signature")
+	headers.Set("Authorization", "Bearer super-secret-token")
+	headers.Set("Content-Type", "application/json")
+
+	got := redactedHeaders(headers, []string{"hashsha256", "authorization"})
+
+	if got["HashSHA256"] != "[REDACTED]" {
+		t.Errorf("HashSHA256 = %q, want [REDACTED]", got["HashSHA256"])
+	}
+	if got["Authorization"] != "[REDACTED]" {
+		t.Errorf("Authorization = %q, want [REDACTED]", got["Authorization"])
+	}
+	if got["Content-Type"] != "application/json" {
+		t.Errorf("Content-Type = %q, want unmodified", got["Content-Type"])
+	}
+}
+
+func TestNewLoggingMiddleware_SampleRateZeroSkipsCapture(t *testing.T) {
+	body := []byte(`{"id":"PollCount","type":"counter","delta":10}`)
+
+	var sawBody []byte
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		sawBody, err = io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body in next handler: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler := NewLoggingMiddleware(LoggingConfig{
+		LogRequestBody:  true,
+		LogResponseBody: true,
+	})(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/update/", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if !bytes.Equal(sawBody, body) {
+		t.Errorf("next handler saw body %q, want %q", sawBody, body)
+	}
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestNewLoggingMiddleware_ComposesWithHashValidationMiddleware(t *testing.T) {
+	const key = "my-secret-key"
+	body := []byte(`{"id":"Alloc","type":"gauge","value":75.5}`)
+
+	var sawBody []byte
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		sawBody, err = io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("failed to read body in next handler: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler := NewLoggingMiddleware(LoggingConfig{
+		SampleRate:     1,
+		LogRequestBody: true,
+	})(HashValidationMiddleware(key)(next))
+
+	req := httptest.NewRequest(http.MethodPost, "/update/", bytes.NewReader(body))
+	req.Header.Set("HashSHA256", hash.ComputeHash(body, key, hash.SHA256))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !bytes.Equal(sawBody, body) {
+		t.Errorf("next handler saw body %q, want %q", sawBody, body)
+	}
+}
@@ -3,55 +3,169 @@ package middleware
 import (
 	"net"
 	"net/http"
+	"strings"
 
 	"github.com/rs/zerolog/log"
+
+	"github.com/idudko/go-musthave-metrics/internal/audit"
 )
 
-// TrustedSubnetMiddleware проверяет, что IP из заголовка X-Real-IP
-// входит в доверенную подсеть. Если trustedSubnet пустой, все запросы пропускаются.
-func TrustedSubnetMiddleware(trustedSubnet string) func(http.Handler) http.Handler {
+// TrustedSubnetConfig configures TrustedSubnetMiddleware.
+type TrustedSubnetConfig struct {
+	// Subnets lists the CIDRs (IPv4 and/or IPv6 may be mixed) a resolved
+	// client IP must fall within for a request to be let through. An
+	// empty Subnets disables the middleware, passing every request.
+	Subnets []string
+	// TrustedProxies lists the CIDRs allowed to appear as intermediate
+	// reverse-proxy hops in X-Forwarded-For/Forwarded: the hop list is
+	// walked right to left (nearest proxy to original client), skipping
+	// hops inside one of these CIDRs, so the rightmost hop NOT in
+	// TrustedProxies is taken as the client IP; hops to its left are not
+	// consulted.
+	TrustedProxies []string
+	// HeaderPriority lists, in order, which sources to resolve the client
+	// IP from: audit.HeaderXForwardedFor, audit.HeaderForwarded (RFC
+	// 7239), audit.HeaderXRealIP, or audit.HeaderRemoteAddr. Defaults to
+	// audit.DefaultHeaderPriority when nil.
+	HeaderPriority []string
+}
+
+// TrustedSubnetMiddleware rejects any request whose client IP - resolved
+// per cfg.HeaderPriority, walking past cfg.TrustedProxies hops in
+// forwarding headers - doesn't fall within one of cfg.Subnets. If
+// cfg.Subnets is empty, or its CIDRs fail to parse, every request is let
+// through (a misconfigured trust boundary shouldn't take the server down).
+func TrustedSubnetMiddleware(cfg TrustedSubnetConfig) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
-		// Если доверенная подсеть не задана, просто пропускаем все запросы
-		if trustedSubnet == "" {
+		if len(cfg.Subnets) == 0 {
 			return next
 		}
 
-		// Парсим доверенную подсеть
-		_, ipNet, err := net.ParseCIDR(trustedSubnet)
+		subnets, err := parseCIDRs(cfg.Subnets)
 		if err != nil {
-			// Если CIDR невалидный, логируем предупреждение и пропускаем все запросы
-			// (не блокируем систему из-за ошибки конфигурации)
-			log.Warn().Err(err).Str("trusted_subnet", trustedSubnet).Msg("Invalid trusted subnet CIDR format, allowing all requests")
+			log.Warn().Err(err).Strs("subnets", cfg.Subnets).Msg("Invalid trusted subnet CIDR, allowing all requests")
 			return next
 		}
 
+		trustedProxies, err := parseCIDRs(cfg.TrustedProxies)
+		if err != nil {
+			log.Warn().Err(err).Strs("trusted_proxies", cfg.TrustedProxies).Msg("Invalid trusted proxy CIDR, ignoring trusted proxies")
+			trustedProxies = nil
+		}
+
+		headerPriority := cfg.HeaderPriority
+		if len(headerPriority) == 0 {
+			headerPriority = audit.DefaultHeaderPriority
+		}
+
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Получаем IP из заголовка X-Real-IP
-			realIP := r.Header.Get("X-Real-IP")
-			if realIP == "" {
-				log.Warn().Str("remote_addr", r.RemoteAddr).Str("method", r.Method).Str("uri", r.RequestURI).Msg("X-Real-IP header is required but missing")
-				http.Error(w, "X-Real-IP header is required", http.StatusForbidden)
+			clientIP := resolveTrustedClientIP(r, headerPriority, trustedProxies)
+			if clientIP == "" {
+				log.Warn().Str("remote_addr", r.RemoteAddr).Str("method", r.Method).Str("uri", r.RequestURI).Msg("Could not resolve a client IP from the configured headers")
+				http.Error(w, "client IP could not be resolved", http.StatusForbidden)
 				return
 			}
 
-			// Парсим IP адрес
-			ip := net.ParseIP(realIP)
+			ip := net.ParseIP(clientIP)
 			if ip == nil {
-				log.Warn().Str("real_ip", realIP).Str("remote_addr", r.RemoteAddr).Msg("Invalid IP address in X-Real-IP header")
-				http.Error(w, "Invalid IP address in X-Real-IP header", http.StatusForbidden)
+				log.Warn().Str("client_ip", clientIP).Str("remote_addr", r.RemoteAddr).Msg("Invalid client IP address")
+				http.Error(w, "invalid client IP address", http.StatusForbidden)
 				return
 			}
 
-			// Проверяем, что IP входит в доверенную подсеть
-			if !ipNet.Contains(ip) {
-				log.Warn().Str("ip", ip.String()).Str("trusted_subnet", trustedSubnet).Str("method", r.Method).Str("uri", r.RequestURI).Msg("IP address is not in trusted subnet")
-				http.Error(w, "IP address is not in trusted subnet", http.StatusForbidden)
+			if !cidrsContain(subnets, ip) {
+				log.Warn().Str("ip", ip.String()).Str("method", r.Method).Str("uri", r.RequestURI).Msg("IP address is not in a trusted subnet")
+				http.Error(w, "IP address is not in a trusted subnet", http.StatusForbidden)
 				return
 			}
 
-			// IP в доверенной подсети, пропускаем запрос дальше
-			log.Debug().Str("ip", ip.String()).Str("method", r.Method).Str("uri", r.RequestURI).Msg("IP is in trusted subnet, allowing request")
 			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+// resolveTrustedClientIP walks headerPriority in order and returns the
+// first client IP it can resolve. X-Forwarded-For and Forwarded name a hop
+// chain set by reverse proxies, so they're only trusted - and resolved via
+// firstUntrustedHop - when at least one trustedProxies CIDR is configured;
+// with none configured, RemoteAddr is the only hop that can't be spoofed by
+// the connecting client, so these headers are skipped entirely rather than
+// letting an unproxied client's own header pick its trusted client IP.
+// X-Real-IP and RemoteAddr name a single hop and are used as-is.
+func resolveTrustedClientIP(r *http.Request, headerPriority []string, trustedProxies []*net.IPNet) string {
+	for _, header := range headerPriority {
+		switch header {
+		case audit.HeaderXForwardedFor:
+			if len(trustedProxies) == 0 {
+				continue
+			}
+			if hops := audit.ForwardedForHops(r.Header.Get(audit.HeaderXForwardedFor)); len(hops) > 0 {
+				if ip := firstUntrustedHop(hops, trustedProxies); ip != "" {
+					return ip
+				}
+			}
+		case audit.HeaderForwarded:
+			if len(trustedProxies) == 0 {
+				continue
+			}
+			if hops := audit.ForwardedHeaderHops(r.Header.Get(audit.HeaderForwarded)); len(hops) > 0 {
+				if ip := firstUntrustedHop(hops, trustedProxies); ip != "" {
+					return ip
+				}
+			}
+		case audit.HeaderXRealIP:
+			if realIP := r.Header.Get(audit.HeaderXRealIP); realIP != "" {
+				return realIP
+			}
+		case audit.HeaderRemoteAddr:
+			return audit.RemoteAddrIP(r.RemoteAddr)
+		}
+	}
+	return ""
+}
+
+// firstUntrustedHop walks hops right to left (nearest proxy to original
+// client), skipping any hop that does not parse as an IP or parses as one
+// inside trustedProxies, and returns the first one it meets that isn't -
+// i.e. the rightmost untrusted hop, the standard reverse-proxy client-IP
+// pattern. It returns "" if no hop qualifies.
+func firstUntrustedHop(hops []string, trustedProxies []*net.IPNet) string {
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			continue
+		}
+		if !cidrsContain(trustedProxies, ip) {
+			return hops[i]
+		}
+	}
+	return ""
+}
+
+// parseCIDRs parses each non-empty entry in cidrs, returning an error on
+// the first one that isn't a valid CIDR.
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// cidrsContain reports whether ip falls within any of nets.
+func cidrsContain(nets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
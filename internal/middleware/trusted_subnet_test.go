@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/idudko/go-musthave-metrics/internal/audit"
+)
+
+func TestTrustedSubnetMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		cfg            TrustedSubnetConfig
+		remoteAddr     string
+		xForwardedFor  string
+		xRealIP        string
+		expectedStatus int
+	}{
+		{
+			name:           "no subnets configured allows everything",
+			cfg:            TrustedSubnetConfig{},
+			remoteAddr:     "203.0.113.1:1234",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "RemoteAddr inside trusted subnet",
+			cfg:            TrustedSubnetConfig{Subnets: []string{"192.168.1.0/24"}, HeaderPriority: []string{audit.HeaderRemoteAddr}},
+			remoteAddr:     "192.168.1.5:1234",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "RemoteAddr outside trusted subnet",
+			cfg:            TrustedSubnetConfig{Subnets: []string{"192.168.1.0/24"}, HeaderPriority: []string{audit.HeaderRemoteAddr}},
+			remoteAddr:     "10.0.0.5:1234",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "mixed IPv4/IPv6 subnets",
+			cfg:            TrustedSubnetConfig{Subnets: []string{"2001:db8::/32", "192.168.1.0/24"}, HeaderPriority: []string{audit.HeaderXRealIP}},
+			remoteAddr:     "10.0.0.5:1234",
+			xRealIP:        "2001:db8::1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "X-Forwarded-For skips trusted proxy hops",
+			cfg: TrustedSubnetConfig{
+				Subnets:        []string{"203.0.113.0/24"},
+				TrustedProxies: []string{"10.0.0.0/8"},
+				HeaderPriority: []string{audit.HeaderXForwardedFor},
+			},
+			remoteAddr:     "10.0.0.9:1234",
+			xForwardedFor:  "203.0.113.7, 10.0.0.2, 10.0.0.1",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "X-Forwarded-For leftmost untrusted hop is not trusted",
+			cfg: TrustedSubnetConfig{
+				Subnets:        []string{"203.0.113.0/24"},
+				TrustedProxies: []string{"10.0.0.0/8"},
+				HeaderPriority: []string{audit.HeaderXForwardedFor},
+			},
+			remoteAddr:     "10.0.0.9:1234",
+			xForwardedFor:  "198.51.100.7, 10.0.0.2, 10.0.0.1",
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "X-Forwarded-For with no trusted proxies configured is never trusted",
+			cfg: TrustedSubnetConfig{
+				Subnets:        []string{"203.0.113.0/24"},
+				HeaderPriority: []string{audit.HeaderXForwardedFor, audit.HeaderRemoteAddr},
+			},
+			remoteAddr:     "198.51.100.9:1234",
+			xForwardedFor:  "203.0.113.7",
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := TrustedSubnetMiddleware(tt.cfg)(next)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.xForwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.xForwardedFor)
+			}
+			if tt.xRealIP != "" {
+				req.Header.Set("X-Real-IP", tt.xRealIP)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, rec.Code)
+			}
+		})
+	}
+}
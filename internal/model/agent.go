@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// AgentHeartbeat is the payload an agent's MetricsService periodically posts
+// to the server so operators can see which agents are alive without an
+// external service-discovery layer.
+type AgentHeartbeat struct {
+	// AgentID is the stable identity derived from the agent's hostname and
+	// MAC address (see agent.AgentIdentity). It's also stamped onto every
+	// metric the agent reports, so the server can correlate ownership.
+	AgentID string `json:"agent_id"`
+
+	// Hostname is the agent host's reported hostname.
+	Hostname string `json:"hostname"`
+
+	// IP is the local IP address the agent reported from.
+	IP string `json:"ip"`
+
+	// Version is the agent binary's version string.
+	Version string `json:"version"`
+
+	// MetricsCount is the number of distinct metrics the agent reported in
+	// its last report cycle.
+	MetricsCount int `json:"metrics_count"`
+
+	// LastReport is when the agent last reported metrics to the server.
+	LastReport time.Time `json:"last_report"`
+}
@@ -16,8 +16,43 @@ const (
 	// Usage:
 	//   metric.MType = model.Gauge
 	Gauge = "gauge"
+
+	// Histogram is a metric type that tracks the distribution of observed
+	// values across a fixed set of bucket boundaries, reported as
+	// cumulative _bucket{le=...}/_count/_sum components (Prometheus-style).
+	// Each observation is sent as a single sample via the Value field.
+	//
+	// Usage:
+	//   metric.MType = model.Histogram
+	Histogram = "histogram"
+
+	// Summary is a metric type that tracks streaming quantiles (e.g. p50,
+	// p90, p99) of observed values using a biased-quantile sketch. Each
+	// observation is sent as a single sample via the Value field.
+	//
+	// Usage:
+	//   metric.MType = model.Summary
+	Summary = "summary"
 )
 
+// HistogramValue is the read-only aggregate for a Histogram metric:
+// cumulative per-bucket counts keyed by upper bound (le), plus the total
+// observation count and sum.
+type HistogramValue struct {
+	Buckets map[float64]uint64 `json:"buckets"`
+	Count   uint64             `json:"count"`
+	Sum     float64            `json:"sum"`
+}
+
+// SummaryValue is the read-only streaming-quantile aggregate for a Summary
+// metric: the estimated value at each target quantile, plus the total
+// observation count and sum.
+type SummaryValue struct {
+	Quantiles map[float64]float64 `json:"quantiles"`
+	Count     uint64              `json:"count"`
+	Sum       float64             `json:"sum"`
+}
+
 // Metrics represents a metric value with optional delta and value fields.
 // Only one of Delta (for counters) or Value (for gauges) should be set.
 //
@@ -96,4 +131,20 @@ type Metrics struct {
 	//
 	// This field is optional and may be empty if hash signing is disabled.
 	Hash string `json:"hash,omitempty"`
+
+	// HistogramValue carries the read-only aggregate for a Histogram
+	// metric. It's populated by the server on read and ignored on write -
+	// observations are submitted one at a time via Value.
+	HistogramValue *HistogramValue `json:"histogram,omitempty"`
+
+	// SummaryValue carries the read-only streaming-quantile aggregate for
+	// a Summary metric. It's populated by the server on read and ignored
+	// on write - observations are submitted one at a time via Value.
+	SummaryValue *SummaryValue `json:"summary,omitempty"`
+
+	// AgentID identifies the agent that reported this metric, stamped by
+	// the agent's MetricsService from its AgentIdentity. It's optional and
+	// empty for metrics submitted without an identified agent (e.g. the
+	// ingest protocols, or older agent builds).
+	AgentID string `json:"agent_id,omitempty"`
 }
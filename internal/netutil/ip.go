@@ -7,10 +7,30 @@ import (
 
 // GetLocalIP возвращает локальный IP-адрес клиента
 func GetLocalIP() (string, error) {
+	_, ip, err := selectInterface()
+	if err != nil {
+		return "", err
+	}
+	return ip.String(), nil
+}
+
+// GetLocalIPAndMAC возвращает локальный IP-адрес и MAC-адрес того же
+// сетевого интерфейса, отобранного по тем же правилам, что и GetLocalIP.
+func GetLocalIPAndMAC() (string, string, error) {
+	iface, ip, err := selectInterface()
+	if err != nil {
+		return "", "", err
+	}
+	return ip.String(), iface.HardwareAddr.String(), nil
+}
+
+// selectInterface проходит по всем сетевым интерфейсам и возвращает первый
+// подходящий (активный, не loopback, с IPv4-адресом) вместе с этим адресом.
+func selectInterface() (net.Interface, net.IP, error) {
 	// Получаем все сетевые интерфейсы
 	interfaces, err := net.Interfaces()
 	if err != nil {
-		return "", err
+		return net.Interface{}, nil, err
 	}
 
 	// Проходим по всем интерфейсам и ищем подходящий IP
@@ -39,10 +59,10 @@ func GetLocalIP() (string, error) {
 				continue
 			}
 
-			return ip.String(), nil
+			return iface, ip, nil
 		}
 	}
 
-	// Если не нашли подходящий IP, возвращаем ошибку
-	return "", errors.New("no network interface found")
+	// Если не нашли подходящий интерфейс, возвращаем ошибку
+	return net.Interface{}, nil, errors.New("no network interface found")
 }
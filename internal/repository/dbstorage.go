@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -12,16 +13,30 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/idudko/go-musthave-metrics/internal/model"
 	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 const (
+	// dbBatchSize is the number of metrics at or above which
+	// UpdateMetricsBatch switches from one INSERT per row to the
+	// CopyFrom-staged merge, which pays a fixed per-statement overhead that
+	// only wins out once row count is large enough to amortize it.
 	dbBatchSize = 500
 )
 
 type DBStorage struct {
 	pool *pgxpool.Pool
+
+	// Histogram and Summary metrics are kept in process memory rather than
+	// persisted to Postgres - they're not in the migrated schema, and the
+	// sketches backing them aren't naturally representable as SQL rows.
+	// They reset on restart, unlike gauges/counters.
+	histogramsMu sync.RWMutex
+	histograms   map[string]*Histogram
+	summariesMu  sync.RWMutex
+	summaries    map[string]*Summary
 }
 
 func isRetryableError(err error) bool {
@@ -33,7 +48,19 @@ func isRetryableError(err error) bool {
 	return false
 }
 
+// retryOnError retries operation against Postgres-specific connection
+// errors (see isRetryableError). It's a thin wrapper around
+// retryOnErrorWith so existing DBStorage call sites don't need to change;
+// other backends (e.g. RedisStorage) call retryOnErrorWith directly with
+// their own isRetryable predicate instead.
 func retryOnError(ctx context.Context, operation func() error) error {
+	return retryOnErrorWith(ctx, isRetryableError, operation)
+}
+
+// retryOnErrorWith retries operation, using isRetryable to decide whether a
+// given failure is worth retrying (e.g. a transient connection error) or
+// should be returned immediately (e.g. a data/validation error).
+func retryOnErrorWith(ctx context.Context, isRetryable func(error) bool, operation func() error) error {
 	retryIntervals := []time.Duration{time.Second, time.Second * 3, time.Second * 5}
 
 	for i, interval := range retryIntervals {
@@ -41,7 +68,7 @@ func retryOnError(ctx context.Context, operation func() error) error {
 		if err == nil {
 			return nil // Успех — выходим
 		}
-		if !isRetryableError(err) {
+		if !isRetryable(err) {
 			return err // Ошибка не подлежит повтору — выходим
 		}
 		if i+1 < len(retryIntervals) {
@@ -68,7 +95,11 @@ func NewDBStorage(dsn string) (*DBStorage, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	db := &DBStorage{pool: pool}
+	db := &DBStorage{
+		pool:       pool,
+		histograms: make(map[string]*Histogram),
+		summaries:  make(map[string]*Summary),
+	}
 
 	if err := db.runMigrations(dsn); err != nil {
 		pool.Close()
@@ -175,6 +206,78 @@ func (d *DBStorage) Save(ctx context.Context) error {
 	return nil
 }
 
+func (d *DBStorage) UpdateHistogram(ctx context.Context, name string, value float64) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	d.histogramsMu.Lock()
+	h, ok := d.histograms[name]
+	if !ok {
+		h = NewHistogram(nil)
+		d.histograms[name] = h
+	}
+	d.histogramsMu.Unlock()
+
+	h.Observe(value)
+	return nil
+}
+
+func (d *DBStorage) GetHistograms(ctx context.Context) (map[string]model.HistogramValue, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	d.histogramsMu.RLock()
+	defer d.histogramsMu.RUnlock()
+
+	result := make(map[string]model.HistogramValue, len(d.histograms))
+	for name, h := range d.histograms {
+		result[name] = h.Snapshot()
+	}
+	return result, nil
+}
+
+func (d *DBStorage) ObserveSummary(ctx context.Context, name string, value float64) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	d.summariesMu.Lock()
+	s, ok := d.summaries[name]
+	if !ok {
+		s = NewSummary(nil)
+		d.summaries[name] = s
+	}
+	d.summariesMu.Unlock()
+
+	s.Observe(value)
+	return nil
+}
+
+func (d *DBStorage) GetSummaries(ctx context.Context) (map[string]model.SummaryValue, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	d.summariesMu.RLock()
+	defer d.summariesMu.RUnlock()
+
+	result := make(map[string]model.SummaryValue, len(d.summaries))
+	for name, s := range d.summaries {
+		result[name] = s.Snapshot()
+	}
+	return result, nil
+}
+
 func (d *DBStorage) Ping(ctx context.Context) error {
 	return d.pool.Ping(ctx)
 }
@@ -183,7 +286,23 @@ func (d *DBStorage) Close() {
 	d.pool.Close()
 }
 
+// UpdateMetricsBatch applies a batch of gauge/counter updates atomically.
+// Batches smaller than dbBatchSize go through updateMetricsBatchPerRow, one
+// INSERT ... ON CONFLICT per row; larger batches go through
+// updateMetricsBatchCopyFrom, which stages rows via CopyFrom into temporary
+// tables and merges them with two set-based INSERT ... SELECT statements.
 func (d *DBStorage) UpdateMetricsBatch(ctx context.Context, metrics []model.Metrics) error {
+	if len(metrics) < dbBatchSize {
+		return d.updateMetricsBatchPerRow(ctx, metrics)
+	}
+	return d.updateMetricsBatchCopyFrom(ctx, metrics)
+}
+
+// updateMetricsBatchPerRow is the original small-batch path: one
+// INSERT ... ON CONFLICT per row inside a transaction. Its per-statement
+// round-trip overhead is negligible below dbBatchSize but dominates above
+// it, which is why larger batches use updateMetricsBatchCopyFrom instead.
+func (d *DBStorage) updateMetricsBatchPerRow(ctx context.Context, metrics []model.Metrics) error {
 	return retryOnError(ctx, func() error {
 
 		tx, err := d.pool.Begin(ctx)
@@ -244,3 +363,100 @@ func (d *DBStorage) UpdateMetricsBatch(ctx context.Context, metrics []model.Metr
 		return nil
 	})
 }
+
+// dedupeGauges keeps the last value seen per metric ID, matching "last
+// write wins" gauge semantics when the same ID appears more than once in a
+// batch.
+func dedupeGauges(metrics []model.Metrics) map[string]float64 {
+	values := make(map[string]float64, len(metrics))
+	for _, metric := range metrics {
+		if metric.MType == model.Gauge && metric.Value != nil {
+			values[metric.ID] = *metric.Value
+		}
+	}
+	return values
+}
+
+// sumCounters adds up every delta seen per metric ID, so that ON CONFLICT
+// DO UPDATE SET value = counters.value + tmp.value only applies the
+// combined delta once per ID instead of once per duplicate row.
+func sumCounters(metrics []model.Metrics) map[string]int64 {
+	deltas := make(map[string]int64, len(metrics))
+	for _, metric := range metrics {
+		if metric.MType == model.Counter && metric.Delta != nil {
+			deltas[metric.ID] += *metric.Delta
+		}
+	}
+	return deltas
+}
+
+// updateMetricsBatchCopyFrom stages deduplicated rows into temporary tables
+// via CopyFrom and merges them into gauges/counters with two set-based
+// INSERT ... SELECT ... ON CONFLICT statements, instead of one round trip
+// per row. The temporary tables are ON COMMIT DROP, so they never outlive
+// the transaction.
+func (d *DBStorage) updateMetricsBatchCopyFrom(ctx context.Context, metrics []model.Metrics) error {
+	gauges := dedupeGauges(metrics)
+	counters := sumCounters(metrics)
+
+	return retryOnError(ctx, func() error {
+		tx, err := d.pool.Begin(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() {
+			if err != nil {
+				tx.Rollback(ctx)
+			}
+		}()
+
+		if len(gauges) > 0 {
+			if _, err = tx.Exec(ctx, `CREATE TEMPORARY TABLE tmp_gauges (name text, value double precision) ON COMMIT DROP`); err != nil {
+				return fmt.Errorf("failed to create tmp_gauges: %w", err)
+			}
+
+			rows := make([][]any, 0, len(gauges))
+			for name, value := range gauges {
+				rows = append(rows, []any{name, value})
+			}
+			if _, err = tx.CopyFrom(ctx, pgx.Identifier{"tmp_gauges"}, []string{"name", "value"}, pgx.CopyFromRows(rows)); err != nil {
+				return fmt.Errorf("failed to copy gauges into tmp_gauges: %w", err)
+			}
+
+			if _, err = tx.Exec(ctx, `
+				INSERT INTO gauges (name, value)
+				SELECT name, value FROM tmp_gauges
+				ON CONFLICT (name) DO UPDATE SET value = EXCLUDED.value
+			`); err != nil {
+				return fmt.Errorf("failed to merge tmp_gauges into gauges: %w", err)
+			}
+		}
+
+		if len(counters) > 0 {
+			if _, err = tx.Exec(ctx, `CREATE TEMPORARY TABLE tmp_counters (name text, value bigint) ON COMMIT DROP`); err != nil {
+				return fmt.Errorf("failed to create tmp_counters: %w", err)
+			}
+
+			rows := make([][]any, 0, len(counters))
+			for name, value := range counters {
+				rows = append(rows, []any{name, value})
+			}
+			if _, err = tx.CopyFrom(ctx, pgx.Identifier{"tmp_counters"}, []string{"name", "value"}, pgx.CopyFromRows(rows)); err != nil {
+				return fmt.Errorf("failed to copy counters into tmp_counters: %w", err)
+			}
+
+			if _, err = tx.Exec(ctx, `
+				INSERT INTO counters (name, value)
+				SELECT name, value FROM tmp_counters
+				ON CONFLICT (name) DO UPDATE SET value = counters.value + EXCLUDED.value
+			`); err != nil {
+				return fmt.Errorf("failed to merge tmp_counters into counters: %w", err)
+			}
+		}
+
+		if err = tx.Commit(ctx); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	})
+}
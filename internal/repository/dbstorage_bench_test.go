@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/idudko/go-musthave-metrics/internal/model"
+)
+
+// newBenchDBStorage connects to the Postgres instance pointed at by the
+// TEST_DATABASE_DSN environment variable, skipping the benchmark when it
+// isn't set - there's no in-process Postgres double, so these only run
+// against a real database (e.g. in CI or locally via docker-compose).
+func newBenchDBStorage(b *testing.B) *DBStorage {
+	b.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		b.Skip("TEST_DATABASE_DSN not set, skipping DBStorage benchmark")
+	}
+
+	db, err := NewDBStorage(dsn)
+	if err != nil {
+		b.Fatalf("failed to connect to database: %v", err)
+	}
+	b.Cleanup(db.Close)
+	return db
+}
+
+func benchGaugeBatch(n int) []model.Metrics {
+	metrics := make([]model.Metrics, n)
+	for i := range n {
+		value := float64(i)
+		metrics[i] = model.Metrics{ID: "metric_" + strconv.Itoa(i), MType: model.Gauge, Value: &value}
+	}
+	return metrics
+}
+
+func BenchmarkDBStorage_UpdateMetricsBatch_Small(b *testing.B) {
+	db := newBenchDBStorage(b)
+	ctx := context.Background()
+	metrics := benchGaugeBatch(10)
+
+	b.ResetTimer()
+	for b.Loop() {
+		if err := db.UpdateMetricsBatch(ctx, metrics); err != nil {
+			b.Fatalf("UpdateMetricsBatch failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkDBStorage_UpdateMetricsBatch_Medium(b *testing.B) {
+	db := newBenchDBStorage(b)
+	ctx := context.Background()
+	metrics := benchGaugeBatch(100)
+
+	b.ResetTimer()
+	for b.Loop() {
+		if err := db.UpdateMetricsBatch(ctx, metrics); err != nil {
+			b.Fatalf("UpdateMetricsBatch failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkDBStorage_UpdateMetricsBatch_Large(b *testing.B) {
+	db := newBenchDBStorage(b)
+	ctx := context.Background()
+	metrics := benchGaugeBatch(1000)
+
+	b.ResetTimer()
+	for b.Loop() {
+		if err := db.UpdateMetricsBatch(ctx, metrics); err != nil {
+			b.Fatalf("UpdateMetricsBatch failed: %v", err)
+		}
+	}
+}
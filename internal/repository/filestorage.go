@@ -3,18 +3,28 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"sync"
 	"time"
 )
 
+// FileStorage is a Storage backed by an in-memory MemStorage, periodically
+// (or, with interval 0, synchronously) checkpointed to a JSON snapshot on
+// disk at path. Between checkpoints, every UpdateGauge/UpdateCounter is
+// also appended to a write-ahead log (see wal.go) so a crash between
+// snapshots loses nothing: restore replays the WAL on top of the last
+// snapshot instead of just the snapshot alone.
 type FileStorage struct {
 	*MemStorage
 	path     string
 	interval time.Duration
 	syncSave bool
 	mu       sync.RWMutex
+
+	wal            *walWriter
+	checkpointPath string
 }
 
 type storageData struct {
@@ -22,12 +32,65 @@ type storageData struct {
 	Counters map[string]int64   `json:"counters"`
 }
 
-func NewFileStorage(path string, interval int, restore bool) (*FileStorage, error) {
+// FileStorageOption customizes a FileStorage's WAL behavior at construction.
+type FileStorageOption func(*fileStorageConfig)
+
+type fileStorageConfig struct {
+	walPath        string
+	checkpointPath string
+	walPolicy      WALFsyncPolicy
+	walSyncEvery   time.Duration
+}
+
+// WithWALPath overrides the WAL file's path. Defaults to path + ".wal".
+func WithWALPath(walPath string) FileStorageOption {
+	return func(c *fileStorageConfig) { c.walPath = walPath }
+}
+
+// WithCheckpointPath overrides the checkpoint header's path. Defaults to
+// path + ".checkpoint".
+func WithCheckpointPath(checkpointPath string) FileStorageOption {
+	return func(c *fileStorageConfig) { c.checkpointPath = checkpointPath }
+}
+
+// WithWALFsyncPolicy sets how often the WAL is fsynced. Defaults to
+// WALFsyncInterval.
+func WithWALFsyncPolicy(policy WALFsyncPolicy) FileStorageOption {
+	return func(c *fileStorageConfig) { c.walPolicy = policy }
+}
+
+// WithWALSyncInterval sets the fsync period WALFsyncInterval uses. Defaults
+// to defaultWALSyncInterval; meaningless under any other WALFsyncPolicy.
+func WithWALSyncInterval(d time.Duration) FileStorageOption {
+	return func(c *fileStorageConfig) { c.walSyncEvery = d }
+}
+
+// NewFileStorage creates a FileStorage checkpointing to path every interval
+// seconds (synchronously, on every update, if interval is 0), restoring
+// from path and its WAL first if restore is true.
+func NewFileStorage(path string, interval int, restore bool, opts ...FileStorageOption) (*FileStorage, error) {
+	cfg := fileStorageConfig{
+		walPath:        path + ".wal",
+		checkpointPath: path + ".checkpoint",
+		walPolicy:      WALFsyncInterval,
+		walSyncEvery:   defaultWALSyncInterval,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	wal, err := openWAL(cfg.walPath, cfg.walPolicy, cfg.walSyncEvery)
+	if err != nil {
+		return nil, fmt.Errorf("opening WAL at %s: %w", cfg.walPath, err)
+	}
+
 	fs := &FileStorage{
-		MemStorage: NewMemStorage(),
-		path:       path,
-		interval:   time.Duration(interval) * time.Second,
-		syncSave:   interval == 0,
+		MemStorage:     NewMemStorage(),
+		path:           path,
+		interval:       time.Duration(interval) * time.Second,
+		syncSave:       interval == 0,
+		wal:            wal,
+		checkpointPath: cfg.checkpointPath,
 	}
 
 	ctx := context.Background()
@@ -43,7 +106,36 @@ func NewFileStorage(path string, interval int, restore bool) (*FileStorage, erro
 	return fs, nil
 }
 
+// restore loads the last snapshot at f.path, then replays every WAL record
+// with a seq greater than the snapshot's checkpointed high-water seq on top
+// of it, so updates written after the last checkpoint but before a crash
+// aren't lost.
 func (f *FileStorage) restore(ctx context.Context) error {
+	checkpointSeq, err := readCheckpoint(f.checkpointPath)
+	if err != nil {
+		return err
+	}
+
+	if err := f.restoreSnapshot(ctx); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	highWater, err := f.wal.replay(checkpointSeq,
+		func(name string, value float64) { f.MemStorage.UpdateGauge(ctx, name, value) },
+		func(name string, delta int64) { f.MemStorage.UpdateCounter(ctx, name, delta) },
+	)
+	if err != nil {
+		return err
+	}
+
+	f.wal.mu.Lock()
+	f.wal.seq = highWater
+	f.wal.mu.Unlock()
+	return nil
+}
+
+// restoreSnapshot loads f.path's JSON snapshot into f.MemStorage.
+func (f *FileStorage) restoreSnapshot(ctx context.Context) error {
 	file, err := os.Open(f.path)
 	if err != nil {
 		return err
@@ -64,6 +156,10 @@ func (f *FileStorage) restore(ctx context.Context) error {
 	return nil
 }
 
+// saveMetrics checkpoints the current in-memory state: it writes a new
+// snapshot atomically (tmpfile+rename), records the WAL's current seq as
+// that snapshot's checkpoint, and truncates the WAL, since every record up
+// to that seq is now folded into the snapshot.
 func (f *FileStorage) saveMetrics(ctx context.Context) error {
 
 	f.mu.Lock()
@@ -98,8 +194,25 @@ func (f *FileStorage) saveMetrics(ctx context.Context) error {
 	if err := os.Rename(tmpfile.Name(), f.path); err != nil {
 		return err
 	}
+	if err := tmpfile.Close(); err != nil {
+		return err
+	}
+
+	f.wal.mu.Lock()
+	seq := f.wal.seq
+	f.wal.mu.Unlock()
+
+	if err := writeCheckpoint(f.checkpointPath, seq); err != nil {
+		return err
+	}
+	return f.wal.reset(seq)
+}
 
-	return tmpfile.Close()
+// WALStats returns a point-in-time snapshot of the WAL's activity counters,
+// for the audit subsystem (or any other caller) to observe how much it has
+// appended, replayed, and checkpointed.
+func (f *FileStorage) WALStats() WALStats {
+	return f.wal.stats()
 }
 
 func (f *FileStorage) Save(ctx context.Context) error {
@@ -123,6 +236,9 @@ func (f *FileStorage) UpdateGauge(ctx context.Context, name string, value float6
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	f.MemStorage.UpdateGauge(ctx, name, value)
+	if err := f.wal.append(walRecord{Ts: time.Now().UnixNano(), Type: walRecordGauge, Name: name, Value: value}); err != nil {
+		log.Printf("error appending WAL record: %v", err)
+	}
 	if f.syncSave {
 		if err := f.saveMetrics(ctx); err != nil {
 			log.Printf("error saving metrics: %v", err)
@@ -140,6 +256,9 @@ func (f *FileStorage) UpdateCounter(ctx context.Context, name string, value int6
 	f.mu.Lock()
 	defer f.mu.Unlock()
 	f.MemStorage.UpdateCounter(ctx, name, value)
+	if err := f.wal.append(walRecord{Ts: time.Now().UnixNano(), Type: walRecordCounter, Name: name, Delta: value}); err != nil {
+		log.Printf("error appending WAL record: %v", err)
+	}
 	if f.syncSave {
 		if err := f.saveMetrics(ctx); err != nil {
 			log.Printf("error saving metrics: %v", err)
@@ -160,3 +279,9 @@ func (f *FileStorage) startAutoSave(ctx context.Context) {
 		}
 	}()
 }
+
+// Close flushes and closes the WAL file. It does not checkpoint; callers
+// that want a final snapshot should call Save first.
+func (f *FileStorage) Close() error {
+	return f.wal.close()
+}
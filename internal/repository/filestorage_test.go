@@ -0,0 +1,326 @@
+package repository
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestFileStorage_RestoreFromSnapshotOnly(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "metrics.json")
+
+	fs, err := NewFileStorage(path, 3600, false)
+	if err != nil {
+		t.Fatalf("NewFileStorage: %v", err)
+	}
+	if err := fs.UpdateGauge(ctx, "Alloc", 42.5); err != nil {
+		t.Fatalf("UpdateGauge: %v", err)
+	}
+	if err := fs.UpdateCounter(ctx, "PollCount", 3); err != nil {
+		t.Fatalf("UpdateCounter: %v", err)
+	}
+	if err := fs.saveMetrics(ctx); err != nil {
+		t.Fatalf("saveMetrics: %v", err)
+	}
+	if err := fs.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	restored, err := NewFileStorage(path, 3600, true)
+	if err != nil {
+		t.Fatalf("NewFileStorage (restore): %v", err)
+	}
+	defer restored.Close()
+
+	gauges, err := restored.GetGauges(ctx)
+	if err != nil {
+		t.Fatalf("GetGauges: %v", err)
+	}
+	if gauges["Alloc"] != 42.5 {
+		t.Errorf("Alloc = %v, want 42.5", gauges["Alloc"])
+	}
+	counters, err := restored.GetCounters(ctx)
+	if err != nil {
+		t.Fatalf("GetCounters: %v", err)
+	}
+	if counters["PollCount"] != 3 {
+		t.Errorf("PollCount = %v, want 3", counters["PollCount"])
+	}
+}
+
+func TestFileStorage_WALReplaysUpdatesSinceLastCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "metrics.json")
+
+	fs, err := NewFileStorage(path, 3600, false)
+	if err != nil {
+		t.Fatalf("NewFileStorage: %v", err)
+	}
+	if err := fs.UpdateCounter(ctx, "PollCount", 1); err != nil {
+		t.Fatalf("UpdateCounter: %v", err)
+	}
+	if err := fs.saveMetrics(ctx); err != nil {
+		t.Fatalf("saveMetrics: %v", err)
+	}
+	// These updates land only in the WAL - no checkpoint follows - so
+	// restore must replay them on top of the snapshot above.
+	if err := fs.UpdateCounter(ctx, "PollCount", 1); err != nil {
+		t.Fatalf("UpdateCounter: %v", err)
+	}
+	if err := fs.UpdateGauge(ctx, "Alloc", 7); err != nil {
+		t.Fatalf("UpdateGauge: %v", err)
+	}
+	if err := fs.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	restored, err := NewFileStorage(path, 3600, true)
+	if err != nil {
+		t.Fatalf("NewFileStorage (restore): %v", err)
+	}
+	defer restored.Close()
+
+	counters, err := restored.GetCounters(ctx)
+	if err != nil {
+		t.Fatalf("GetCounters: %v", err)
+	}
+	if counters["PollCount"] != 2 {
+		t.Errorf("PollCount = %v, want 2 (1 from snapshot + 1 from WAL replay)", counters["PollCount"])
+	}
+	gauges, err := restored.GetGauges(ctx)
+	if err != nil {
+		t.Fatalf("GetGauges: %v", err)
+	}
+	if gauges["Alloc"] != 7 {
+		t.Errorf("Alloc = %v, want 7", gauges["Alloc"])
+	}
+
+	stats := restored.WALStats()
+	if stats.Replayed != 2 {
+		t.Errorf("WALStats().Replayed = %d, want 2", stats.Replayed)
+	}
+}
+
+func TestFileStorage_CheckpointTruncatesWAL(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "metrics.json")
+
+	fs, err := NewFileStorage(path, 3600, false)
+	if err != nil {
+		t.Fatalf("NewFileStorage: %v", err)
+	}
+	defer fs.Close()
+
+	for i := 0; i < 5; i++ {
+		if err := fs.UpdateCounter(ctx, "PollCount", 1); err != nil {
+			t.Fatalf("UpdateCounter: %v", err)
+		}
+	}
+	if got := fs.WALStats().Appended; got != 5 {
+		t.Fatalf("Appended = %d, want 5", got)
+	}
+
+	if err := fs.saveMetrics(ctx); err != nil {
+		t.Fatalf("saveMetrics: %v", err)
+	}
+
+	info, err := os.Stat(fs.wal.path)
+	if err != nil {
+		t.Fatalf("stat WAL: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("WAL file size = %d after checkpoint, want 0", info.Size())
+	}
+	if got := fs.WALStats().Checkpoints; got != 1 {
+		t.Errorf("Checkpoints = %d, want 1", got)
+	}
+
+	// A further update's seq must continue past the checkpoint rather
+	// than restarting from 0, so a second checkpoint's replay window
+	// can't collide with the first.
+	if err := fs.UpdateCounter(ctx, "PollCount", 1); err != nil {
+		t.Fatalf("UpdateCounter: %v", err)
+	}
+	if fs.wal.seq != 6 {
+		t.Errorf("wal.seq = %d after checkpoint + 1 update, want 6", fs.wal.seq)
+	}
+}
+
+// walCrashHelperEnv, when set, turns this test binary into the crash-
+// recovery helper process TestFileStorage_CrashRecovery execs and kills
+// mid-run, following the standard os/exec "TestMain re-dispatch" pattern
+// for subprocess tests.
+const walCrashHelperEnv = "GO_WANT_WAL_CRASH_HELPER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(walCrashHelperEnv) == "1" {
+		runWALCrashHelper()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// runWALCrashHelper repeatedly increments a counter in a FileStorage at
+// FILESTORAGE_WAL_PATH, printing "ok" to stdout (with fsync-always, so a
+// printed line means the record is durable) after each one, then blocks
+// forever so the parent test - not a clean exit - is what ends it.
+func runWALCrashHelper() {
+	path := os.Getenv("FILESTORAGE_WAL_PATH")
+	fs, err := NewFileStorage(path, 3600, false, WithWALFsyncPolicy(WALFsyncAlways))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ctx := context.Background()
+	for {
+		if err := fs.UpdateCounter(ctx, "PollCount", 1); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println("ok")
+	}
+}
+
+// TestFileStorage_CrashRecovery kills a child process mid-way through a
+// string of counter increments and verifies a fresh FileStorage restored
+// from the same path recovers exactly the increments the child had
+// acknowledged (via WAL replay), proving nothing between checkpoints is
+// lost to a crash.
+func TestFileStorage_CrashRecovery(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping subprocess crash-recovery test in -short mode")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "metrics.json")
+
+	const wantAcked = 20
+
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(),
+		walCrashHelperEnv+"=1",
+		"FILESTORAGE_WAL_PATH="+path,
+	)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("StdoutPipe: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { _ = cmd.Process.Kill() })
+
+	acked := 0
+	scanner := bufio.NewScanner(stdout)
+	for acked < wantAcked && scanner.Scan() {
+		acked++
+	}
+	if acked != wantAcked {
+		t.Fatalf("child only acknowledged %d/%d increments before its stdout closed", acked, wantAcked)
+	}
+
+	if err := cmd.Process.Kill(); err != nil {
+		t.Fatalf("Kill: %v", err)
+	}
+	_ = cmd.Wait()
+
+	restored, err := NewFileStorage(path, 3600, true)
+	if err != nil {
+		t.Fatalf("NewFileStorage (restore after crash): %v", err)
+	}
+	defer restored.Close()
+
+	counters, err := restored.GetCounters(context.Background())
+	if err != nil {
+		t.Fatalf("GetCounters: %v", err)
+	}
+	// The child may have durably written (and printed "ok" for) one or
+	// two more increments than we read before the kill signal landed -
+	// that's fine, nothing acknowledged was lost either way. What would
+	// fail this test is recovering fewer than wantAcked: an increment we
+	// saw "ok" for that the WAL failed to make durable.
+	if counters["PollCount"] < int64(wantAcked) {
+		t.Errorf("PollCount after crash recovery = %v, want >= %d (no acknowledged increment lost)", counters["PollCount"], wantAcked)
+	}
+}
+
+func TestParseWALFsyncPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    WALFsyncPolicy
+		wantErr bool
+	}{
+		{"always", WALFsyncAlways, false},
+		{"interval", WALFsyncInterval, false},
+		{"never", WALFsyncNever, false},
+		{"bogus", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseWALFsyncPolicy(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseWALFsyncPolicy(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ParseWALFsyncPolicy(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileStorage_WALSurvivesInterleavedGaugesAndCounters(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "metrics.json")
+
+	fs, err := NewFileStorage(path, 3600, false)
+	if err != nil {
+		t.Fatalf("NewFileStorage: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		name := "Metric" + strconv.Itoa(i%3)
+		if i%2 == 0 {
+			if err := fs.UpdateCounter(ctx, name, int64(i)); err != nil {
+				t.Fatalf("UpdateCounter: %v", err)
+			}
+		} else {
+			if err := fs.UpdateGauge(ctx, name, float64(i)); err != nil {
+				t.Fatalf("UpdateGauge: %v", err)
+			}
+		}
+	}
+	if err := fs.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	restored, err := NewFileStorage(path, 3600, true)
+	if err != nil {
+		t.Fatalf("NewFileStorage (restore): %v", err)
+	}
+	defer restored.Close()
+
+	wantCounters, err := fs.GetCounters(ctx)
+	if err != nil {
+		t.Fatalf("GetCounters (original): %v", err)
+	}
+	gotCounters, err := restored.GetCounters(ctx)
+	if err != nil {
+		t.Fatalf("GetCounters (restored): %v", err)
+	}
+	for name, want := range wantCounters {
+		if gotCounters[name] != want {
+			t.Errorf("counter %s = %v, want %v", name, gotCounters[name], want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/idudko/go-musthave-metrics/internal/model"
+)
+
+// defaultHistogramBuckets mirrors the default bucket boundaries Prometheus
+// client libraries use.
+var defaultHistogramBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram tracks the distribution of observed values across a fixed set
+// of cumulative bucket boundaries, plus a running count and sum - the same
+// shape Prometheus histograms expose as _bucket{le=...}/_count/_sum.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64 // sorted upper bounds (le)
+	counts  []uint64  // counts[i] is the cumulative count for buckets[i]
+	count   uint64
+	sum     float64
+}
+
+// NewHistogram creates a Histogram with the given bucket upper bounds. A nil
+// or empty buckets slice falls back to defaultHistogramBuckets.
+func NewHistogram(buckets []float64) *Histogram {
+	if len(buckets) == 0 {
+		buckets = defaultHistogramBuckets
+	}
+	sorted := make([]float64, len(buckets))
+	copy(sorted, buckets)
+	sort.Float64s(sorted)
+
+	return &Histogram{buckets: sorted, counts: make([]uint64, len(sorted))}
+}
+
+// Observe records a single observation, incrementing every bucket whose
+// upper bound is >= v.
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, le := range h.buckets {
+		if v <= le {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// Snapshot returns a point-in-time copy of the histogram's aggregate.
+func (h *Histogram) Snapshot() model.HistogramValue {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	buckets := make(map[float64]uint64, len(h.buckets))
+	for i, le := range h.buckets {
+		buckets[le] = h.counts[i]
+	}
+	return model.HistogramValue{Buckets: buckets, Count: h.count, Sum: h.sum}
+}
@@ -2,32 +2,73 @@ package repository
 
 import (
 	"context"
+	"maps"
 	"sync"
 	"sync/atomic"
+
+	"github.com/idudko/go-musthave-metrics/internal/model"
+	"github.com/idudko/go-musthave-metrics/pkg/quantile"
 )
 
+// defaultMaxCardinality bounds the number of distinct metric IDs MemStorage
+// will track per metric type, mirroring the guardrails Prometheus-style
+// ingestion systems use to prevent unbounded memory growth from label/ID
+// explosions.
+const defaultMaxCardinality = 10_000
+
 type MemStorage struct {
-	gauges         map[string]float64
-	counters       map[string]int64
-	cachedGauges   atomic.Value // stores *map[string]float64
-	cachedCounters atomic.Value // stores *map[string]int64
-	version        atomic.Int64
-	mu             sync.RWMutex
+	gauges   map[string]float64
+	counters map[string]int64
+	mu       sync.Mutex // serializes writers; readers never take this lock
+
+	cachedGauges   atomic.Value // stores *map[string]float64, immutable snapshot
+	cachedCounters atomic.Value // stores *map[string]int64, immutable snapshot
+
+	maxCardinality int
+	metricsDropped atomic.Int64
+
+	histogramBuckets []float64
+	summaryTargets   quantile.Targets
+
+	histogramsMu sync.RWMutex
+	histograms   map[string]*Histogram
+	summariesMu  sync.RWMutex
+	summaries    map[string]*Summary
 }
 
 func NewMemStorage() *MemStorage {
+	return NewMemStorageWithCardinality(defaultMaxCardinality)
+}
+
+// NewMemStorageWithCardinality creates a MemStorage that rejects new metric
+// IDs (per type) once maxCardinality distinct IDs are already stored.
+// Existing IDs can still be updated past the limit. A maxCardinality <= 0
+// disables the limit.
+func NewMemStorageWithCardinality(maxCardinality int) *MemStorage {
+	return NewMemStorageWithConfig(maxCardinality, nil, nil)
+}
+
+// NewMemStorageWithConfig creates a MemStorage with full control over its
+// cardinality limit, Histogram bucket boundaries, and Summary target
+// quantiles/rank-error. A nil histogramBuckets or summaryTargets falls back
+// to the package defaults (Prometheus-style buckets; p50/p90/p99 at 0.01
+// rank error).
+func NewMemStorageWithConfig(maxCardinality int, histogramBuckets []float64, summaryTargets quantile.Targets) *MemStorage {
 	initialGauges := make(map[string]float64)
 	initialCounters := make(map[string]int64)
 
 	s := &MemStorage{
-		gauges:   initialGauges,
-		counters: initialCounters,
+		gauges:           initialGauges,
+		counters:         initialCounters,
+		maxCardinality:   maxCardinality,
+		histogramBuckets: histogramBuckets,
+		summaryTargets:   summaryTargets,
+		histograms:       make(map[string]*Histogram),
+		summaries:        make(map[string]*Summary),
 	}
 
-	// Store initial cached maps
 	s.cachedGauges.Store(&initialGauges)
 	s.cachedCounters.Store(&initialCounters)
-	s.version.Store(0)
 
 	return s
 }
@@ -38,10 +79,22 @@ func (s *MemStorage) UpdateGauge(ctx context.Context, name string, value float64
 		return ctx.Err()
 	default:
 	}
+
 	s.mu.Lock()
-	s.gauges[name] = value
-	s.version.Add(1)
-	s.mu.Unlock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.gauges[name]; !exists && s.atCardinalityLimit(len(s.gauges)) {
+		s.metricsDropped.Add(1)
+		return nil
+	}
+
+	// Copy-on-write: build a new map so the snapshot readers hold via
+	// cachedGauges is never mutated in place.
+	next := maps.Clone(s.gauges)
+	next[name] = value
+	s.gauges = next
+	s.cachedGauges.Store(&next)
+
 	return nil
 }
 
@@ -51,20 +104,43 @@ func (s *MemStorage) UpdateCounter(ctx context.Context, name string, value int64
 		return ctx.Err()
 	default:
 	}
+
 	s.mu.Lock()
-	s.counters[name] += value
-	s.version.Add(1)
-	s.mu.Unlock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.counters[name]; !exists && s.atCardinalityLimit(len(s.counters)) {
+		s.metricsDropped.Add(1)
+		return nil
+	}
+
+	next := maps.Clone(s.counters)
+	next[name] += value
+	s.counters = next
+	s.cachedCounters.Store(&next)
+
 	return nil
 }
 
+// atCardinalityLimit reports whether adding one more distinct metric ID would
+// exceed s.maxCardinality. Must be called with s.mu held.
+func (s *MemStorage) atCardinalityLimit(currentCount int) bool {
+	return s.maxCardinality > 0 && currentCount >= s.maxCardinality
+}
+
+// MetricsDropped returns the number of metric updates rejected so far because
+// they would have exceeded MaxCardinality, mirroring a metrics_dropped_total
+// counter.
+func (s *MemStorage) MetricsDropped() int64 {
+	return s.metricsDropped.Load()
+}
+
 func (s *MemStorage) GetGauges(ctx context.Context) (map[string]float64, error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	default:
 	}
-	// Return cached map - no allocation on read
+	// Return cached snapshot - no allocation, no lock on read path.
 	if cached := s.cachedGauges.Load(); cached != nil {
 		return *cached.(*map[string]float64), nil
 	}
@@ -77,7 +153,7 @@ func (s *MemStorage) GetCounters(ctx context.Context) (map[string]int64, error)
 		return nil, ctx.Err()
 	default:
 	}
-	// Return cached map - no allocation on read
+	// Return cached snapshot - no allocation, no lock on read path.
 	if cached := s.cachedCounters.Load(); cached != nil {
 		return *cached.(*map[string]int64), nil
 	}
@@ -92,3 +168,129 @@ func (s *MemStorage) Save(ctx context.Context) error {
 	}
 	return nil
 }
+
+func (s *MemStorage) UpdateHistogram(ctx context.Context, name string, value float64) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	h, err := s.histogramFor(name)
+	if err != nil {
+		return err
+	}
+	if h == nil {
+		// Dropped for exceeding MaxCardinality.
+		return nil
+	}
+	h.Observe(value)
+	return nil
+}
+
+// histogramFor returns the Histogram for name, creating it if this is the
+// first observation and MaxCardinality allows it. A nil, nil return means
+// the metric was dropped for exceeding MaxCardinality.
+func (s *MemStorage) histogramFor(name string) (*Histogram, error) {
+	s.histogramsMu.RLock()
+	h, ok := s.histograms[name]
+	s.histogramsMu.RUnlock()
+	if ok {
+		return h, nil
+	}
+
+	s.histogramsMu.Lock()
+	defer s.histogramsMu.Unlock()
+
+	if h, ok := s.histograms[name]; ok {
+		return h, nil
+	}
+	if s.atCardinalityLimit(len(s.histograms)) {
+		s.metricsDropped.Add(1)
+		return nil, nil
+	}
+
+	h = NewHistogram(s.histogramBuckets)
+	s.histograms[name] = h
+	return h, nil
+}
+
+func (s *MemStorage) GetHistograms(ctx context.Context) (map[string]model.HistogramValue, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.histogramsMu.RLock()
+	defer s.histogramsMu.RUnlock()
+
+	result := make(map[string]model.HistogramValue, len(s.histograms))
+	for name, h := range s.histograms {
+		result[name] = h.Snapshot()
+	}
+	return result, nil
+}
+
+func (s *MemStorage) ObserveSummary(ctx context.Context, name string, value float64) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	sm, err := s.summaryFor(name)
+	if err != nil {
+		return err
+	}
+	if sm == nil {
+		// Dropped for exceeding MaxCardinality.
+		return nil
+	}
+	sm.Observe(value)
+	return nil
+}
+
+// summaryFor returns the Summary for name, creating it if this is the first
+// observation and MaxCardinality allows it. A nil, nil return means the
+// metric was dropped for exceeding MaxCardinality.
+func (s *MemStorage) summaryFor(name string) (*Summary, error) {
+	s.summariesMu.RLock()
+	sm, ok := s.summaries[name]
+	s.summariesMu.RUnlock()
+	if ok {
+		return sm, nil
+	}
+
+	s.summariesMu.Lock()
+	defer s.summariesMu.Unlock()
+
+	if sm, ok := s.summaries[name]; ok {
+		return sm, nil
+	}
+	if s.atCardinalityLimit(len(s.summaries)) {
+		s.metricsDropped.Add(1)
+		return nil, nil
+	}
+
+	sm = NewSummary(s.summaryTargets)
+	s.summaries[name] = sm
+	return sm, nil
+}
+
+func (s *MemStorage) GetSummaries(ctx context.Context) (map[string]model.SummaryValue, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	s.summariesMu.RLock()
+	defer s.summariesMu.RUnlock()
+
+	result := make(map[string]model.SummaryValue, len(s.summaries))
+	for name, sm := range s.summaries {
+		result[name] = sm.Snapshot()
+	}
+	return result, nil
+}
@@ -3,9 +3,129 @@ package repository
 import (
 	"context"
 	"strconv"
+	"sync"
 	"testing"
 )
 
+// TestMemStorage_SnapshotIsImmutable verifies that a map returned by
+// GetGauges/GetCounters is never mutated by later writes - i.e. it's a
+// point-in-time, copy-on-write snapshot rather than a live view.
+func TestMemStorage_SnapshotIsImmutable(t *testing.T) {
+	s := NewMemStorage()
+	ctx := context.Background()
+
+	if err := s.UpdateGauge(ctx, "g", 1); err != nil {
+		t.Fatalf("UpdateGauge: %v", err)
+	}
+	snapshot, err := s.GetGauges(ctx)
+	if err != nil {
+		t.Fatalf("GetGauges: %v", err)
+	}
+
+	if err := s.UpdateGauge(ctx, "g", 2); err != nil {
+		t.Fatalf("UpdateGauge: %v", err)
+	}
+	if err := s.UpdateGauge(ctx, "new", 3); err != nil {
+		t.Fatalf("UpdateGauge: %v", err)
+	}
+
+	if got := snapshot["g"]; got != 1 {
+		t.Errorf("snapshot mutated after later write: got %v, want 1", got)
+	}
+	if _, ok := snapshot["new"]; ok {
+		t.Errorf("snapshot gained a key added by a later write")
+	}
+}
+
+// TestMemStorage_ConcurrentReadWriteRace hammers concurrent readers against
+// churning writers under `go test -race` to prove reads never observe a map
+// that's being mutated in place.
+func TestMemStorage_ConcurrentReadWriteRace(t *testing.T) {
+	s := NewMemStorage()
+	ctx := context.Background()
+
+	const writers = 8
+	const readers = 8
+	const iterations = 200
+
+	var wg sync.WaitGroup
+	wg.Add(writers + readers)
+
+	for w := range writers {
+		go func(w int) {
+			defer wg.Done()
+			name := "metric_" + strconv.Itoa(w)
+			for i := range iterations {
+				s.UpdateGauge(ctx, name, float64(i))
+				s.UpdateCounter(ctx, name, 1)
+			}
+		}(w)
+	}
+
+	for range readers {
+		go func() {
+			defer wg.Done()
+			for range iterations {
+				gauges, err := s.GetGauges(ctx)
+				if err != nil {
+					t.Errorf("GetGauges: %v", err)
+					return
+				}
+				for _, v := range gauges {
+					_ = v
+				}
+				counters, err := s.GetCounters(ctx)
+				if err != nil {
+					t.Errorf("GetCounters: %v", err)
+					return
+				}
+				for _, v := range counters {
+					_ = v
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// TestMemStorage_MaxCardinality verifies that updates for new metric IDs are
+// rejected (and counted) once MaxCardinality is reached, while existing IDs
+// can still be updated.
+func TestMemStorage_MaxCardinality(t *testing.T) {
+	s := NewMemStorageWithCardinality(2)
+	ctx := context.Background()
+
+	if err := s.UpdateGauge(ctx, "a", 1); err != nil {
+		t.Fatalf("UpdateGauge a: %v", err)
+	}
+	if err := s.UpdateGauge(ctx, "b", 2); err != nil {
+		t.Fatalf("UpdateGauge b: %v", err)
+	}
+	// Updating an existing ID past the limit must still succeed.
+	if err := s.UpdateGauge(ctx, "a", 10); err != nil {
+		t.Fatalf("UpdateGauge existing a: %v", err)
+	}
+	// A brand new ID past the limit must be dropped, not stored.
+	if err := s.UpdateGauge(ctx, "c", 3); err != nil {
+		t.Fatalf("UpdateGauge c: %v", err)
+	}
+
+	gauges, err := s.GetGauges(ctx)
+	if err != nil {
+		t.Fatalf("GetGauges: %v", err)
+	}
+	if _, ok := gauges["c"]; ok {
+		t.Errorf("metric \"c\" should have been dropped past MaxCardinality")
+	}
+	if got := gauges["a"]; got != 10 {
+		t.Errorf("existing metric \"a\" not updated: got %v, want 10", got)
+	}
+	if dropped := s.MetricsDropped(); dropped != 1 {
+		t.Errorf("MetricsDropped() = %d, want 1", dropped)
+	}
+}
+
 // Benchmarks
 func BenchmarkMemStorage_UpdateGauge(b *testing.B) {
 	s := NewMemStorage()
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/idudko/go-musthave-metrics/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisGaugesKey and redisCountersKey are the hash keys gauges and
+	// counters are stored under: HSET redisGaugesKey name value, etc.
+	redisGaugesKey   = "metrics:gauges"
+	redisCountersKey = "metrics:counters"
+)
+
+// RedisStorage implements Storage backed by Redis: gauges and counters
+// live in the "metrics:gauges"/"metrics:counters" hashes, with HSET
+// overwriting gauges and HINCRBY giving UpdateCounter's "add to the
+// existing value" semantic atomically, without needing a transaction.
+//
+// Histogram and Summary metrics are kept in process memory rather than
+// persisted to Redis - as with DBStorage, they're not naturally
+// representable as a flat hash and reset on restart.
+type RedisStorage struct {
+	client *redis.Client
+
+	histogramsMu sync.RWMutex
+	histograms   map[string]*Histogram
+	summariesMu  sync.RWMutex
+	summaries    map[string]*Summary
+}
+
+// NewRedisStorage creates a RedisStorage connected to addr (e.g.
+// "localhost:6379").
+func NewRedisStorage(addr string) (*RedisStorage, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to ping redis: %w", err)
+	}
+
+	return &RedisStorage{
+		client:     client,
+		histograms: make(map[string]*Histogram),
+		summaries:  make(map[string]*Summary),
+	}, nil
+}
+
+// isRetryableRedisError reports whether err is a transient network failure
+// worth retrying, as opposed to redis.Nil (a normal "key not found" result)
+// or a command/argument error that would fail again unchanged.
+func isRetryableRedisError(err error) bool {
+	if errors.Is(err, redis.Nil) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func (r *RedisStorage) UpdateGauge(ctx context.Context, name string, value float64) error {
+	return retryOnErrorWith(ctx, isRetryableRedisError, func() error {
+		return r.client.HSet(ctx, redisGaugesKey, name, value).Err()
+	})
+}
+
+func (r *RedisStorage) UpdateCounter(ctx context.Context, name string, value int64) error {
+	return retryOnErrorWith(ctx, isRetryableRedisError, func() error {
+		return r.client.HIncrBy(ctx, redisCountersKey, name, value).Err()
+	})
+}
+
+func (r *RedisStorage) GetGauges(ctx context.Context) (map[string]float64, error) {
+	result := make(map[string]float64)
+	err := retryOnErrorWith(ctx, isRetryableRedisError, func() error {
+		raw, err := r.client.HGetAll(ctx, redisGaugesKey).Result()
+		if err != nil {
+			return err
+		}
+		for name, s := range raw {
+			value, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return fmt.Errorf("invalid gauge value for %q: %w", name, err)
+			}
+			result[name] = value
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (r *RedisStorage) GetCounters(ctx context.Context) (map[string]int64, error) {
+	result := make(map[string]int64)
+	err := retryOnErrorWith(ctx, isRetryableRedisError, func() error {
+		raw, err := r.client.HGetAll(ctx, redisCountersKey).Result()
+		if err != nil {
+			return err
+		}
+		for name, s := range raw {
+			value, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid counter value for %q: %w", name, err)
+			}
+			result[name] = value
+		}
+		return nil
+	})
+	return result, err
+}
+
+// UpdateMetricsBatch applies every gauge/counter update in metrics via a
+// single pipelined round trip, using the same HSET/HINCRBY commands as the
+// single-metric paths so the pipeline preserves UpdateCounter's "add to the
+// existing value" semantic.
+func (r *RedisStorage) UpdateMetricsBatch(ctx context.Context, metrics []model.Metrics) error {
+	return retryOnErrorWith(ctx, isRetryableRedisError, func() error {
+		pipe := r.client.Pipeline()
+
+		for _, metric := range metrics {
+			switch metric.MType {
+			case model.Gauge:
+				if metric.Value != nil {
+					pipe.HSet(ctx, redisGaugesKey, metric.ID, *metric.Value)
+				}
+			case model.Counter:
+				if metric.Delta != nil {
+					pipe.HIncrBy(ctx, redisCountersKey, metric.ID, *metric.Delta)
+				}
+			}
+		}
+
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+}
+
+func (r *RedisStorage) Save(ctx context.Context) error {
+	return nil
+}
+
+func (r *RedisStorage) UpdateHistogram(ctx context.Context, name string, value float64) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	r.histogramsMu.Lock()
+	h, ok := r.histograms[name]
+	if !ok {
+		h = NewHistogram(nil)
+		r.histograms[name] = h
+	}
+	r.histogramsMu.Unlock()
+
+	h.Observe(value)
+	return nil
+}
+
+func (r *RedisStorage) GetHistograms(ctx context.Context) (map[string]model.HistogramValue, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	r.histogramsMu.RLock()
+	defer r.histogramsMu.RUnlock()
+
+	result := make(map[string]model.HistogramValue, len(r.histograms))
+	for name, h := range r.histograms {
+		result[name] = h.Snapshot()
+	}
+	return result, nil
+}
+
+func (r *RedisStorage) ObserveSummary(ctx context.Context, name string, value float64) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+	}
+
+	r.summariesMu.Lock()
+	s, ok := r.summaries[name]
+	if !ok {
+		s = NewSummary(nil)
+		r.summaries[name] = s
+	}
+	r.summariesMu.Unlock()
+
+	s.Observe(value)
+	return nil
+}
+
+func (r *RedisStorage) GetSummaries(ctx context.Context) (map[string]model.SummaryValue, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	r.summariesMu.RLock()
+	defer r.summariesMu.RUnlock()
+
+	result := make(map[string]model.SummaryValue, len(r.summaries))
+	for name, s := range r.summaries {
+		result[name] = s.Snapshot()
+	}
+	return result, nil
+}
+
+func (r *RedisStorage) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r *RedisStorage) Close() {
+	r.client.Close()
+}
@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type fakeNetError struct{}
+
+func (fakeNetError) Error() string   { return "fake network error" }
+func (fakeNetError) Timeout() bool   { return true }
+func (fakeNetError) Temporary() bool { return true }
+
+func TestIsRetryableRedisError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "redis.Nil is not retryable", err: redis.Nil, want: false},
+		{name: "wrapped redis.Nil is not retryable", err: errors.Join(errors.New("context"), redis.Nil), want: false},
+		{name: "network error is retryable", err: fakeNetError{}, want: true},
+		{name: "wrapped network error is retryable", err: &net.OpError{Err: fakeNetError{}}, want: true},
+		{name: "command error is not retryable", err: errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableRedisError(tt.err); got != tt.want {
+				t.Errorf("isRetryableRedisError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
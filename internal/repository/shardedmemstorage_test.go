@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// TestShardFor_Deterministic verifies that shardFor always routes the same
+// name to the same shard and never returns an out-of-range index.
+func TestShardFor_Deterministic(t *testing.T) {
+	const shardCount = 16
+	names := []string{"", "a", "requests_total", "gauge_with_a_long_name_42"}
+
+	for _, name := range names {
+		first := shardFor(name, shardCount)
+		if first < 0 || first >= shardCount {
+			t.Fatalf("shardFor(%q) = %d, want in [0, %d)", name, first, shardCount)
+		}
+		if got := shardFor(name, shardCount); got != first {
+			t.Errorf("shardFor(%q) not deterministic: got %d, then %d", name, first, got)
+		}
+	}
+}
+
+// TestShardedMemStorage_UpdateAndGet verifies basic gauge/counter round-trips.
+func TestShardedMemStorage_UpdateAndGet(t *testing.T) {
+	s := NewShardedMemStorage()
+	ctx := context.Background()
+
+	if err := s.UpdateGauge(ctx, "g", 1.5); err != nil {
+		t.Fatalf("UpdateGauge: %v", err)
+	}
+	if err := s.UpdateCounter(ctx, "c", 3); err != nil {
+		t.Fatalf("UpdateCounter: %v", err)
+	}
+	if err := s.UpdateCounter(ctx, "c", 4); err != nil {
+		t.Fatalf("UpdateCounter: %v", err)
+	}
+
+	gauges, err := s.GetGauges(ctx)
+	if err != nil {
+		t.Fatalf("GetGauges: %v", err)
+	}
+	if got := gauges["g"]; got != 1.5 {
+		t.Errorf("gauges[\"g\"] = %v, want 1.5", got)
+	}
+
+	counters, err := s.GetCounters(ctx)
+	if err != nil {
+		t.Fatalf("GetCounters: %v", err)
+	}
+	if got := counters["c"]; got != 7 {
+		t.Errorf("counters[\"c\"] = %v, want 7 (3+4)", got)
+	}
+}
+
+// TestShardedMemStorage_MaxCardinality mirrors
+// TestMemStorage_MaxCardinality with a single shard, so the per-shard limit
+// is also the effective global limit.
+func TestShardedMemStorage_MaxCardinality(t *testing.T) {
+	s := NewShardedMemStorageWithConfig(1, 2, nil, nil)
+	ctx := context.Background()
+
+	if err := s.UpdateGauge(ctx, "a", 1); err != nil {
+		t.Fatalf("UpdateGauge a: %v", err)
+	}
+	if err := s.UpdateGauge(ctx, "b", 2); err != nil {
+		t.Fatalf("UpdateGauge b: %v", err)
+	}
+	// Updating an existing ID past the limit must still succeed.
+	if err := s.UpdateGauge(ctx, "a", 10); err != nil {
+		t.Fatalf("UpdateGauge existing a: %v", err)
+	}
+	// A brand new ID past the limit must be dropped, not stored.
+	if err := s.UpdateGauge(ctx, "c", 3); err != nil {
+		t.Fatalf("UpdateGauge c: %v", err)
+	}
+
+	gauges, err := s.GetGauges(ctx)
+	if err != nil {
+		t.Fatalf("GetGauges: %v", err)
+	}
+	if _, ok := gauges["c"]; ok {
+		t.Errorf("metric \"c\" should have been dropped past MaxCardinality")
+	}
+	if got := gauges["a"]; got != 10 {
+		t.Errorf("existing metric \"a\" not updated: got %v, want 10", got)
+	}
+	if dropped := s.MetricsDropped(); dropped != 1 {
+		t.Errorf("MetricsDropped() = %d, want 1", dropped)
+	}
+}
+
+// TestShardedMemStorage_ConcurrentReadWriteRace hammers concurrent readers
+// against churning writers across many distinct names under `go test -race`
+// to prove per-shard locking never lets a reader observe a map that's being
+// mutated.
+func TestShardedMemStorage_ConcurrentReadWriteRace(t *testing.T) {
+	s := NewShardedMemStorage()
+	ctx := context.Background()
+
+	const writers = 8
+	const readers = 8
+	const iterations = 200
+
+	var wg sync.WaitGroup
+	wg.Add(writers + readers)
+
+	for w := range writers {
+		go func(w int) {
+			defer wg.Done()
+			name := "metric_" + strconv.Itoa(w)
+			for i := range iterations {
+				s.UpdateGauge(ctx, name, float64(i))
+				s.UpdateCounter(ctx, name, 1)
+			}
+		}(w)
+	}
+
+	for range readers {
+		go func() {
+			defer wg.Done()
+			for range iterations {
+				gauges, err := s.GetGauges(ctx)
+				if err != nil {
+					t.Errorf("GetGauges: %v", err)
+					return
+				}
+				for _, v := range gauges {
+					_ = v
+				}
+				counters, err := s.GetCounters(ctx)
+				if err != nil {
+					t.Errorf("GetCounters: %v", err)
+					return
+				}
+				for _, v := range counters {
+					_ = v
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// mixedWorkloadCases enumerates the metric-count / write-ratio combinations
+// BenchmarkMemStorage_MixedWorkload and BenchmarkShardedMemStorage_MixedWorkload
+// sweep to compare how each storage scales under concurrent access.
+var mixedWorkloadCases = []struct {
+	name         string
+	metricCount  int
+	writePercent int // 0-100; the remaining operations are reads
+}{
+	{"10metrics_write90read10", 10, 90},
+	{"10metrics_write50read50", 10, 50},
+	{"10metrics_write10read90", 10, 10},
+	{"100metrics_write90read10", 100, 90},
+	{"100metrics_write50read50", 100, 50},
+	{"100metrics_write10read90", 100, 10},
+	{"1000metrics_write90read10", 1000, 90},
+	{"1000metrics_write50read50", 1000, 50},
+	{"1000metrics_write10read90", 1000, 10},
+}
+
+// runMixedWorkloadBenchmark pre-populates s with metricCount gauges, then
+// runs writePercent/100 updates and the rest full-map reads, spread across
+// b.RunParallel's default GOMAXPROCS goroutines.
+func runMixedWorkloadBenchmark(b *testing.B, s Storage, metricCount, writePercent int) {
+	ctx := context.Background()
+	names := make([]string, metricCount)
+	for i := range names {
+		names[i] = "metric_" + strconv.Itoa(i)
+		if err := s.UpdateGauge(ctx, names[i], float64(i)); err != nil {
+			b.Fatalf("UpdateGauge: %v", err)
+		}
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			name := names[i%metricCount]
+			if i%100 < writePercent {
+				s.UpdateGauge(ctx, name, float64(i))
+			} else {
+				s.GetGauges(ctx)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkMemStorage_MixedWorkload(b *testing.B) {
+	for _, tc := range mixedWorkloadCases {
+		b.Run(tc.name, func(b *testing.B) {
+			runMixedWorkloadBenchmark(b, NewMemStorage(), tc.metricCount, tc.writePercent)
+		})
+	}
+}
+
+func BenchmarkShardedMemStorage_MixedWorkload(b *testing.B) {
+	for _, tc := range mixedWorkloadCases {
+		b.Run(tc.name, func(b *testing.B) {
+			runMixedWorkloadBenchmark(b, NewShardedMemStorage(), tc.metricCount, tc.writePercent)
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package repository
 
-import "context"
+import (
+	"context"
+
+	"github.com/idudko/go-musthave-metrics/internal/model"
+)
 
 // Storage defines the interface for metric storage operations.
 //
@@ -121,4 +125,38 @@ type Storage interface {
 	//	    return err
 	//	}
 	Save(ctx context.Context) error
+
+	// UpdateHistogram records a single observation against the named
+	// Histogram metric, creating it with the storage's configured default
+	// bucket boundaries on first use.
+	//
+	// Parameters:
+	//   - ctx: Context for request cancellation and timeout handling
+	//   - name: Unique identifier for the metric
+	//   - value: The observed value
+	//
+	// Returns:
+	//   - error: Storage error if operation fails
+	UpdateHistogram(ctx context.Context, name string, value float64) error
+
+	// GetHistograms retrieves a snapshot of every Histogram metric's
+	// aggregate (buckets, count, sum).
+	GetHistograms(ctx context.Context) (map[string]model.HistogramValue, error)
+
+	// ObserveSummary records a single observation against the named
+	// Summary metric, creating it with the storage's configured default
+	// target quantiles on first use.
+	//
+	// Parameters:
+	//   - ctx: Context for request cancellation and timeout handling
+	//   - name: Unique identifier for the metric
+	//   - value: The observed value
+	//
+	// Returns:
+	//   - error: Storage error if operation fails
+	ObserveSummary(ctx context.Context, name string, value float64) error
+
+	// GetSummaries retrieves a snapshot of every Summary metric's
+	// aggregate (quantiles, count, sum).
+	GetSummaries(ctx context.Context) (map[string]model.SummaryValue, error)
 }
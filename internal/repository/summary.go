@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/idudko/go-musthave-metrics/internal/model"
+	"github.com/idudko/go-musthave-metrics/pkg/quantile"
+)
+
+// Summary tracks streaming quantiles of observed values using a
+// quantile.Estimator, plus a running count and sum - the same shape
+// Prometheus summaries expose.
+type Summary struct {
+	estimator *quantile.Estimator
+
+	mu    sync.Mutex
+	count uint64
+	sum   float64
+}
+
+// NewSummary creates a Summary targeting the given quantiles. A nil or empty
+// targets map falls back to quantile.DefaultTargets.
+func NewSummary(targets quantile.Targets) *Summary {
+	return &Summary{estimator: quantile.NewEstimator(targets)}
+}
+
+// Observe records a single observation.
+func (s *Summary) Observe(v float64) {
+	s.estimator.Insert(v)
+
+	s.mu.Lock()
+	s.count++
+	s.sum += v
+	s.mu.Unlock()
+}
+
+// Snapshot returns a point-in-time copy of the summary's aggregate.
+func (s *Summary) Snapshot() model.SummaryValue {
+	quantiles := s.estimator.QueryAll()
+
+	s.mu.Lock()
+	count, sum := s.count, s.sum
+	s.mu.Unlock()
+
+	return model.SummaryValue{Quantiles: quantiles, Count: count, Sum: sum}
+}
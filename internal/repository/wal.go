@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WALFsyncPolicy controls how often FileStorage's WAL is fsynced to disk
+// between checkpoints.
+type WALFsyncPolicy int
+
+const (
+	// WALFsyncAlways fsyncs after every WAL record. Slowest, but a crash
+	// never loses a record FileStorage has already returned from
+	// UpdateGauge/UpdateCounter for.
+	WALFsyncAlways WALFsyncPolicy = iota
+	// WALFsyncInterval fsyncs on a timer (see WithWALSyncInterval),
+	// bounding data loss to that window without paying fsync's cost on
+	// every write. This is the default.
+	WALFsyncInterval
+	// WALFsyncNever never calls fsync explicitly, relying on the OS to
+	// flush dirty pages on its own schedule. A crash (as opposed to a
+	// clean process exit, which still flushes the buffered writer) can
+	// lose however much the kernel was holding back.
+	WALFsyncNever
+)
+
+// ParseWALFsyncPolicy parses the "always"/"interval"/"never" values a
+// FileStorage config file or flag would carry into a WALFsyncPolicy.
+func ParseWALFsyncPolicy(s string) (WALFsyncPolicy, error) {
+	switch s {
+	case "always":
+		return WALFsyncAlways, nil
+	case "interval":
+		return WALFsyncInterval, nil
+	case "never":
+		return WALFsyncNever, nil
+	default:
+		return 0, fmt.Errorf("unknown WAL fsync policy %q: want always, interval, or never", s)
+	}
+}
+
+// defaultWALSyncInterval is the fsync period WALFsyncInterval uses when a
+// FileStorage isn't given an explicit WithWALSyncInterval.
+const defaultWALSyncInterval = time.Second
+
+// walRecord is one WAL entry: a single UpdateGauge or UpdateCounter call,
+// newline-delimited JSON so the WAL can be replayed line by line without a
+// separate length prefix - a truncated final line (from a crash mid-write)
+// simply fails to unmarshal and is dropped, which is exactly the record
+// restore should discard anyway since it never reached the OS, let alone
+// disk.
+type walRecord struct {
+	Seq   uint64  `json:"seq"`
+	Ts    int64   `json:"ts"`
+	Type  string  `json:"type"`
+	Name  string  `json:"name"`
+	Value float64 `json:"value,omitempty"`
+	Delta int64   `json:"delta,omitempty"`
+}
+
+const (
+	walRecordGauge   = "gauge"
+	walRecordCounter = "counter"
+)
+
+// WALStats is a point-in-time snapshot of a FileStorage's WAL activity, for
+// the audit subsystem (or any other caller) to observe how much durability
+// work the WAL is doing and how much it recovered on the last restore.
+type WALStats struct {
+	// Appended counts records written via UpdateGauge/UpdateCounter since
+	// the WAL was opened.
+	Appended uint64
+	// Replayed counts records restore replayed from the WAL on top of the
+	// last snapshot, the most recent time FileStorage started up.
+	Replayed uint64
+	// Checkpoints counts completed saveMetrics checkpoints, each of which
+	// truncates the WAL.
+	Checkpoints uint64
+}
+
+// walWriter is the append-only log FileStorage writes one record to per
+// UpdateGauge/UpdateCounter call, buffered and fsynced per policy. seq is
+// monotonically increasing across the WAL's entire lifetime, including
+// past checkpoints/truncations, so restore can always tell a WAL record
+// apart from one already folded into the snapshot.
+type walWriter struct {
+	path   string
+	policy WALFsyncPolicy
+	every  time.Duration
+
+	mu       sync.Mutex
+	file     *os.File
+	bw       *bufio.Writer
+	seq      uint64
+	lastSync time.Time
+
+	appended    atomic.Uint64
+	replayed    atomic.Uint64
+	checkpoints atomic.Uint64
+}
+
+// openWAL opens (creating if necessary) the WAL file at path, appending to
+// whatever it already contains.
+func openWAL(path string, policy WALFsyncPolicy, every time.Duration) (*walWriter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &walWriter{
+		path:     path,
+		policy:   policy,
+		every:    every,
+		file:     f,
+		bw:       bufio.NewWriter(f),
+		lastSync: time.Now(),
+	}, nil
+}
+
+// append writes rec to the WAL under the next seq, honoring w.policy's
+// fsync cadence.
+func (w *walWriter) append(rec walRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.seq++
+	rec.Seq = w.seq
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if _, err := w.bw.Write(data); err != nil {
+		return err
+	}
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	w.appended.Add(1)
+
+	switch w.policy {
+	case WALFsyncAlways:
+		return w.file.Sync()
+	case WALFsyncInterval:
+		if time.Since(w.lastSync) >= w.every {
+			w.lastSync = time.Now()
+			return w.file.Sync()
+		}
+	}
+	return nil
+}
+
+// replay reads every well-formed record in the WAL file at w.path whose Seq
+// is greater than checkpointSeq, applying gauges to gauges and deltas to
+// counters via apply, and returns the highest Seq it saw (checkpointSeq if
+// the WAL is empty or missing). A truncated or corrupt trailing line - the
+// signature of a crash mid-write - is silently skipped rather than failing
+// the whole replay, since every record before it is still valid.
+func (w *walWriter) replay(checkpointSeq uint64, applyGauge func(name string, value float64), applyCounter func(name string, delta int64)) (uint64, error) {
+	f, err := os.Open(w.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return checkpointSeq, nil
+		}
+		return checkpointSeq, err
+	}
+	defer f.Close()
+
+	highWater := checkpointSeq
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var rec walRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			// A partial final line from a crashed write; every prior
+			// line has already been applied, so stop here.
+			break
+		}
+		if rec.Seq <= checkpointSeq {
+			continue
+		}
+
+		switch rec.Type {
+		case walRecordGauge:
+			applyGauge(rec.Name, rec.Value)
+		case walRecordCounter:
+			applyCounter(rec.Name, rec.Delta)
+		}
+		if rec.Seq > highWater {
+			highWater = rec.Seq
+		}
+		w.replayed.Add(1)
+	}
+	if err := scanner.Err(); err != nil {
+		return highWater, err
+	}
+	return highWater, nil
+}
+
+// reset sets the WAL's seq counter (so future appends continue from there)
+// and truncates the WAL file, discarding every record now folded into a
+// checkpointed snapshot.
+func (w *walWriter) reset(seq uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.bw.Flush(); err != nil {
+		return err
+	}
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.file.Seek(0, 0); err != nil {
+		return err
+	}
+	w.bw.Reset(w.file)
+	w.seq = seq
+	w.checkpoints.Add(1)
+	return nil
+}
+
+// stats returns a point-in-time snapshot of the WAL's counters.
+func (w *walWriter) stats() WALStats {
+	return WALStats{
+		Appended:    w.appended.Load(),
+		Replayed:    w.replayed.Load(),
+		Checkpoints: w.checkpoints.Load(),
+	}
+}
+
+// close flushes and closes the underlying WAL file.
+func (w *walWriter) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.bw.Flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// checkpointHeader is the small file recording the high-water WAL seq
+// folded into the most recent snapshot, so restore knows which WAL records
+// (if any) still need replaying on top of it.
+type checkpointHeader struct {
+	Seq uint64 `json:"seq"`
+}
+
+// readCheckpoint loads the checkpoint seq at path, returning 0 if the file
+// doesn't exist yet (a fresh FileStorage that has never checkpointed).
+func readCheckpoint(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	var hdr checkpointHeader
+	if err := json.Unmarshal(data, &hdr); err != nil {
+		return 0, err
+	}
+	return hdr.Seq, nil
+}
+
+// writeCheckpoint atomically (tmpfile+rename, matching saveMetrics'
+// snapshot write) records seq as path's new checkpoint.
+func writeCheckpoint(path string, seq uint64) error {
+	tmpfile, err := os.CreateTemp("", "checkpoint-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpfile.Name())
+
+	if err := json.NewEncoder(tmpfile).Encode(checkpointHeader{Seq: seq}); err != nil {
+		tmpfile.Close()
+		return err
+	}
+	if err := tmpfile.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpfile.Name(), path)
+}
@@ -0,0 +1,89 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStaticTokenValidator_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{name: "matching token", token: "secret-token", want: true},
+		{name: "wrong token", token: "wrong-token", want: false},
+		{name: "empty token", token: "", want: false},
+	}
+
+	v := StaticTokenValidator{Token: "secret-token"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := v.Validate(tt.token); got != tt.want {
+				t.Errorf("Validate(%q) = %v, want %v", tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStaticTokenValidator_EmptyValidatorToken(t *testing.T) {
+	v := StaticTokenValidator{Token: ""}
+	if v.Validate("anything") {
+		t.Error("Validate should reject all tokens when no token is configured")
+	}
+}
+
+func TestBearerAuthMiddleware(t *testing.T) {
+	validator := StaticTokenValidator{Token: "secret-token"}
+	mw := BearerAuthMiddleware(validator)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := mw(next)
+
+	tests := []struct {
+		name           string
+		authHeader     string
+		expectedStatus int
+	}{
+		{name: "valid bearer token", authHeader: "Bearer secret-token", expectedStatus: http.StatusOK},
+		{name: "wrong bearer token", authHeader: "Bearer wrong-token", expectedStatus: http.StatusUnauthorized},
+		{name: "missing header", authHeader: "", expectedStatus: http.StatusUnauthorized},
+		{name: "missing bearer prefix", authHeader: "secret-token", expectedStatus: http.StatusUnauthorized},
+		{name: "empty token after prefix", authHeader: "Bearer ", expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/update/", nil)
+			if tt.authHeader != "" {
+				req.Header.Set("Authorization", tt.authHeader)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestBearerAuthMiddleware_NilValidatorDisablesCheck(t *testing.T) {
+	mw := BearerAuthMiddleware(nil)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := mw(next)
+
+	req := httptest.NewRequest(http.MethodPost, "/update/", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d with nil validator, got %d", http.StatusOK, w.Code)
+	}
+}
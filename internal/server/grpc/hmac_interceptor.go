@@ -0,0 +1,57 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/idudko/go-musthave-metrics/pkg/hash"
+	"github.com/rs/zerolog/log"
+)
+
+// HMACInterceptor проверяет HMAC-SHA256 подпись запроса, переданную в метаданных
+// "hashsha256", зеркалируя HashValidationMiddleware на HTTP-транспорте. Подпись
+// вычисляется клиентом от сериализованного protobuf-сообщения запроса.
+func HMACInterceptor(key string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if key == "" {
+			return handler(ctx, req)
+		}
+
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok {
+			log.Warn().Msg("Failed to get metadata from context")
+			return nil, status.Error(codes.PermissionDenied, "failed to get metadata from context")
+		}
+
+		values := md.Get("hashsha256")
+		if len(values) == 0 || values[0] == "" || values[0] == "none" {
+			// Зеркалируем HTTP-поведение: отсутствие подписи не блокирует запрос,
+			// клиент может не поддерживать подпись.
+			return handler(ctx, req)
+		}
+
+		msg, ok := req.(proto.Message)
+		if !ok {
+			log.Warn().Str("method", info.FullMethod).Msg("Request does not implement proto.Message, cannot validate HMAC")
+			return nil, status.Error(codes.Internal, "unable to validate request signature")
+		}
+
+		data, err := proto.Marshal(msg)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to marshal request for HMAC validation")
+			return nil, status.Error(codes.Internal, "failed to validate request signature")
+		}
+
+		if !hash.ValidateHash(data, key, values[0], hash.SHA256) {
+			log.Warn().Str("method", info.FullMethod).Msg("Invalid HMAC signature")
+			return nil, status.Error(codes.PermissionDenied, "invalid hash signature")
+		}
+
+		return handler(ctx, req)
+	}
+}
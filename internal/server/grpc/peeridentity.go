@@ -0,0 +1,59 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/rs/zerolog/log"
+)
+
+// PeerIdentityInterceptor checks the verified client certificate
+// established by TLSConfig.MutualTLS against allowed: the request is
+// rejected unless the certificate's CN or one of its DNS SANs is in the
+// list. An empty allowed accepts any client certificate that verified
+// against TLSConfig.ClientCAFile during the handshake.
+//
+// This only makes sense once mTLS is in effect, so the server fails closed
+// when no verified peer certificate is present rather than silently
+// passing the request through.
+func PeerIdentityInterceptor(allowed []string) grpc.UnaryServerInterceptor {
+	allowSet := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		allowSet[name] = true
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		p, ok := peer.FromContext(ctx)
+		if !ok {
+			log.Warn().Str("method", info.FullMethod).Msg("gRPC request has no peer info, cannot verify mTLS identity")
+			return nil, status.Error(codes.PermissionDenied, "client certificate required")
+		}
+
+		tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+		if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+			log.Warn().Str("method", info.FullMethod).Msg("gRPC client presented no verified certificate")
+			return nil, status.Error(codes.PermissionDenied, "client certificate required")
+		}
+
+		if len(allowSet) == 0 {
+			return handler(ctx, req)
+		}
+
+		leaf := tlsInfo.State.VerifiedChains[0][0]
+		names := append([]string{leaf.Subject.CommonName}, leaf.DNSNames...)
+		for _, name := range names {
+			if allowSet[name] {
+				return handler(ctx, req)
+			}
+		}
+
+		log.Warn().Str("method", info.FullMethod).Str("names", strings.Join(names, ",")).Msg("gRPC client certificate identity not in allowlist")
+		return nil, status.Error(codes.PermissionDenied, "client certificate identity not allowed")
+	}
+}
@@ -2,10 +2,14 @@ package grpc
 
 import (
 	"context"
+	"io"
 	"net"
+	"sync/atomic"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
@@ -47,10 +51,169 @@ func (s *MetricsServer) UpdateMetrics(ctx context.Context, req *proto.UpdateMetr
 	return &proto.UpdateMetricsResponse{}, nil
 }
 
+// PushBatch принимает поток MetricBatch-сообщений - агент открывает одно
+// долгоживущее соединение и шлёт по нему накопленный буфер метрик вместо
+// одного HTTP POST на метрику. Подтверждает общее число полученных метрик
+// одним Ack, когда агент закрывает отправляющую сторону потока.
+func (s *MetricsServer) PushBatch(stream proto.Metrics_PushBatchServer) error {
+	var received int32
+
+	for {
+		batch, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&proto.Ack{Received: received})
+		}
+		if err != nil {
+			return err
+		}
+
+		ctx := stream.Context()
+		for _, m := range batch.Metrics {
+			switch m.Type {
+			case proto.Metric_GAUGE:
+				if err := s.storage.UpdateGauge(ctx, m.Id, m.Value); err != nil {
+					log.Error().Err(err).Str("id", m.Id).Msg("failed to update gauge metric")
+					return status.Error(codes.Internal, "failed to update gauge metric")
+				}
+			case proto.Metric_COUNTER:
+				if err := s.storage.UpdateCounter(ctx, m.Id, m.Delta); err != nil {
+					log.Error().Err(err).Str("id", m.Id).Msg("failed to update counter metric")
+					return status.Error(codes.Internal, "failed to update counter metric")
+				}
+			default:
+				return status.Error(codes.InvalidArgument, "invalid metric type")
+			}
+			received++
+		}
+	}
+}
+
+// streamFlushCount and streamFlushInterval bound how long StreamMetrics
+// goes without acking: whichever limit is hit first triggers a StreamAck,
+// so a slow client isn't left guessing about backpressure for an entire
+// poll interval.
+const (
+	streamFlushCount    = 100
+	streamFlushInterval = 500 * time.Millisecond
+)
+
+// StreamMetrics is a bidirectional-streaming alternative to UpdateMetrics:
+// the client keeps one stream open and sends Metric messages one at a
+// time instead of a full request per batch, and the server periodically
+// (every streamFlushCount messages or streamFlushInterval, whichever comes
+// first) reports a StreamAck{Received, Applied, LastError} so the client
+// can throttle itself without waiting for a response per message. Unlike
+// UpdateMetrics and PushBatch, a storage error does not abort the stream -
+// it's surfaced via StreamAck.LastError and the stream keeps going, since
+// the whole point of streaming is to avoid a round trip per metric.
+func (s *MetricsServer) StreamMetrics(stream proto.Metrics_StreamMetricsServer) error {
+	ctx := stream.Context()
+
+	msgCh := make(chan *proto.Metric)
+	recvErrCh := make(chan error, 1)
+	go func() {
+		for {
+			m, err := stream.Recv()
+			if err == io.EOF {
+				close(msgCh)
+				return
+			}
+			if err != nil {
+				recvErrCh <- err
+				return
+			}
+			msgCh <- m
+		}
+	}()
+
+	var received, applied int32
+	var lastErr string
+
+	sendAck := func() error {
+		return stream.Send(&proto.StreamAck{Received: received, Applied: applied, LastError: lastErr})
+	}
+
+	ticker := time.NewTicker(streamFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case m, ok := <-msgCh:
+			if !ok {
+				return sendAck()
+			}
+
+			received++
+			switch m.Type {
+			case proto.Metric_GAUGE:
+				if err := s.storage.UpdateGauge(ctx, m.Id, m.Value); err != nil {
+					log.Error().Err(err).Str("id", m.Id).Msg("failed to apply streamed gauge metric")
+					lastErr = err.Error()
+					continue
+				}
+			case proto.Metric_COUNTER:
+				if err := s.storage.UpdateCounter(ctx, m.Id, m.Delta); err != nil {
+					log.Error().Err(err).Str("id", m.Id).Msg("failed to apply streamed counter metric")
+					lastErr = err.Error()
+					continue
+				}
+			default:
+				lastErr = "invalid metric type"
+				continue
+			}
+			applied++
+
+			if received%streamFlushCount == 0 {
+				if err := sendAck(); err != nil {
+					return err
+				}
+			}
+		case <-ticker.C:
+			if err := sendAck(); err != nil {
+				return err
+			}
+		case err := <-recvErrCh:
+			return err
+		}
+	}
+}
+
+// TrustedSubnetState holds the trusted subnet CIDR read by
+// TrustedSubnetInterceptor, so it can be updated in place - e.g. from a
+// config.Watcher subscriber - without rebuilding the gRPC server's
+// interceptor chain.
+type TrustedSubnetState struct {
+	v atomic.Value // string
+}
+
+// NewTrustedSubnetState creates a TrustedSubnetState holding initial. An
+// empty initial disables the check, same as TrustedSubnetInterceptor's old
+// static trustedSubnet argument.
+func NewTrustedSubnetState(initial string) *TrustedSubnetState {
+	s := &TrustedSubnetState{}
+	s.v.Store(initial)
+	return s
+}
+
+// Set updates the trusted subnet CIDR every subsequent request is checked
+// against.
+func (s *TrustedSubnetState) Set(trustedSubnet string) {
+	s.v.Store(trustedSubnet)
+}
+
+// Get returns the currently configured trusted subnet CIDR.
+func (s *TrustedSubnetState) Get() string {
+	return s.v.Load().(string)
+}
+
 // TrustedSubnetInterceptor проверяет, что IP из метаданных x-real-ip
-// входит в доверенную подсеть
-func TrustedSubnetInterceptor(trustedSubnet string) grpc.UnaryServerInterceptor {
+// входит в доверенную подсеть. state.Get() is read fresh on every request,
+// so updating it (e.g. on a config.Watcher ConfigChange for
+// "trusted_subnet") takes effect without restarting the server.
+func TrustedSubnetInterceptor(state *TrustedSubnetState) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		trustedSubnet := state.Get()
+
 		// Если доверенная подсеть не задана, просто пропускаем все запросы
 		if trustedSubnet == "" {
 			return handler(ctx, req)
@@ -102,9 +265,14 @@ func createListener(address string) (net.Listener, error) {
 	return net.Listen("tcp", address)
 }
 
-// createServer создаёт и конфигурирует gRPC сервер с интерцептором и сервисом метрик
-func createServer(interceptor grpc.UnaryServerInterceptor, storage repository.Storage) *grpc.Server {
-	s := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptor))
+// createServer создаёт и конфигурирует gRPC сервер с интерцепторами и сервисом метрик.
+// creds, если не nil, включает TLS (см. TLSConfig.credentials).
+func createServer(storage repository.Storage, creds credentials.TransportCredentials, interceptors ...grpc.UnaryServerInterceptor) *grpc.Server {
+	opts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(interceptors...)}
+	if creds != nil {
+		opts = append(opts, grpc.Creds(creds))
+	}
+	s := grpc.NewServer(opts...)
 
 	// Регистрируем сервис метрик
 	metricsServer := &MetricsServer{
@@ -134,20 +302,43 @@ func startServerWithGracefulShutdown(ctx context.Context, server *grpc.Server, l
 }
 
 // StartServer запускает gRPC сервер
-func StartServer(ctx context.Context, address string, trustedSubnet string, storage repository.Storage) (*grpc.Server, error) {
+//
+// key включает проверку HMAC-SHA256 подписи запросов (см. HMACInterceptor);
+// пустая строка отключает проверку, как и на HTTP-транспорте.
+//
+// tlsConfig, when TLSConfig.Enabled, serves over TLS instead of plaintext;
+// when TLSConfig.MutualTLS is also set, client certificates are required
+// and verified, and PeerIdentityInterceptor is added to the chain to
+// enforce TLSConfig.AllowedClientNames.
+//
+// The returned *TrustedSubnetState lets a caller update trustedSubnet
+// later - e.g. from a config.Watcher subscriber - without restarting the
+// server.
+func StartServer(ctx context.Context, address string, trustedSubnet string, key string, tlsConfig TLSConfig, storage repository.Storage) (*grpc.Server, *TrustedSubnetState, error) {
 	lis, err := createListener(address)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	creds, err := tlsConfig.credentials()
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Создаём интерцептор для проверки доверенной подсети
-	interceptor := TrustedSubnetInterceptor(trustedSubnet)
+	// Создаём gRPC сервер с интерцепторами, зеркалирующими HTTP middleware
+	trustedSubnetState := NewTrustedSubnetState(trustedSubnet)
+	interceptors := []grpc.UnaryServerInterceptor{
+		TrustedSubnetInterceptor(trustedSubnetState),
+		HMACInterceptor(key),
+	}
+	if tlsConfig.MutualTLS() {
+		interceptors = append(interceptors, PeerIdentityInterceptor(tlsConfig.AllowedClientNames))
+	}
 
-	// Создаём gRPC сервер с интерцептором
-	s := createServer(interceptor, storage)
+	s := createServer(storage, creds, interceptors...)
 
 	// Запускаем сервер с graceful shutdown
 	startServerWithGracefulShutdown(ctx, s, lis, address)
 
-	return s, nil
+	return s, trustedSubnetState, nil
 }
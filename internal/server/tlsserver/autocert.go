@@ -0,0 +1,72 @@
+// Package tlsserver wires an HTTP handler up to Let's Encrypt via ACME
+// autocert, so the metrics server can serve HTTPS without operators having
+// to provision certificates by hand.
+package tlsserver
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Serve runs handler behind TLS using a Let's Encrypt certificate for domain.
+// Certificates (and their renewals) are cached under cacheDir. A small HTTP
+// listener on redirectAddr answers ACME http-01 challenges and redirects all
+// other traffic to https://domain.
+//
+// Serve blocks until ctx is canceled, at which point both listeners are shut
+// down gracefully, or until either listener fails.
+func Serve(ctx context.Context, address, domain, cacheDir, email, redirectAddr string, handler http.Handler) error {
+	certManager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domain),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      email,
+	}
+
+	challengeServer := &http.Server{
+		Addr:    redirectAddr,
+		Handler: certManager.HTTPHandler(http.HandlerFunc(redirectToHTTPS)),
+	}
+	go func() {
+		if err := challengeServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error().Err(err).Msg("ACME challenge server failed")
+		}
+	}()
+
+	server := &http.Server{
+		Addr:      address,
+		Handler:   handler,
+		TLSConfig: certManager.TLSConfig(),
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.ListenAndServeTLS("", "")
+	}()
+
+	select {
+	case <-ctx.Done():
+		log.Info().Msg("Shutting down HTTPS and ACME challenge servers gracefully...")
+		if err := challengeServer.Shutdown(context.Background()); err != nil {
+			log.Error().Err(err).Msg("Failed to shut down ACME challenge server")
+		}
+		return server.Shutdown(context.Background())
+	case err := <-errCh:
+		if err != nil && err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	}
+}
+
+// redirectToHTTPS redirects plain HTTP requests (other than ACME challenges,
+// which autocert.Manager.HTTPHandler intercepts before this is reached) to
+// the equivalent HTTPS URL.
+func redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	target := "https://" + r.Host + r.URL.RequestURI()
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+}
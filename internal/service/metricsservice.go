@@ -30,6 +30,18 @@ func (s *MetricsService) UpdateMetric(metricType, metricName, metricValue string
 			return errors.New("invalid gauge value")
 		}
 		s.storage.UpdateGauge(metricName, value)
+	case model.Histogram:
+		value, err := strconv.ParseFloat(metricValue, 64)
+		if err != nil {
+			return errors.New("invalid histogram value")
+		}
+		s.storage.UpdateHistogram(metricName, value)
+	case model.Summary:
+		value, err := strconv.ParseFloat(metricValue, 64)
+		if err != nil {
+			return errors.New("invalid summary value")
+		}
+		s.storage.ObserveSummary(metricName, value)
 	default:
 		return errors.New("invalid metric type")
 	}
@@ -53,6 +65,20 @@ func (s *MetricsService) GetMetricValue(metricType, metricName string) (interfac
 			return nil, errors.New("metric not found")
 		}
 		return value, nil
+	case model.Histogram:
+		histograms := s.storage.GetHistograms()
+		value, exists := histograms[metricName]
+		if !exists {
+			return nil, errors.New("metric not found")
+		}
+		return value, nil
+	case model.Summary:
+		summaries := s.storage.GetSummaries()
+		value, exists := summaries[metricName]
+		if !exists {
+			return nil, errors.New("metric not found")
+		}
+		return value, nil
 	default:
 		return nil, errors.New("invalid metric type")
 	}
@@ -0,0 +1,120 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// Frame layout for EncryptHybrid/DecryptHybrid:
+//
+//	[1-byte version][2-byte encrypted-key-length][RSA-encrypted AES key][12-byte nonce][AES-GCM ciphertext+tag]
+//
+// The version byte allows future algorithm changes to stay backward-compatible:
+// unknown versions are rejected by DecryptHybrid instead of being misinterpreted.
+const (
+	hybridVersion1 = 1
+
+	aesKeySize    = 32 // AES-256
+	gcmNonceSize  = 12
+	frameHeaderSz = 1 + 2 // version + encrypted-key-length
+)
+
+// EncryptHybrid encrypts plaintext of arbitrary size for pubKey using hybrid
+// RSA+AES-GCM encryption: a fresh 256-bit AES key and 12-byte nonce are
+// generated per call, the plaintext is sealed with AES-GCM, and the AES key
+// is wrapped with RSA-OAEP (SHA-256). Unlike plain Encrypt, this is not
+// limited by the RSA key size, so it is suitable for batches of any size.
+//
+// Returns the framed message in the layout described above the constants.
+func EncryptHybrid(plaintext []byte, pubKey *rsa.PublicKey) ([]byte, error) {
+	aesKey := make([]byte, aesKeySize)
+	if _, err := rand.Read(aesKey); err != nil {
+		return nil, fmt.Errorf("failed to generate AES key: %w", err)
+	}
+
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, gcmNonceSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES-GCM: %w", err)
+	}
+
+	nonce := make([]byte, gcmNonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	encryptedKey, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pubKey, aesKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt AES key: %w", err)
+	}
+	if len(encryptedKey) > 0xFFFF {
+		return nil, fmt.Errorf("encrypted key too large: %d bytes", len(encryptedKey))
+	}
+
+	ciphertext := gcm.Seal(nil, nonce, plaintext, nil)
+
+	frame := make([]byte, 0, frameHeaderSz+len(encryptedKey)+gcmNonceSize+len(ciphertext))
+	frame = append(frame, hybridVersion1)
+	frame = binary.BigEndian.AppendUint16(frame, uint16(len(encryptedKey)))
+	frame = append(frame, encryptedKey...)
+	frame = append(frame, nonce...)
+	frame = append(frame, ciphertext...)
+
+	return frame, nil
+}
+
+// DecryptHybrid reverses EncryptHybrid: it unwraps the AES key with privKey
+// using RSA-OAEP (SHA-256), then decrypts the AES-GCM payload.
+//
+// It rejects frames with an unknown version byte or a malformed length so
+// that future, incompatible framing changes fail safely instead of being
+// silently misparsed.
+func DecryptHybrid(frame []byte, privKey *rsa.PrivateKey) ([]byte, error) {
+	if len(frame) < frameHeaderSz {
+		return nil, fmt.Errorf("encrypted frame too short")
+	}
+
+	version := frame[0]
+	if version != hybridVersion1 {
+		return nil, fmt.Errorf("unsupported encryption frame version: %d", version)
+	}
+
+	keyLen := int(binary.BigEndian.Uint16(frame[1:3]))
+	rest := frame[frameHeaderSz:]
+	if len(rest) < keyLen+gcmNonceSize {
+		return nil, fmt.Errorf("encrypted frame too short for key and nonce")
+	}
+
+	encryptedKey := rest[:keyLen]
+	nonce := rest[keyLen : keyLen+gcmNonceSize]
+	ciphertext := rest[keyLen+gcmNonceSize:]
+
+	aesKey, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, privKey, encryptedKey, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt AES key: %w", err)
+	}
+
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, gcmNonceSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AES-GCM: %w", err)
+	}
+
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload: %w", err)
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,120 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+)
+
+func mustGenerateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	return key
+}
+
+func TestEncryptHybrid_RoundTrip(t *testing.T) {
+	privKey := mustGenerateKey(t)
+
+	sizes := map[string]int{
+		"1KB":   1024,
+		"100KB": 100 * 1024,
+		"1MB":   1024 * 1024,
+	}
+
+	for name, size := range sizes {
+		t.Run(name, func(t *testing.T) {
+			plaintext := make([]byte, size)
+			for i := range plaintext {
+				plaintext[i] = byte(i % 256)
+			}
+
+			frame, err := EncryptHybrid(plaintext, &privKey.PublicKey)
+			if err != nil {
+				t.Fatalf("EncryptHybrid failed: %v", err)
+			}
+
+			decrypted, err := DecryptHybrid(frame, privKey)
+			if err != nil {
+				t.Fatalf("DecryptHybrid failed: %v", err)
+			}
+
+			if len(decrypted) != len(plaintext) {
+				t.Fatalf("decrypted length = %d, want %d", len(decrypted), len(plaintext))
+			}
+			for i := range plaintext {
+				if decrypted[i] != plaintext[i] {
+					t.Fatalf("decrypted data mismatch at byte %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestDecryptHybrid_UnknownVersion(t *testing.T) {
+	privKey := mustGenerateKey(t)
+
+	frame, err := EncryptHybrid([]byte("hello"), &privKey.PublicKey)
+	if err != nil {
+		t.Fatalf("EncryptHybrid failed: %v", err)
+	}
+	frame[0] = 0xFF
+
+	if _, err := DecryptHybrid(frame, privKey); err == nil {
+		t.Fatal("expected error for unknown frame version, got nil")
+	}
+}
+
+func TestDecryptHybrid_TooShort(t *testing.T) {
+	privKey := mustGenerateKey(t)
+
+	if _, err := DecryptHybrid([]byte{1, 0}, privKey); err == nil {
+		t.Fatal("expected error for truncated frame, got nil")
+	}
+}
+
+func TestDecryptHybrid_TruncatedEnvelope(t *testing.T) {
+	privKey := mustGenerateKey(t)
+
+	frame, err := EncryptHybrid([]byte("hello, world"), &privKey.PublicKey)
+	if err != nil {
+		t.Fatalf("EncryptHybrid failed: %v", err)
+	}
+
+	if _, err := DecryptHybrid(frame[:len(frame)-20], privKey); err == nil {
+		t.Fatal("expected error for truncated envelope, got nil")
+	}
+}
+
+func TestDecryptHybrid_TamperedCiphertext(t *testing.T) {
+	privKey := mustGenerateKey(t)
+
+	frame, err := EncryptHybrid([]byte("hello, world"), &privKey.PublicKey)
+	if err != nil {
+		t.Fatalf("EncryptHybrid failed: %v", err)
+	}
+
+	// Flip a bit in the AES-GCM ciphertext, well past the RSA-wrapped key and
+	// nonce, so only GCM's auth tag check should catch the tampering.
+	frame[len(frame)-1] ^= 0xFF
+
+	if _, err := DecryptHybrid(frame, privKey); err == nil {
+		t.Fatal("expected GCM authentication failure for tampered ciphertext, got nil")
+	}
+}
+
+func TestDecryptHybrid_WrongKey(t *testing.T) {
+	privKey := mustGenerateKey(t)
+	wrongKey := mustGenerateKey(t)
+
+	frame, err := EncryptHybrid([]byte("hello, world"), &privKey.PublicKey)
+	if err != nil {
+		t.Fatalf("EncryptHybrid failed: %v", err)
+	}
+
+	if _, err := DecryptHybrid(frame, wrongKey); err == nil {
+		t.Fatal("expected error when decrypting with the wrong private key, got nil")
+	}
+}
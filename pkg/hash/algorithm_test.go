@@ -0,0 +1,76 @@
+package hash
+
+import (
+	"testing"
+)
+
+func TestHeaders_SortedAndComplete(t *testing.T) {
+	want := []string{"HashBLAKE2B", "HashSHA1", "HashSHA256", "HashSHA512"}
+	got := Headers()
+
+	if len(got) != len(want) {
+		t.Fatalf("Headers() returned %d headers, want %d: %v", len(got), len(want), got)
+	}
+	for i, h := range want {
+		if got[i] != h {
+			t.Errorf("Headers()[%d] = %q, want %q", i, got[i], h)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		header string
+		want   Algorithm
+		wantOk bool
+	}{
+		{"HashSHA1", SHA1, true},
+		{"HashSHA256", SHA256, true},
+		{"HashSHA512", SHA512, true},
+		{"HashBLAKE2B", BLAKE2b, true},
+		{"HashUnknown", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			got, ok := Lookup(tt.header)
+			if ok != tt.wantOk {
+				t.Fatalf("Lookup(%q) ok = %v, want %v", tt.header, ok, tt.wantOk)
+			}
+			if ok && got != tt.want {
+				t.Errorf("Lookup(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlgorithm_ComputeHashRoundTrip(t *testing.T) {
+	data := []byte("the quick brown fox")
+	key := "my-secret-key"
+
+	for _, algo := range []Algorithm{SHA1, SHA256, SHA512, BLAKE2b} {
+		t.Run(algo.Name(), func(t *testing.T) {
+			computed := ComputeHash(data, key, algo)
+			if computed == "" {
+				t.Fatalf("ComputeHash returned empty hash for %s", algo.Name())
+			}
+			if !ValidateHash(data, key, computed, algo) {
+				t.Errorf("ValidateHash rejected a hash %s computed itself", algo.Name())
+			}
+		})
+	}
+}
+
+func TestAlgorithm_DistinctOutputs(t *testing.T) {
+	data := []byte("the quick brown fox")
+	key := "my-secret-key"
+
+	seen := make(map[string]string)
+	for _, algo := range []Algorithm{SHA1, SHA256, SHA512, BLAKE2b} {
+		computed := ComputeHash(data, key, algo)
+		if other, ok := seen[computed]; ok {
+			t.Errorf("%s and %s produced the same hash %q", algo.Name(), other, computed)
+		}
+		seen[computed] = algo.Name()
+	}
+}
@@ -2,21 +2,18 @@ package hash
 
 import (
 	"crypto/hmac"
-	"crypto/sha256"
 	"encoding/hex"
 )
 
-// ComputeHash calculates the HMAC-SHA256 hash of the provided data using the given key.
-//
-// This function computes a hash-based message authentication code (HMAC) using
-// the SHA-256 algorithm. HMAC provides both data integrity and authenticity verification.
+// ComputeHash calculates the keyed hash of data under algo using key.
 //
 // Parameters:
 //   - data: Byte slice containing the data to be hashed
-//   - key: Secret key used for HMAC computation (empty string returns empty hash)
+//   - key: Secret key used for the keyed hash (empty string returns empty hash)
+//   - algo: Algorithm to hash with (e.g. SHA256)
 //
 // Returns:
-//   - string: Hexadecimal representation of the HMAC-SHA256 hash (64 characters)
+//   - string: Hexadecimal representation of the hash
 //   - Empty string if key is empty
 //
 // Use Cases:
@@ -28,26 +25,25 @@ import (
 //
 //	data := []byte(`{"id": "metric", "type": "gauge", "value": 75.5}`)
 //	key := "my-secret-key"
-//	hash := ComputeHash(data, key)
+//	hash := ComputeHash(data, key, SHA256)
 //	fmt.Println(hash) // Output: abc123... (64 hex characters)
-func ComputeHash(data []byte, key string) string {
+func ComputeHash(data []byte, key string, algo Algorithm) string {
 	if key == "" {
 		return ""
 	}
-	h := hmac.New(sha256.New, []byte(key))
+	h := algo.New([]byte(key))
 	h.Write(data)
 	return hex.EncodeToString(h.Sum(nil))
 }
 
-// ValidateHash verifies that the received HMAC-SHA256 hash matches the computed hash of the data.
-//
-// This function is used to verify data integrity and authenticity by comparing
-// a received hash with a newly computed hash of the same data using the shared secret key.
+// ValidateHash verifies that the received hash matches the computed hash of
+// the data under algo.
 //
 // Parameters:
 //   - data: Byte slice containing the original data
-//   - key: Secret key used for HMAC computation (empty string always returns true)
+//   - key: Secret key used for the keyed hash (empty string always returns true)
 //   - receivedHash: Hexadecimal string containing the hash to verify (empty returns false)
+//   - algo: Algorithm the receivedHash was computed with
 //
 // Returns:
 //   - bool: true if hashes match (or validation is skipped), false otherwise
@@ -64,14 +60,14 @@ func ComputeHash(data []byte, key string) string {
 //
 //	data := []byte(`{"id": "metric", "type": "gauge", "value": 75.5}`)
 //	key := "my-secret-key"
-//	receivedHash := ComputeHash(data, key) // In real scenario, this comes from client
+//	receivedHash := ComputeHash(data, key, SHA256) // In real scenario, this comes from client
 //
-//	if ValidateHash(data, key, receivedHash) {
+//	if ValidateHash(data, key, receivedHash, SHA256) {
 //	    fmt.Println("Hash is valid - data is authentic")
 //	} else {
 //	    fmt.Println("Hash is invalid - data may be tampered")
 //	}
-func ValidateHash(data []byte, key string, receivedHash string) bool {
+func ValidateHash(data []byte, key string, receivedHash string, algo Algorithm) bool {
 	if key == "" {
 		return true
 	}
@@ -80,6 +76,34 @@ func ValidateHash(data []byte, key string, receivedHash string) bool {
 		return false
 	}
 
-	expectedHash := ComputeHash(data, key)
+	expectedHash := ComputeHash(data, key, algo)
 	return hmac.Equal([]byte(expectedHash), []byte(receivedHash))
 }
+
+// Signer signs request bodies with a fixed key and Algorithm, returning the
+// header name/value pair a client should attach. It lets an operator roll
+// the signing key or swap algorithms by reconstructing a Signer, without a
+// fleet-wide restart of either side as long as the server still recognizes
+// the chosen Algorithm's header (see Lookup).
+type Signer struct {
+	Key       string
+	Algorithm Algorithm
+}
+
+// NewSigner creates a Signer for key using algo, defaulting to SHA256 if algo
+// is nil.
+func NewSigner(key string, algo Algorithm) Signer {
+	if algo == nil {
+		algo = SHA256
+	}
+	return Signer{Key: key, Algorithm: algo}
+}
+
+// Sign computes the signature header for data. It returns ("", "") if no key
+// is configured, so callers can skip setting the header entirely.
+func (s Signer) Sign(data []byte) (header, value string) {
+	if s.Key == "" {
+		return "", ""
+	}
+	return s.Algorithm.Name(), ComputeHash(data, s.Key, s.Algorithm)
+}
@@ -11,7 +11,7 @@ func BenchmarkComputeHash_Small(b *testing.B) {
 
 	b.ResetTimer()
 	for b.Loop() {
-		ComputeHash(data, key)
+		ComputeHash(data, key, SHA256)
 	}
 }
 
@@ -24,7 +24,7 @@ func BenchmarkComputeHash_Medium(b *testing.B) {
 
 	b.ResetTimer()
 	for b.Loop() {
-		ComputeHash(data, key)
+		ComputeHash(data, key, SHA256)
 	}
 }
 
@@ -37,18 +37,18 @@ func BenchmarkComputeHash_Large(b *testing.B) {
 
 	b.ResetTimer()
 	for b.Loop() {
-		ComputeHash(data, key)
+		ComputeHash(data, key, SHA256)
 	}
 }
 
 func BenchmarkValidateHash_Valid(b *testing.B) {
 	key := "secret-key"
 	data := []byte("test data")
-	validHash := ComputeHash(data, key)
+	validHash := ComputeHash(data, key, SHA256)
 
 	b.ResetTimer()
 	for b.Loop() {
-		ValidateHash(data, key, validHash)
+		ValidateHash(data, key, validHash, SHA256)
 	}
 }
 
@@ -59,6 +59,6 @@ func BenchmarkValidateHash_Invalid(b *testing.B) {
 
 	b.ResetTimer()
 	for b.Loop() {
-		ValidateHash(data, key, invalidHash)
+		ValidateHash(data, key, invalidHash, SHA256)
 	}
 }
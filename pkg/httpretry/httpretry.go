@@ -0,0 +1,209 @@
+// Package httpretry provides an http.RoundTripper that retries transient
+// failures with truncated exponential backoff, mirroring the approach
+// golang.org/x/crypto/acme uses for retrying ACME requests.
+package httpretry
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryBackoff computes the delay to wait before retry attempt n (n starts at
+// 1), given the request that was just attempted and the response it produced
+// (nil on network error). A non-positive return value aborts the retry loop
+// and the last response/error is returned to the caller.
+type RetryBackoff func(n int, req *http.Request, resp *http.Response) time.Duration
+
+const (
+	maxBackoff    = 10 * time.Second
+	maxBackoffJit = 1 * time.Second
+
+	// maxAttempts bounds the retry loop: once attempt n exceeds it,
+	// DefaultBackoff returns 0 and gives up, even on a plain network error
+	// (resp == nil) that isRetryableStatus never gets a chance to veto.
+	// Without this a persistently down server would retry forever.
+	maxAttempts = 8
+
+	// maxShift caps the exponent passed to 1<<n; n is already bounded by
+	// maxAttempts above, but this keeps the shift itself safe regardless.
+	maxShift = 32
+)
+
+// DefaultBackoff waits min(2^n, 10s) plus up to 1s of jitter for attempt n,
+// unless the response carries a Retry-After header (delta-seconds or
+// HTTP-date), in which case that value plus jitter takes precedence.
+// Non-retryable responses and exceeding maxAttempts both abort the loop by
+// returning 0; the maxAttempts check runs before the Retry-After lookup so a
+// server that keeps answering 429/503 with Retry-After can't honor its way
+// past the cap and retry forever, the same as a plain network error
+// (resp == nil) would without the cap.
+func DefaultBackoff(n int, req *http.Request, resp *http.Response) time.Duration {
+	if resp != nil && !isRetryableStatus(resp) {
+		return 0
+	}
+
+	if n > maxAttempts {
+		return 0
+	}
+
+	if resp != nil {
+		if d, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+			return d + jitter()
+		}
+	}
+
+	shift := n
+	if shift > maxShift {
+		shift = maxShift
+	}
+	d := time.Duration(1<<uint(shift)) * time.Second
+	if d > maxBackoff {
+		d = maxBackoff
+	}
+	return d + jitter()
+}
+
+func jitter() time.Duration {
+	return time.Duration(rand.Int63n(int64(maxBackoffJit)))
+}
+
+// retryAfter parses a Retry-After header value, which may be either a number
+// of delta-seconds or an HTTP-date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		if secs < 0 {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// isRetryableStatus reports whether a failed response should be retried. 5xx
+// responses, 408 Request Timeout and 429 Too Many Requests are retried; other
+// 4xx responses are not, except for a 400 carrying a "bad nonce" or "invalid
+// hash" style transient error, which lets a request survive a key-rotation
+// race with middleware.HashValidationMiddleware.
+func isRetryableStatus(resp *http.Response) bool {
+	switch {
+	case resp.StatusCode < 400:
+		return false
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode == http.StatusBadRequest:
+		return isTransientBadRequest(resp)
+	case resp.StatusCode < 500:
+		return false
+	default:
+		return true
+	}
+}
+
+// isTransientBadRequest detects a "bad nonce"/"invalid hash" style transient
+// condition in the response body, restoring the body afterwards so callers
+// can still read it.
+func isTransientBadRequest(resp *http.Response) bool {
+	if resp.Body == nil {
+		return false
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	s := strings.ToLower(string(body))
+	return strings.Contains(s, "bad nonce") || strings.Contains(s, "invalid hash")
+}
+
+// RoundTripper wraps Next, retrying failed requests using Backoff until a
+// request succeeds, Backoff aborts the loop (delay <= 0), or the request's
+// context is canceled.
+//
+// A retried request's body must be replayable: net/http populates
+// http.Request.GetBody automatically for bodies created from common in-memory
+// sources such as *bytes.Buffer, *bytes.Reader and *strings.Reader, and a nil
+// body is always replayable. Any other body makes a request ineligible for
+// retry; RoundTrip returns the first attempt's result unchanged in that case.
+type RoundTripper struct {
+	Next    http.RoundTripper
+	Backoff RetryBackoff
+}
+
+// NewRoundTripper creates a RoundTripper wrapping next with backoff. A nil
+// next uses http.DefaultTransport; a nil backoff uses DefaultBackoff.
+func NewRoundTripper(next http.RoundTripper, backoff RetryBackoff) *RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if backoff == nil {
+		backoff = DefaultBackoff
+	}
+	return &RoundTripper{Next: next, Backoff: backoff}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return rt.Next.RoundTrip(req)
+	}
+
+	for n := 1; ; n++ {
+		attempt := req
+		if n > 1 {
+			var err error
+			attempt, err = cloneRequest(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := rt.Next.RoundTrip(attempt)
+
+		delay := rt.Backoff(n, attempt, resp)
+		if delay <= 0 {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// cloneRequest rebuilds req for a retry attempt, rewinding its body via
+// GetBody so the original request is left untouched.
+func cloneRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("httpretry: request body is not replayable (GetBody is nil)")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("httpretry: failed to rewind request body: %w", err)
+	}
+	clone.Body = body
+	return clone, nil
+}
@@ -0,0 +1,229 @@
+package httpretry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func noJitterBackoff(n int, req *http.Request, resp *http.Response) time.Duration {
+	d := DefaultBackoff(n, req, resp)
+	if d <= 0 {
+		return d
+	}
+	return time.Millisecond
+}
+
+func TestRoundTripper_RetriesUntilSuccess(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewRoundTripper(nil, noJitterBackoff)}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("final status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+}
+
+func TestRoundTripper_NonRetryableStatusStopsImmediately(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewRoundTripper(nil, noJitterBackoff)}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("final status = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("attempts = %d, want 1 (no retry for non-retryable status)", got)
+	}
+}
+
+func TestRoundTripper_RetriesBadRequestWithTransientBody(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte("invalid hash signature"))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewRoundTripper(nil, noJitterBackoff)}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("final status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+}
+
+func TestRoundTripper_RetriesReplayableBody(t *testing.T) {
+	var attempts int32
+	var lastBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := make([]byte, 64)
+		n, _ := r.Body.Read(buf)
+		lastBody = string(buf[:n])
+		if atomic.AddInt32(&attempts, 1) < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewRoundTripper(nil, noJitterBackoff)}
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("Post failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("final status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if lastBody != "payload" {
+		t.Errorf("server saw body %q on final attempt, want %q", lastBody, "payload")
+	}
+}
+
+func TestRoundTripper_ContextCancelStopsRetries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: NewRoundTripper(nil, noJitterBackoff)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("expected an error once the context deadline is exceeded, got nil")
+	}
+}
+
+func TestDefaultBackoff_GivesUpAfterMaxAttempts(t *testing.T) {
+	for _, n := range []int{maxAttempts, maxAttempts + 1, maxShift + 1} {
+		d := DefaultBackoff(n, nil, nil)
+		wantZero := n > maxAttempts
+		if (d <= 0) != wantZero {
+			t.Errorf("DefaultBackoff(%d, nil, nil) = %v, want zero = %v", n, d, wantZero)
+		}
+	}
+}
+
+// TestDefaultBackoff_MaxAttemptsOverridesRetryAfter guards against the
+// maxAttempts cap being bypassed by a response that honors Retry-After: a
+// server that keeps answering 503+Retry-After must still be given up on
+// once maxAttempts is exceeded, the same as a plain network error.
+func TestDefaultBackoff_MaxAttemptsOverridesRetryAfter(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Retry-After": []string{"1"}},
+	}
+
+	if d := DefaultBackoff(maxAttempts+1, nil, resp); d > 0 {
+		t.Errorf("DefaultBackoff(maxAttempts+1, nil, resp with Retry-After) = %v, want <= 0", d)
+	}
+	if d := DefaultBackoff(maxAttempts, nil, resp); d <= 0 {
+		t.Errorf("DefaultBackoff(maxAttempts, nil, resp with Retry-After) = %v, want > 0", d)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		wantOk bool
+	}{
+		{"empty", "", false},
+		{"seconds", "5", true},
+		{"negative seconds", "-1", false},
+		{"http date", time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), true},
+		{"garbage", "not-a-date", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, ok := retryAfter(tt.value)
+			if ok != tt.wantOk {
+				t.Errorf("retryAfter(%q) ok = %v, want %v", tt.value, ok, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestIsRetryableStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   bool
+	}{
+		{http.StatusOK, "", false},
+		{http.StatusNotFound, "", false},
+		{http.StatusRequestTimeout, "", true},
+		{http.StatusTooManyRequests, "", true},
+		{http.StatusBadRequest, "malformed request", false},
+		{http.StatusBadRequest, "bad nonce", true},
+		{http.StatusBadRequest, "invalid hash signature", true},
+		{http.StatusInternalServerError, "", true},
+		{http.StatusBadGateway, "", true},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{
+			StatusCode: tt.status,
+			Body:       http.NoBody,
+		}
+		if tt.body != "" {
+			resp.Body = io.NopCloser(strings.NewReader(tt.body))
+		}
+		if got := isRetryableStatus(resp); got != tt.want {
+			t.Errorf("isRetryableStatus(%d, %q) = %v, want %v", tt.status, tt.body, got, tt.want)
+		}
+	}
+}
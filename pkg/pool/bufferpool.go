@@ -0,0 +1,132 @@
+package pool
+
+import (
+	"bytes"
+	"expvar"
+	"sync"
+	"sync/atomic"
+)
+
+// bufferShardCaps are the capacity classes a BufferPool buckets *bytes.Buffer
+// values into, each a power of two. Get(sizeHint) and Put route to the
+// smallest class that fits, so pooling one oversized buffer doesn't pin that
+// much memory for every future Get regardless of how small a buffer is
+// actually needed.
+var bufferShardCaps = []int{64, 256, 1024, 4096, 16384, 65536}
+
+// shardCounters tracks one shard's Get/New/drop activity. Always accessed
+// through a pointer; copying would duplicate the atomic values.
+type shardCounters struct {
+	gets   atomic.Int64
+	misses atomic.Int64
+	drops  atomic.Int64
+}
+
+// ShardStats is a point-in-time snapshot of one capacity shard's counters.
+type ShardStats struct {
+	CapClass int
+	Hits     int64
+	Misses   int64
+	Drops    int64
+}
+
+// BufferPool pools *bytes.Buffer values sharded by capacity class (see
+// bufferShardCaps) instead of a single undifferentiated sync.Pool, so that a
+// handful of oversized buffers can't pin that much memory for every future
+// Get regardless of the size actually needed.
+type BufferPool struct {
+	// MaxCap bounds the capacity of buffers this pool retains; a buffer
+	// larger than MaxCap is dropped on Put instead of pooled. Zero means no
+	// limit.
+	MaxCap int
+
+	shards []sync.Pool
+	stats  []shardCounters
+}
+
+// NewBufferPool creates a BufferPool bucketed into bufferShardCaps capacity
+// classes. If name is non-empty, the pool's PoolStats are additionally
+// published under that key via expvar for operators to scrape; name must be
+// unique per process, and a duplicate name is silently skipped rather than
+// panicking (expvar.Publish panics on redefinition).
+func NewBufferPool(name string, maxCap int) *BufferPool {
+	p := &BufferPool{
+		MaxCap: maxCap,
+		shards: make([]sync.Pool, len(bufferShardCaps)),
+		stats:  make([]shardCounters, len(bufferShardCaps)),
+	}
+	for i, capClass := range bufferShardCaps {
+		capClass := capClass
+		stats := &p.stats[i]
+		p.shards[i].New = func() interface{} {
+			stats.misses.Add(1)
+			return bytes.NewBuffer(make([]byte, 0, capClass))
+		}
+	}
+	if name != "" && expvar.Get(name) == nil {
+		expvar.Publish(name, expvar.Func(func() interface{} { return p.PoolStats() }))
+	}
+	return p
+}
+
+// shardIndex returns the index of the smallest capacity class that fits
+// size, and false if size exceeds every class.
+func shardIndex(size int) (idx int, ok bool) {
+	for i, capClass := range bufferShardCaps {
+		if size <= capClass {
+			return i, true
+		}
+	}
+	return len(bufferShardCaps) - 1, false
+}
+
+// Get returns a *bytes.Buffer with at least sizeHint bytes of capacity, from
+// the smallest shard that satisfies it. A sizeHint larger than every shard's
+// capacity class bypasses the pool entirely and allocates a fresh buffer.
+func (p *BufferPool) Get(sizeHint int) *bytes.Buffer {
+	idx, ok := shardIndex(sizeHint)
+	if !ok {
+		return bytes.NewBuffer(make([]byte, 0, sizeHint))
+	}
+
+	p.stats[idx].gets.Add(1)
+	buf := p.shards[idx].Get().(*bytes.Buffer)
+	if buf.Cap() < sizeHint {
+		return bytes.NewBuffer(make([]byte, 0, sizeHint))
+	}
+	return buf
+}
+
+// Put resets buf and returns it to the shard matching its current capacity,
+// or drops it if that capacity exceeds every shard class or MaxCap.
+func (p *BufferPool) Put(buf *bytes.Buffer) {
+	if buf == nil {
+		return
+	}
+	buf.Reset()
+
+	idx, ok := shardIndex(buf.Cap())
+	if !ok || (p.MaxCap > 0 && buf.Cap() > p.MaxCap) {
+		p.stats[len(p.stats)-1].drops.Add(1)
+		return
+	}
+	p.shards[idx].Put(buf)
+}
+
+// PoolStats returns a snapshot of each shard's hit/miss/drop counters. Hits
+// are derived as Gets minus Misses, where a miss is a Get that found its
+// shard empty and fell through to sync.Pool's New.
+func (p *BufferPool) PoolStats() []ShardStats {
+	out := make([]ShardStats, len(bufferShardCaps))
+	for i, capClass := range bufferShardCaps {
+		gets := p.stats[i].gets.Load()
+		misses := p.stats[i].misses.Load()
+		out[i] = ShardStats{
+			CapClass: capClass,
+			Hits:     gets - misses,
+			Misses:   misses,
+			Drops:    p.stats[i].drops.Load(),
+		}
+	}
+	return out
+}
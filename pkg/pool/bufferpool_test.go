@@ -0,0 +1,148 @@
+package pool
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestShardIndex(t *testing.T) {
+	tests := []struct {
+		size    int
+		wantIdx int
+		wantOk  bool
+	}{
+		{0, 0, true},
+		{64, 0, true},
+		{65, 1, true},
+		{1024, 2, true},
+		{1025, 3, true},
+		{65536, 5, true},
+		{65537, 5, false},
+	}
+
+	for _, tt := range tests {
+		idx, ok := shardIndex(tt.size)
+		if idx != tt.wantIdx || ok != tt.wantOk {
+			t.Errorf("shardIndex(%d) = (%d, %v), want (%d, %v)", tt.size, idx, ok, tt.wantIdx, tt.wantOk)
+		}
+	}
+}
+
+func TestBufferPool_GetPutRoundTrip(t *testing.T) {
+	p := NewBufferPool("", 0)
+
+	buf := p.Get(100)
+	if buf.Cap() < 100 {
+		t.Fatalf("Get(100) returned capacity %d, want >= 100", buf.Cap())
+	}
+	buf.WriteString("hello")
+
+	p.Put(buf)
+
+	buf2 := p.Get(100)
+	if buf2.Len() != 0 {
+		t.Errorf("expected Get to return a Reset buffer, got length %d", buf2.Len())
+	}
+}
+
+func TestBufferPool_OversizedGetBypassesPool(t *testing.T) {
+	p := NewBufferPool("", 0)
+
+	buf := p.Get(1 << 20)
+	if buf.Cap() < 1<<20 {
+		t.Fatalf("Get(1<<20) returned capacity %d, want >= %d", buf.Cap(), 1<<20)
+	}
+}
+
+func TestBufferPool_DropsBuffersAboveMaxCap(t *testing.T) {
+	p := NewBufferPool("", 1024)
+
+	big := bytes.NewBuffer(make([]byte, 0, 65536))
+	p.Put(big)
+
+	stats := p.PoolStats()
+	var drops int64
+	for _, s := range stats {
+		drops += s.Drops
+	}
+	if drops != 1 {
+		t.Errorf("expected 1 drop for an over-MaxCap buffer, got %d", drops)
+	}
+}
+
+func TestBufferPool_PoolStatsTracksHitsAndMisses(t *testing.T) {
+	p := NewBufferPool("", 0)
+
+	// First Get for this size finds the shard empty: a miss.
+	buf := p.Get(100)
+	p.Put(buf)
+
+	// Second Get reuses the buffer just returned: a hit.
+	p.Get(100)
+
+	stats := p.PoolStats()
+	idx, _ := shardIndex(100)
+	if stats[idx].Misses != 1 {
+		t.Errorf("Misses = %d, want 1", stats[idx].Misses)
+	}
+	if stats[idx].Hits != 1 {
+		t.Errorf("Hits = %d, want 1", stats[idx].Hits)
+	}
+}
+
+func TestBufferPool_PublishesExpvarStatsOnce(t *testing.T) {
+	p1 := NewBufferPool("test-bufferpool-expvar", 0)
+	// Constructing a second pool under the same name must not panic
+	// (expvar.Publish panics on redefinition).
+	p2 := NewBufferPool("test-bufferpool-expvar", 0)
+
+	if p1 == p2 {
+		t.Fatal("expected two distinct BufferPool instances")
+	}
+}
+
+// mixedSizes mirrors a realistic mix of small request/response bodies with
+// an occasional large batch payload.
+func mixedSizes(n int) []int {
+	sizes := make([]int, n)
+	r := rand.New(rand.NewSource(1))
+	for i := range sizes {
+		if i%50 == 0 {
+			sizes[i] = 32 * 1024 // occasional large buffer
+		} else {
+			sizes[i] = 32 + r.Intn(200) // typical small body
+		}
+	}
+	return sizes
+}
+
+func BenchmarkBufferPool_MixedSizes(b *testing.B) {
+	p := NewBufferPool("", 64*1024)
+	sizes := mixedSizes(1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		size := sizes[i%len(sizes)]
+		buf := p.Get(size)
+		buf.Write(make([]byte, size))
+		p.Put(buf)
+	}
+}
+
+// BenchmarkPlainPool_MixedSizes exercises the original single-shard
+// pool.New[*bytes.Buffer] under the same mixed workload: every Put keeps
+// whatever capacity the buffer grew to, so one oversized buffer a caller
+// happens to Put can be handed back on every subsequent Get indefinitely.
+func BenchmarkPlainPool_MixedSizes(b *testing.B) {
+	plain := New(func() *bytes.Buffer { return &bytes.Buffer{} })
+	sizes := mixedSizes(1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		size := sizes[i%len(sizes)]
+		buf := plain.Get()
+		buf.Write(make([]byte, size))
+		plain.Put(buf)
+	}
+}
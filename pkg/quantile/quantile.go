@@ -0,0 +1,194 @@
+// Package quantile implements a streaming biased-quantile estimator using
+// the Cormode-Korolova-Muthukrishnan (Greenwald-Khanna biased variant)
+// algorithm - the same one github.com/beorn7/perks/quantile exposes. It
+// answers quantile queries within a configurable per-quantile rank error
+// using memory sublinear in the number of observations.
+package quantile
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// Targets maps a target quantile (in (0, 1)) to its acceptable rank error
+// epsilon.
+type Targets map[float64]float64
+
+// DefaultEpsilon is the default rank-error budget used by NewTargets.
+const DefaultEpsilon = 0.01
+
+// DefaultTargets returns the package's default target quantiles - p50, p90,
+// p99 - at DefaultEpsilon rank error.
+func DefaultTargets() Targets {
+	return NewTargets(DefaultEpsilon, 0.5, 0.9, 0.99)
+}
+
+// NewTargets builds a Targets map assigning the same rank-error epsilon to
+// every quantile in quantiles.
+func NewTargets(epsilon float64, quantiles ...float64) Targets {
+	t := make(Targets, len(quantiles))
+	for _, q := range quantiles {
+		t[q] = epsilon
+	}
+	return t
+}
+
+// sample is one (value, rank-error g, Δ) tuple in the sketch's sorted
+// sample list, following the paper's notation: g is the minimum possible
+// rank error introduced since the previous sample, and Δ is the maximum
+// possible rank error.
+type sample struct {
+	value float64
+	g     float64
+	delta float64
+}
+
+// Estimator is a streaming quantile estimator. The zero value is not usable;
+// construct one with NewEstimator. Safe for concurrent use.
+type Estimator struct {
+	mu      sync.Mutex
+	targets Targets
+	samples []sample
+	n       float64
+
+	// compressEvery controls how often (in inserts) a compression pass
+	// runs over the sample list, bounding its size.
+	compressEvery int
+	sinceCompress int
+}
+
+// NewEstimator creates an Estimator for the given per-quantile rank-error
+// targets. A nil or empty targets map falls back to DefaultTargets.
+func NewEstimator(targets Targets) *Estimator {
+	if len(targets) == 0 {
+		targets = DefaultTargets()
+	}
+	return &Estimator{targets: targets, compressEvery: 128}
+}
+
+// Insert adds an observed value to the sketch.
+func (e *Estimator) Insert(v float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	i := sort.Search(len(e.samples), func(i int) bool { return e.samples[i].value >= v })
+
+	var delta float64
+	if i > 0 && i < len(e.samples) {
+		var rank float64
+		for _, s := range e.samples[:i] {
+			rank += s.g
+		}
+		delta = math.Floor(e.invariant(rank)) - 1
+		if delta < 0 {
+			delta = 0
+		}
+	}
+
+	e.samples = append(e.samples, sample{})
+	copy(e.samples[i+1:], e.samples[i:])
+	e.samples[i] = sample{value: v, g: 1, delta: delta}
+
+	e.n++
+	e.sinceCompress++
+	if e.sinceCompress >= e.compressEvery {
+		e.compress()
+		e.sinceCompress = 0
+	}
+}
+
+// invariant is the target error function f(r, n), minimized across every
+// configured target quantile so the tightest constraint wins: a tuple can
+// merge with its neighbor only while g+Δ stays within floor(f(r, n)).
+func (e *Estimator) invariant(r float64) float64 {
+	min := math.Inf(1)
+	for q, epsilon := range e.targets {
+		var f float64
+		if r >= q*e.n {
+			f = 2 * epsilon * r / q
+		} else {
+			f = 2 * epsilon * (e.n - r) / (1 - q)
+		}
+		if f < min {
+			min = f
+		}
+	}
+	if min < 1 {
+		min = 1
+	}
+	return min
+}
+
+// compress removes tuples where g_i + g_{i+1} + Δ_{i+1} <= floor(f(r, n)),
+// merging their rank error into the surviving neighbor. Must be called with
+// e.mu held.
+func (e *Estimator) compress() {
+	if len(e.samples) < 2 {
+		return
+	}
+
+	r := e.samples[0].g
+	for i := 1; i < len(e.samples)-1; {
+		next := e.samples[i+1]
+		combined := e.samples[i].g + next.g + next.delta
+		if combined <= math.Floor(e.invariant(r)) {
+			next.g += e.samples[i].g
+			e.samples[i+1] = next
+			e.samples = append(e.samples[:i], e.samples[i+1:]...)
+			continue
+		}
+		r += e.samples[i].g
+		i++
+	}
+}
+
+// Query returns the estimated value at quantile q (0 <= q <= 1), walking the
+// sample list and summing g until the accumulated rank r satisfies
+// r >= ceil(q*n) - floor(f(q*n, n)/2).
+func (e *Estimator) Query(q float64) float64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if len(e.samples) == 0 {
+		return 0
+	}
+
+	target := math.Ceil(q*e.n) - math.Floor(e.invariant(q*e.n)/2)
+
+	var r float64
+	for i, s := range e.samples {
+		r += s.g
+		if r+s.delta > target {
+			if i == 0 {
+				return s.value
+			}
+			return e.samples[i-1].value
+		}
+	}
+
+	return e.samples[len(e.samples)-1].value
+}
+
+// QueryAll returns the estimated value at every quantile in e's targets.
+func (e *Estimator) QueryAll() map[float64]float64 {
+	e.mu.Lock()
+	targets := make([]float64, 0, len(e.targets))
+	for q := range e.targets {
+		targets = append(targets, q)
+	}
+	e.mu.Unlock()
+
+	result := make(map[float64]float64, len(targets))
+	for _, q := range targets {
+		result[q] = e.Query(q)
+	}
+	return result
+}
+
+// Count returns the number of observations inserted so far.
+func (e *Estimator) Count() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return uint64(e.n)
+}
@@ -0,0 +1,116 @@
+package quantile
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestEstimator_EmptyAndSingleValue(t *testing.T) {
+	e := NewEstimator(nil)
+
+	if got := e.Count(); got != 0 {
+		t.Errorf("Count() on empty estimator = %d, want 0", got)
+	}
+	if got := e.Query(0.5); got != 0 {
+		t.Errorf("Query(0.5) on empty estimator = %v, want 0", got)
+	}
+	if all := e.QueryAll(); len(all) == 0 {
+		t.Error("QueryAll() on empty estimator returned no targets")
+	} else {
+		for q, v := range all {
+			if v != 0 {
+				t.Errorf("QueryAll()[%v] on empty estimator = %v, want 0", q, v)
+			}
+		}
+	}
+
+	e.Insert(42)
+	if got := e.Count(); got != 1 {
+		t.Errorf("Count() after one Insert = %d, want 1", got)
+	}
+	for q := range e.targets {
+		if got := e.Query(q); got != 42 {
+			t.Errorf("Query(%v) after one Insert = %v, want 42", q, got)
+		}
+	}
+}
+
+// TestEstimator_RankErrorWithinEpsilon inserts a known uniform distribution
+// and checks every target quantile's estimate against the exact rank,
+// allowing the configured epsilon's worth of rank error.
+func TestEstimator_RankErrorWithinEpsilon(t *testing.T) {
+	const n = 100_000
+	const epsilon = 0.01
+
+	targets := NewTargets(epsilon, 0.5, 0.9, 0.99)
+	e := NewEstimator(targets)
+
+	rng := rand.New(rand.NewSource(1))
+	values := make([]float64, n)
+	for i := range values {
+		v := rng.Float64() * 1000
+		values[i] = v
+		e.Insert(v)
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	for q := range targets {
+		got := e.Query(q)
+
+		wantRank := int(q * float64(len(sorted)))
+		if wantRank >= len(sorted) {
+			wantRank = len(sorted) - 1
+		}
+		want := sorted[wantRank]
+
+		// Convert got back to a rank via binary search, and compare ranks
+		// rather than values - the distribution is dense enough that a
+		// rank-error bound translates directly into a rank-error check.
+		// The bound itself is f(r, n) = 2*epsilon*r/q (or its mirror image
+		// past the target rank), the same target function compress uses -
+		// not a flat epsilon*n, which only holds at the extreme quantiles.
+		gotRank := sort.SearchFloat64s(sorted, got)
+		maxRankErr := int(math.Ceil(e.invariant(float64(wantRank))))
+
+		if diff := gotRank - wantRank; diff < -maxRankErr || diff > maxRankErr {
+			t.Errorf("quantile %v: rank(Query) = %d, want within %d of %d (value got=%v want=%v)",
+				q, gotRank, maxRankErr, wantRank, got, want)
+		}
+	}
+}
+
+func TestEstimator_CompressBoundsSampleCount(t *testing.T) {
+	e := NewEstimator(DefaultTargets())
+
+	rng := rand.New(rand.NewSource(2))
+	for i := 0; i < 50_000; i++ {
+		e.Insert(rng.Float64())
+	}
+
+	// The sketch is sublinear: with DefaultEpsilon and three targets the
+	// sample list should stay a small fraction of n, not grow with it.
+	if got := len(e.samples); got > 5000 {
+		t.Errorf("len(samples) = %d, want a small fraction of 50000 observations", got)
+	}
+}
+
+func TestEstimator_MonotonicQuantiles(t *testing.T) {
+	e := NewEstimator(NewTargets(0.01, 0.1, 0.5, 0.9))
+
+	rng := rand.New(rand.NewSource(3))
+	for i := 0; i < 10_000; i++ {
+		e.Insert(rng.NormFloat64())
+	}
+
+	p10 := e.Query(0.1)
+	p50 := e.Query(0.5)
+	p90 := e.Query(0.9)
+
+	if !(p10 <= p50 && p50 <= p90) {
+		t.Errorf("quantiles not monotonic: p10=%v p50=%v p90=%v", p10, p50, p90)
+	}
+}